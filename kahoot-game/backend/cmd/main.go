@@ -4,89 +4,126 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
 	"kahoot-game/internal/config"
 	"kahoot-game/internal/database"
 	"kahoot-game/internal/handlers"
+	"kahoot-game/internal/logging"
+	"kahoot-game/internal/metrics"
 	"kahoot-game/internal/services"
 	"kahoot-game/internal/websocket"
 
 	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
 	"github.com/joho/godotenv"
 )
 
+// version 建置版本，發佈時以 -ldflags "-X main.version=..." 設定
+var version = "dev"
+
 func main() {
 	// 載入環境變數
-	if err := godotenv.Load(); err != nil {
-		log.Println("警告: 無法載入 .env 文件，使用系統環境變數")
-	}
+	envErr := godotenv.Load()
 
 	// 載入配置
-	cfg := config.Load()
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ 配置錯誤: %v\n", err)
+		os.Exit(1)
+	}
+
+	// 子命令（例如匯入 / 匯出題目包）
+	if len(os.Args) > 1 {
+		os.Exit(runCommand(cfg, os.Args[1:]))
+	}
+
+	// 初始化日誌
+	logger, err := logging.New(cfg.Log, os.Stdout)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ 日誌設定錯誤: %v\n", err)
+		os.Exit(1)
+	}
+	slog.SetDefault(logger)
+
+	if envErr != nil {
+		logger.Warn("無法載入 .env 文件，使用系統環境變數")
+	}
+	if cfg.AdminToken == "" {
+		logger.Warn("未設定 ADMIN_TOKEN，新增、修改與刪除題目的 API 已停用")
+	}
 
 	// 設置 Gin 模式
 	if cfg.Environment == "production" {
 		gin.SetMode(gin.ReleaseMode)
 	}
 
-	// 初始化 Redis
-	redisClient := database.NewRedisClient(cfg)
-
-	// 測試 Redis 連線
-	ctx := context.Background()
-	if err := redisClient.Ping(ctx).Err(); err != nil {
-		log.Printf("⚠️ 無法連接 Redis: %v", err)
-		// 根據需求決定是否要 panic，這裡我們先繼續，讓 Service 決定是否降級
-	} else {
-		log.Println("✅ Redis 連線成功")
+	// 初始化房間存放（Redis 或記憶體）
+	roomStore, redisClient, err := newRoomStore(cfg, logger)
+	if err != nil {
+		logger.Error("房間存放設定錯誤", logging.Err(err))
+		os.Exit(1)
 	}
 
 	// 初始化資料庫
 	var db *sql.DB
-	var err error
 
 	// 嘗試連接資料庫
 	db, err = database.NewPostgresDB(cfg)
 	if err != nil {
-		log.Printf("⚠️ 無法連接資料庫: %v", err)
-		log.Println("⚠️ 將使用記憶體模式運行")
+		logger.Warn("無法連接資料庫，將使用記憶體模式運行", logging.Err(err))
 	} else {
-		log.Println("✅ 資料庫連線成功")
+		logger.Info("資料庫連線成功")
 
 		// 自動遷移表格
 		if err := database.CreateTables(db); err != nil {
-			log.Printf("⚠️ 創建表格失敗: %v", err)
+			logger.Error("創建表格失敗", logging.Err(err))
 		}
 
 		// 插入種子數據
 		if err := database.SeedQuestions(db); err != nil {
-			log.Printf("⚠️ 插入種子數據失敗: %v", err)
+			logger.Error("插入種子數據失敗", logging.Err(err))
 		}
 	}
 
 	// 初始化服務層
-	gameService := services.NewGameService(db, redisClient)
-	roomService := services.NewRoomService(redisClient, gameService)
-	questionService := services.NewQuestionService(db)
+	questionService := services.NewQuestionService(db, logger)
+	if err := questionService.SeedBuiltinQuestions(); err != nil {
+		logger.Error("插入「2種人」種子題目失敗", logging.Err(err))
+	}
+	gameService := services.NewGameService(db, redisClient, questionService, logger)
+	roomLogService := services.NewRoomLogService(db, logger)
+	roomService := services.NewRoomService(roomStore, gameService, roomLogService, cfg.Game, logger)
+	hostTokenService := services.NewHostTokenService(cfg.JWTSecret, cfg.HostTokenTTL)
 
 	// 初始化 WebSocket Hub
-	wsHub := websocket.NewHub(roomService, gameService, cfg.FrontendURL)
+	wsHub := websocket.NewHub(roomService, gameService, roomLogService, hostTokenService, redisClient, cfg.FrontendURL, cfg.WebSocket, logger)
 	go wsHub.Run()
+	metrics.RegisterHub(wsHub)
+
+	// 定期回收閒置房間
+	janitor := wsHub.StartJanitor(services.RoomReapPolicy{
+		EmptyAfter:    cfg.Janitor.EmptyTTL,
+		FinishedAfter: cfg.Janitor.FinishedTTL,
+		IdleAfter:     cfg.Janitor.IdleTTL,
+	}, cfg.Janitor.Interval)
+	defer janitor.Stop()
 
 	// 初始化處理器
 	gameHandler := handlers.NewGameHandler(gameService)
-	roomHandler := handlers.NewRoomHandler(roomService, cfg.FrontendURL)
+	roomHandler := handlers.NewRoomHandler(roomService, roomLogService, hostTokenService, cfg.FrontendURL)
 	questionHandler := handlers.NewQuestionHandler(questionService)
 	wsHandler := handlers.NewWebSocketHandler(wsHub)
+	healthHandler := handlers.NewHealthHandler(services.NewHealthService(db, redisClient, roomStore, version), wsHub)
 
 	// 設置路由
-	router := setupRoutes(cfg, gameHandler, roomHandler, questionHandler, wsHandler)
+	router := setupRoutes(cfg, gameHandler, roomHandler, questionHandler, wsHandler, healthHandler)
 
 	// 創建 HTTP 服務器
 	server := &http.Server{
@@ -96,12 +133,14 @@ func main() {
 
 	// 在 goroutine 中啟動服務器
 	go func() {
-		log.Printf("🚀 服務器啟動在 http://%s:%s", cfg.Host, cfg.Port)
-		log.Printf("📡 WebSocket 端點: ws://%s:%s/ws", cfg.Host, cfg.Port)
-		log.Printf("🎮 API 文檔: http://%s:%s/api/health", cfg.Host, cfg.Port)
+		logger.Info("服務器啟動",
+			"addr", fmt.Sprintf("http://%s:%s", cfg.Host, cfg.Port),
+			"websocket", fmt.Sprintf("ws://%s:%s/ws", cfg.Host, cfg.Port),
+			"environment", cfg.Environment)
 
 		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatalf("❌ 服務器啟動失敗: %v", err)
+			logger.Error("服務器啟動失敗", logging.Err(err))
+			os.Exit(1)
 		}
 	}()
 
@@ -110,34 +149,72 @@ func main() {
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 
-	log.Println("🔄 正在關閉服務器...")
+	logger.Info("正在關閉服務器")
 
 	// 優雅關閉，超時 5 秒
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
 	if err := server.Shutdown(ctx); err != nil {
-		log.Fatalf("❌ 服務器關閉失敗: %v", err)
+		logger.Error("服務器關閉失敗", logging.Err(err))
+		os.Exit(1)
 	}
 
-	log.Println("✅ 服務器已關閉")
+	logger.Info("服務器已關閉")
 }
 
-func setupRoutes(cfg *config.Config, gameHandler *handlers.GameHandler, roomHandler *handlers.RoomHandler, questionHandler *handlers.QuestionHandler, wsHandler *handlers.WebSocketHandler) *gin.Engine {
+// newRoomStore 依 ROOM_STORE 選擇房間存放位置
+// auto：Redis 可連線時使用 Redis，否則使用記憶體；redis：Redis 無法連線時啟動失敗；memory：不使用 Redis。
+// 回傳的 redisClient 為 nil 代表不使用 Redis，房間訊息只在本節點內投遞
+func newRoomStore(cfg *config.Config, logger *slog.Logger) (services.RoomStore, *redis.Client, error) {
+	backend := strings.ToLower(strings.TrimSpace(cfg.Storage.Backend))
+	switch backend {
+	case services.StorageModeMemory:
+		logger.Info("房間資料存放在記憶體，未使用 Redis")
+		return services.NewMemoryRoomStore(), nil, nil
+	case "", "auto", services.StorageModeRedis:
+	default:
+		return nil, nil, fmt.Errorf("不支援的 ROOM_STORE: %q（可用 auto、redis、memory）", cfg.Storage.Backend)
+	}
+
+	redisClient, err := database.NewRedisClient(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := redisClient.Ping(ctx).Err(); err != nil {
+		redisClient.Close()
+		if backend == services.StorageModeRedis {
+			return nil, nil, fmt.Errorf("無法連接 Redis: %w", err)
+		}
+		logger.Warn("無法連接 Redis，房間資料改存放在記憶體（只支援單一實例）", logging.Err(err))
+		return services.NewMemoryRoomStore(), nil, nil
+	}
+	logger.Info("Redis 連線成功")
+
+	redisStore := services.NewRedisRoomStore(redisClient, logger)
+	if cfg.Storage.Fallback {
+		logger.Info("已啟用房間存放自動切換，Redis 無法存取時改用記憶體", "retryInterval", cfg.Storage.RetryInterval)
+		return services.NewFallbackRoomStore(redisStore, cfg.Storage.RetryInterval, logger), redisClient, nil
+	}
+	return redisStore, redisClient, nil
+}
+
+func setupRoutes(cfg *config.Config, gameHandler *handlers.GameHandler, roomHandler *handlers.RoomHandler, questionHandler *handlers.QuestionHandler, wsHandler *handlers.WebSocketHandler, healthHandler *handlers.HealthHandler) *gin.Engine {
 	router := gin.Default()
 
 	// CORS 中間件
 	router.Use(corsMiddleware(cfg.CORSOrigins))
 
-	// 健康檢查端點
-	router.GET("/api/health", func(c *gin.Context) {
-		c.JSON(http.StatusOK, gin.H{
-			"status":    "healthy",
-			"service":   "kahoot-game-backend",
-			"version":   "1.0.0",
-			"timestamp": time.Now().UTC(),
-		})
-	})
+	// 健康檢查端點：/healthz 為存活檢查，/readyz 與 /api/health 會檢查 Redis 與 PostgreSQL
+	router.GET("/healthz", healthHandler.Liveness)
+	router.GET("/readyz", healthHandler.Readiness)
+	router.GET("/api/health", healthHandler.Readiness)
+
+	// Prometheus 指標
+	router.GET("/metrics", gin.WrapH(metrics.Handler()))
 
 	// API 路由群組
 	api := router.Group("/api")
@@ -146,15 +223,32 @@ func setupRoutes(cfg *config.Config, gameHandler *handlers.GameHandler, roomHand
 		api.GET("/games", gameHandler.GetActiveGames)
 		api.GET("/games/:gameId/stats", gameHandler.GetGameStats)
 
+		// 連線統計
+		api.GET("/stats", wsHandler.GetHubStats)
+
 		// 房間相關
+		api.GET("/rooms", roomHandler.ListRooms)
 		api.POST("/rooms", roomHandler.CreateRoom)
 		api.GET("/rooms/:roomId", roomHandler.GetRoom)
 		api.DELETE("/rooms/:roomId", roomHandler.DeleteRoom)
+		api.PUT("/rooms/:roomId/passcode", roomHandler.SetPasscode)
+		api.DELETE("/rooms/:roomId/passcode", roomHandler.ClearPasscode)
+		api.GET("/rooms/:roomId/events", roomHandler.GetRoomEvents)
 
 		// 題目相關
 		api.GET("/questions", questionHandler.GetQuestions)
 		api.GET("/questions/random/:count", questionHandler.GetRandomQuestions)
-		api.POST("/questions", questionHandler.CreateQuestion)
+		api.GET("/questions/export", questionHandler.ExportQuestions)
+		api.GET("/questions/:questionId", questionHandler.GetQuestion)
+
+		// 修改題庫需要管理憑證
+		admin := api.Group("/questions", handlers.RequireAdminToken(cfg.AdminToken))
+		admin.POST("", questionHandler.CreateQuestion)
+		admin.POST("/import", questionHandler.ImportQuestions)
+		admin.PUT("/:questionId", questionHandler.UpdateQuestion)
+		admin.DELETE("/:questionId", questionHandler.DeleteQuestion)
+		admin.POST("/:questionId/deactivate", questionHandler.DeactivateQuestion)
+		admin.POST("/:questionId/activate", questionHandler.ActivateQuestion)
 	}
 
 	// WebSocket 端點
@@ -187,7 +281,7 @@ func corsMiddleware(origins []string) gin.HandlerFunc {
 		}
 
 		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		c.Header("Access-Control-Allow-Headers", "Origin, Content-Type, Accept, Authorization")
+		c.Header("Access-Control-Allow-Headers", "Origin, Content-Type, Accept, Authorization, X-Room-Passcode")
 		c.Header("Access-Control-Allow-Credentials", "true")
 
 		if c.Request.Method == "OPTIONS" {
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"kahoot-game/internal/config"
+	"kahoot-game/internal/database"
+	"kahoot-game/internal/logging"
+	"kahoot-game/internal/models"
+	"kahoot-game/internal/services"
+)
+
+const questionsUsage = `用法:
+  server questions import [-pack 題目包] [-format json|csv|yaml] [-dry-run] <檔案>
+  server questions export [-pack 題目包] [-category 分類] [-format json|csv|yaml] [-include-inactive] [檔案]
+
+export 未指定檔案時輸出到標準輸出`
+
+// runCommand 執行子命令，回傳程式結束代碼
+func runCommand(cfg *config.Config, args []string) int {
+	switch args[0] {
+	case "questions":
+		return runQuestionsCommand(cfg, args[1:])
+	}
+
+	fmt.Fprintf(os.Stderr, "未知的子命令: %s\n\n%s\n", args[0], questionsUsage)
+	return 2
+}
+
+// runQuestionsCommand 匯入 / 匯出「2種人」題目包
+func runQuestionsCommand(cfg *config.Config, args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, questionsUsage)
+		return 2
+	}
+
+	logger, err := logging.New(cfg.Log, os.Stderr)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ 日誌設定錯誤: %v\n", err)
+		return 1
+	}
+
+	db, err := database.NewPostgresDB(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ 無法連接資料庫: %v\n", err)
+		return 1
+	}
+	defer db.Close()
+
+	if err := database.CreateTables(db); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ 創建表格失敗: %v\n", err)
+		return 1
+	}
+
+	questionService := services.NewQuestionService(db, logger)
+	if err := questionService.SeedBuiltinQuestions(); err != nil {
+		fmt.Fprintf(os.Stderr, "⚠️ 插入「2種人」種子題目失敗: %v\n", err)
+	}
+
+	switch args[0] {
+	case "import":
+		return importQuestions(questionService, args[1:])
+	case "export":
+		return exportQuestions(questionService, args[1:])
+	}
+
+	fmt.Fprintln(os.Stderr, questionsUsage)
+	return 2
+}
+
+func importQuestions(questionService *services.QuestionService, args []string) int {
+	flags := flag.NewFlagSet("questions import", flag.ContinueOnError)
+	packID := flags.String("pack", "", "題目包名稱（覆蓋檔案中的 packId）")
+	format := flags.String("format", "", "檔案格式，預設依副檔名判斷")
+	dryRun := flags.Bool("dry-run", false, "只驗證不寫入")
+	if err := flags.Parse(args); err != nil || flags.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, questionsUsage)
+		return 2
+	}
+
+	filename := flags.Arg(0)
+	packFormat, err := services.DetectPackFormat(*format, filename)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		return 2
+	}
+
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ 讀取檔案失敗: %v\n", err)
+		return 1
+	}
+
+	pack, err := services.ParseQuestionPack(data, packFormat)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		return 1
+	}
+	if *packID != "" {
+		pack.PackID = *packID
+	}
+
+	result, err := questionService.ImportQuestionPack(pack, *dryRun)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ 匯入題目包失敗: %v\n", err)
+		return 1
+	}
+
+	for _, rowErr := range result.Errors {
+		fmt.Fprintf(os.Stderr, "⚠️ 第 %d 列 %s: %s\n", rowErr.Row, rowErr.Field, rowErr.Message)
+	}
+
+	summary, _ := json.MarshalIndent(result, "", "  ")
+	fmt.Println(string(summary))
+
+	if len(result.Errors) > 0 {
+		return 1
+	}
+	return 0
+}
+
+func exportQuestions(questionService *services.QuestionService, args []string) int {
+	flags := flag.NewFlagSet("questions export", flag.ContinueOnError)
+	packID := flags.String("pack", "", "只匯出此題目包")
+	category := flags.String("category", "", "只匯出此分類")
+	format := flags.String("format", "", "檔案格式，預設依副檔名判斷")
+	includeInactive := flags.Bool("include-inactive", false, "包含已停用的題目")
+	if err := flags.Parse(args); err != nil || flags.NArg() > 1 {
+		fmt.Fprintln(os.Stderr, questionsUsage)
+		return 2
+	}
+
+	filename := flags.Arg(0)
+	packFormat, err := services.DetectPackFormat(*format, filename)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		return 2
+	}
+
+	pack, err := questionService.ExportQuestionPack(models.QuestionFilter{
+		Category:        *category,
+		PackID:          *packID,
+		IncludeInactive: *includeInactive,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ 匯出題目包失敗: %v\n", err)
+		return 1
+	}
+
+	data, err := services.EncodeQuestionPack(pack, packFormat)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ 匯出題目包失敗: %v\n", err)
+		return 1
+	}
+
+	if filename == "" {
+		os.Stdout.Write(data)
+		return 0
+	}
+
+	if err := os.WriteFile(filename, data, 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ 寫入檔案失敗: %v\n", err)
+		return 1
+	}
+
+	fmt.Fprintf(os.Stderr, "✅ 已匯出 %d 題到 %s\n", len(pack.Questions), filename)
+	return 0
+}
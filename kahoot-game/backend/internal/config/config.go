@@ -1,9 +1,12 @@
 package config
 
 import (
+	"errors"
+	"fmt"
 	"os"
 	"strconv"
 	"strings"
+	"time"
 )
 
 // Config 應用程式配置結構
@@ -18,8 +21,15 @@ type Config struct {
 	Database DatabaseConfig
 	Redis    RedisConfig
 
+	// 房間資料存放配置
+	Storage StorageConfig
+
 	// JWT 配置
-	JWTSecret string
+	JWTSecret    string
+	HostTokenTTL time.Duration
+
+	// 題庫管理憑證，空字串代表停用新增、修改與刪除題目的 API
+	AdminToken string
 
 	// CORS 配置
 	CORSOrigins []string
@@ -30,12 +40,13 @@ type Config struct {
 	// 遊戲配置
 	Game GameConfig
 
+	// 房間清理配置
+	Janitor JanitorConfig
+
 	// 日誌配置
 	Log LogConfig
 }
 
-
-
 // DatabaseConfig PostgreSQL 資料庫配置
 type DatabaseConfig struct {
 	Host     string
@@ -56,19 +67,37 @@ type RedisConfig struct {
 	URL      string // 直接使用連接字串
 }
 
+// StorageConfig 房間資料存放配置
+type StorageConfig struct {
+	Backend       string        // auto（Redis 可連線時使用 Redis，否則使用記憶體）、redis 或 memory
+	Fallback      bool          // 執行中 Redis 無法存取時自動改用記憶體
+	RetryInterval time.Duration // 改用記憶體後檢查 Redis 是否恢復的間隔
+}
+
 // WebSocketConfig WebSocket 配置
 type WebSocketConfig struct {
 	ReadBufferSize  int
 	WriteBufferSize int
 	MaxMessageSize  int64
+
+	// 斷線後保留玩家資料、等待重連的時間
+	ResumeGracePeriod time.Duration
 }
 
 // GameConfig 遊戲相關配置
 type GameConfig struct {
-	MaxPlayersPerRoom      int
-	RoomIDLength           int
-	QuestionTimeLimit      int
-	DefaultTotalQuestions  int
+	MaxPlayersPerRoom     int // 每個房間的玩家人數上限
+	RoomIDLength          int // 房間ID長度
+	QuestionTimeLimit     int // 未指定時的每題作答秒數
+	DefaultTotalQuestions int // 未指定時的題數
+}
+
+// JanitorConfig 閒置房間清理配置，保留時間為 0 代表不使用該條件
+type JanitorConfig struct {
+	Interval    time.Duration // 清理間隔
+	EmptyTTL    time.Duration // 沒有玩家的房間保留時間
+	FinishedTTL time.Duration // 遊戲結束後的保留時間
+	IdleTTL     time.Duration // 沒有任何活動的房間保留時間
 }
 
 // LogConfig 日誌配置
@@ -78,98 +107,233 @@ type LogConfig struct {
 }
 
 // Load 載入配置
-func Load() *Config {
-	frontendURL := strings.TrimSuffix(getEnv("FRONTEND_URL", "http://localhost:5173"), "/")
-	corsOrigins := getEnvAsSlice("CORS_ORIGINS", []string{
-		"http://localhost:5173",
-		"http://localhost:3000",
-	})
-
-	if frontendURL != "" {
-		matched := false
-		for _, origin := range corsOrigins {
-			if strings.TrimSuffix(origin, "/") == frontendURL {
-				matched = true
-				break
-			}
-		}
-		if !matched {
-			corsOrigins = append(corsOrigins, frontendURL)
+// 依序套用預設值、CONFIG_FILE 指定的設定檔（YAML 或 TOML）與環境變數，後者覆蓋前者；
+// 設定檔無法讀取、環境變數格式錯誤或設定值超出範圍時回傳錯誤
+func Load() (*Config, error) {
+	cfg := defaultConfig()
+
+	if path := os.Getenv("CONFIG_FILE"); path != "" {
+		if err := loadFile(path, cfg); err != nil {
+			return nil, err
 		}
 	}
 
+	if err := applyEnv(cfg); err != nil {
+		return nil, err
+	}
+
+	cfg.FrontendURL = strings.TrimSuffix(cfg.FrontendURL, "/")
+	cfg.CORSOrigins = withOrigin(cfg.CORSOrigins, cfg.FrontendURL)
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+// defaultConfig 預設配置
+func defaultConfig() *Config {
 	return &Config{
-		Port:        getEnv("PORT", "8080"),
-		Host:        getEnv("HOST", "localhost"),
-		Environment: getEnv("ENV", "development"),
-		FrontendURL: frontendURL,
+		Port:        "8080",
+		Host:        "localhost",
+		Environment: "development",
+		FrontendURL: "http://localhost:5173",
 
 		Database: DatabaseConfig{
-			Host:     getEnv("DB_HOST", "localhost"),
-			Port:     getEnv("DB_PORT", "5432"),
-			User:     getEnv("DB_USER", "postgres"),
-			Password: getEnv("DB_PASSWORD", "password"),
-			Name:     getEnv("DB_NAME", "kahoot_game"),
-			SSLMode:  getEnv("DB_SSLMODE", "disable"),
-			URL:      getEnv("DATABASE_URL", ""),
+			Host:     "localhost",
+			Port:     "5432",
+			User:     "postgres",
+			Password: "password",
+			Name:     "kahoot_game",
+			SSLMode:  "disable",
 		},
 
 		Redis: RedisConfig{
-			Host:     getEnv("REDIS_HOST", "localhost"),
-			Port:     getEnv("REDIS_PORT", "6379"),
-			Password: getEnv("REDIS_PASSWORD", ""),
-			DB:       getEnvAsInt("REDIS_DB", 0),
-			URL:      getEnv("REDIS_URL", ""),
+			Host: "localhost",
+			Port: "6379",
+		},
+
+		Storage: StorageConfig{
+			Backend:       "auto",
+			RetryInterval: 30 * time.Second,
 		},
 
-		JWTSecret: getEnv("JWT_SECRET", "your-super-secret-jwt-key"),
+		JWTSecret:    defaultJWTSecret,
+		HostTokenTTL: 24 * time.Hour,
 
-		CORSOrigins: corsOrigins,
+		CORSOrigins: []string{
+			"http://localhost:5173",
+			"http://localhost:3000",
+		},
 
 		WebSocket: WebSocketConfig{
-			ReadBufferSize:  getEnvAsInt("WS_READ_BUFFER_SIZE", 1024),
-			WriteBufferSize: getEnvAsInt("WS_WRITE_BUFFER_SIZE", 1024),
-			MaxMessageSize:  int64(getEnvAsInt("WS_MAX_MESSAGE_SIZE", 512)),
+			ReadBufferSize:    1024,
+			WriteBufferSize:   1024,
+			MaxMessageSize:    512,
+			ResumeGracePeriod: 30 * time.Second,
 		},
 
 		Game: GameConfig{
-			MaxPlayersPerRoom:     getEnvAsInt("MAX_PLAYERS_PER_ROOM", 20),
-			RoomIDLength:          getEnvAsInt("ROOM_ID_LENGTH", 6),
-			QuestionTimeLimit:     getEnvAsInt("QUESTION_TIME_LIMIT", 30),
-			DefaultTotalQuestions: getEnvAsInt("DEFAULT_TOTAL_QUESTIONS", 10),
+			MaxPlayersPerRoom:     20,
+			RoomIDLength:          6,
+			QuestionTimeLimit:     30,
+			DefaultTotalQuestions: 10,
+		},
+
+		Janitor: JanitorConfig{
+			Interval:    60 * time.Second,
+			EmptyTTL:    15 * time.Minute,
+			FinishedTTL: 30 * time.Minute,
+			IdleTTL:     120 * time.Minute,
 		},
 
 		Log: LogConfig{
-			Level:  getEnv("LOG_LEVEL", "debug"),
-			Format: getEnv("LOG_FORMAT", "json"),
+			Level:  "info",
+			Format: "json",
 		},
 	}
 }
 
-// getEnv 獲取環境變數，如果不存在則返回預設值
-func getEnv(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
-		return value
+// applyEnv 以環境變數覆蓋配置，未設定的環境變數保留原值
+func applyEnv(cfg *Config) error {
+	env := &envReader{}
+
+	env.string("PORT", &cfg.Port)
+	env.string("HOST", &cfg.Host)
+	env.string("ENV", &cfg.Environment)
+	env.string("FRONTEND_URL", &cfg.FrontendURL)
+
+	env.string("DB_HOST", &cfg.Database.Host)
+	env.string("DB_PORT", &cfg.Database.Port)
+	env.string("DB_USER", &cfg.Database.User)
+	env.string("DB_PASSWORD", &cfg.Database.Password)
+	env.string("DB_NAME", &cfg.Database.Name)
+	env.string("DB_SSLMODE", &cfg.Database.SSLMode)
+	env.string("DATABASE_URL", &cfg.Database.URL)
+
+	env.string("REDIS_HOST", &cfg.Redis.Host)
+	env.string("REDIS_PORT", &cfg.Redis.Port)
+	env.string("REDIS_PASSWORD", &cfg.Redis.Password)
+	env.int("REDIS_DB", &cfg.Redis.DB)
+	env.string("REDIS_URL", &cfg.Redis.URL)
+
+	env.string("ROOM_STORE", &cfg.Storage.Backend)
+	env.bool("ROOM_STORE_FALLBACK", &cfg.Storage.Fallback)
+	env.duration("ROOM_STORE_RETRY_SECONDS", time.Second, &cfg.Storage.RetryInterval)
+
+	env.string("JWT_SECRET", &cfg.JWTSecret)
+	env.duration("HOST_TOKEN_TTL_HOURS", time.Hour, &cfg.HostTokenTTL)
+	env.string("ADMIN_TOKEN", &cfg.AdminToken)
+
+	env.slice("CORS_ORIGINS", &cfg.CORSOrigins)
+
+	env.int("WS_READ_BUFFER_SIZE", &cfg.WebSocket.ReadBufferSize)
+	env.int("WS_WRITE_BUFFER_SIZE", &cfg.WebSocket.WriteBufferSize)
+	env.int64("WS_MAX_MESSAGE_SIZE", &cfg.WebSocket.MaxMessageSize)
+	env.duration("WS_RESUME_GRACE_SECONDS", time.Second, &cfg.WebSocket.ResumeGracePeriod)
+
+	env.int("MAX_PLAYERS_PER_ROOM", &cfg.Game.MaxPlayersPerRoom)
+	env.int("ROOM_ID_LENGTH", &cfg.Game.RoomIDLength)
+	env.int("QUESTION_TIME_LIMIT", &cfg.Game.QuestionTimeLimit)
+	env.int("DEFAULT_TOTAL_QUESTIONS", &cfg.Game.DefaultTotalQuestions)
+
+	env.duration("JANITOR_INTERVAL_SECONDS", time.Second, &cfg.Janitor.Interval)
+	env.duration("ROOM_EMPTY_TTL_MINUTES", time.Minute, &cfg.Janitor.EmptyTTL)
+	env.duration("ROOM_FINISHED_TTL_MINUTES", time.Minute, &cfg.Janitor.FinishedTTL)
+	env.duration("ROOM_IDLE_TTL_MINUTES", time.Minute, &cfg.Janitor.IdleTTL)
+
+	env.string("LOG_LEVEL", &cfg.Log.Level)
+	env.string("LOG_FORMAT", &cfg.Log.Format)
+
+	if len(env.errs) > 0 {
+		return fmt.Errorf("環境變數格式錯誤:\n%w", errors.Join(env.errs...))
 	}
-	return defaultValue
+	return nil
 }
 
-// getEnvAsInt 獲取環境變數並轉換為整數
-func getEnvAsInt(key string, defaultValue int) int {
-	if valueStr := os.Getenv(key); valueStr != "" {
-		if value, err := strconv.Atoi(valueStr); err == nil {
-			return value
+// withOrigin 確保前端網址在允許的來源中
+func withOrigin(origins []string, frontendURL string) []string {
+	if frontendURL == "" {
+		return origins
+	}
+	for _, origin := range origins {
+		if strings.TrimSuffix(origin, "/") == frontendURL {
+			return origins
 		}
 	}
-	return defaultValue
+	return append(origins, frontendURL)
+}
+
+// envReader 讀取環境變數，格式錯誤的值會記錄下來，而不是默默使用預設值
+type envReader struct {
+	errs []error
+}
+
+// string 讀取字串
+func (e *envReader) string(key string, value *string) {
+	if raw := os.Getenv(key); raw != "" {
+		*value = raw
+	}
+}
+
+// int 讀取整數
+func (e *envReader) int(key string, value *int) {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return
+	}
+	parsed, err := strconv.Atoi(strings.TrimSpace(raw))
+	if err != nil {
+		e.errs = append(e.errs, fmt.Errorf("%s=%q 不是整數", key, raw))
+		return
+	}
+	*value = parsed
+}
+
+// int64 讀取 64 位元整數
+func (e *envReader) int64(key string, value *int64) {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return
+	}
+	parsed, err := strconv.ParseInt(strings.TrimSpace(raw), 10, 64)
+	if err != nil {
+		e.errs = append(e.errs, fmt.Errorf("%s=%q 不是整數", key, raw))
+		return
+	}
+	*value = parsed
+}
+
+// bool 讀取布林值（true、false、1、0 等）
+func (e *envReader) bool(key string, value *bool) {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return
+	}
+	parsed, err := strconv.ParseBool(strings.TrimSpace(raw))
+	if err != nil {
+		e.errs = append(e.errs, fmt.Errorf("%s=%q 不是布林值（可用 true、false）", key, raw))
+		return
+	}
+	*value = parsed
+}
+
+// duration 讀取以 unit 為單位的整數時間
+func (e *envReader) duration(key string, unit time.Duration, value *time.Duration) {
+	if os.Getenv(key) == "" {
+		return
+	}
+	count := int(*value / unit)
+	e.int(key, &count)
+	*value = time.Duration(count) * unit
 }
 
-// getEnvAsSlice 獲取環境變數並轉換為字串切片
-func getEnvAsSlice(key string, defaultValue []string) []string {
-	if valueStr := os.Getenv(key); valueStr != "" {
-		return strings.Split(valueStr, ",")
+// slice 讀取以逗號分隔的字串列表
+func (e *envReader) slice(key string, value *[]string) {
+	if raw := os.Getenv(key); raw != "" {
+		*value = strings.Split(raw, ",")
 	}
-	return defaultValue
 }
 
 // GetDatabaseDSN 獲取資料庫連線字串
@@ -0,0 +1,206 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// configEnvKeys Load 會讀取的環境變數
+var configEnvKeys = []string{
+	"CONFIG_FILE", "PORT", "HOST", "ENV", "FRONTEND_URL",
+	"DB_HOST", "DB_PORT", "DB_USER", "DB_PASSWORD", "DB_NAME", "DB_SSLMODE", "DATABASE_URL",
+	"REDIS_HOST", "REDIS_PORT", "REDIS_PASSWORD", "REDIS_DB", "REDIS_URL",
+	"ROOM_STORE", "ROOM_STORE_FALLBACK", "ROOM_STORE_RETRY_SECONDS",
+	"JWT_SECRET", "HOST_TOKEN_TTL_HOURS", "ADMIN_TOKEN", "CORS_ORIGINS",
+	"WS_READ_BUFFER_SIZE", "WS_WRITE_BUFFER_SIZE", "WS_MAX_MESSAGE_SIZE", "WS_RESUME_GRACE_SECONDS",
+	"MAX_PLAYERS_PER_ROOM", "ROOM_ID_LENGTH", "QUESTION_TIME_LIMIT", "DEFAULT_TOTAL_QUESTIONS",
+	"JANITOR_INTERVAL_SECONDS", "ROOM_EMPTY_TTL_MINUTES", "ROOM_FINISHED_TTL_MINUTES", "ROOM_IDLE_TTL_MINUTES",
+	"LOG_LEVEL", "LOG_FORMAT",
+}
+
+// setConfigEnv 清除執行環境中的設定後套用測試用環境變數（空字串視為未設定）
+func setConfigEnv(t *testing.T, env map[string]string) {
+	t.Helper()
+
+	for _, key := range configEnvKeys {
+		t.Setenv(key, "")
+	}
+	for key, value := range env {
+		t.Setenv(key, value)
+	}
+}
+
+// writeConfigFile 寫入暫存設定檔並回傳路徑
+func writeConfigFile(t *testing.T, name, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("寫入設定檔失敗: %v", err)
+	}
+	return path
+}
+
+func TestLoadDefaults(t *testing.T) {
+	setConfigEnv(t, nil)
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() = %v", err)
+	}
+	if cfg.Port != "8080" || cfg.WebSocket.MaxMessageSize != 512 || cfg.Game.MaxPlayersPerRoom != 20 || cfg.Game.RoomIDLength != 6 {
+		t.Errorf("預設值不符: port=%s maxMessageSize=%d maxPlayers=%d roomIdLength=%d",
+			cfg.Port, cfg.WebSocket.MaxMessageSize, cfg.Game.MaxPlayersPerRoom, cfg.Game.RoomIDLength)
+	}
+}
+
+func TestLoadConfigFilePrecedence(t *testing.T) {
+	const yamlConfig = `
+port: 9090
+websocket:
+  maxMessageSize: 4096
+  resumeGracePeriod: 45s
+game:
+  maxPlayersPerRoom: 40
+  roomIdLength: 8
+`
+	const tomlConfig = `
+port = 9090
+
+[websocket]
+maxMessageSize = 4096
+resumeGracePeriod = "45s"
+
+[game]
+maxPlayersPerRoom = 40
+roomIdLength = 8
+`
+
+	tests := []struct {
+		name    string
+		file    string
+		content string
+	}{
+		{name: "YAML", file: "config.yaml", content: yamlConfig},
+		{name: "YML", file: "config.yml", content: yamlConfig},
+		{name: "TOML", file: "config.toml", content: tomlConfig},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setConfigEnv(t, map[string]string{
+				"CONFIG_FILE":          writeConfigFile(t, tt.file, tt.content),
+				"PORT":                 "7070",
+				"MAX_PLAYERS_PER_ROOM": "50",
+			})
+
+			cfg, err := Load()
+			if err != nil {
+				t.Fatalf("Load() = %v", err)
+			}
+
+			// 環境變數覆蓋設定檔，設定檔覆蓋預設值，兩者都沒有的保留預設值
+			checks := []struct {
+				field string
+				got   interface{}
+				want  interface{}
+			}{
+				{"port", cfg.Port, "7070"},
+				{"game.maxPlayersPerRoom", cfg.Game.MaxPlayersPerRoom, 50},
+				{"game.roomIdLength", cfg.Game.RoomIDLength, 8},
+				{"websocket.maxMessageSize", cfg.WebSocket.MaxMessageSize, int64(4096)},
+				{"websocket.resumeGracePeriod", cfg.WebSocket.ResumeGracePeriod, 45 * time.Second},
+				{"websocket.readBufferSize", cfg.WebSocket.ReadBufferSize, 1024},
+				{"game.questionTimeLimit", cfg.Game.QuestionTimeLimit, 30},
+			}
+			for _, check := range checks {
+				if check.got != check.want {
+					t.Errorf("%s = %v, 預期 %v", check.field, check.got, check.want)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadRejectsInvalidValues(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string // 設定檔名稱與內容，空字串代表不使用設定檔
+		content string
+		env     map[string]string
+		wantErr string
+	}{
+		{name: "訊息大小太小", env: map[string]string{"WS_MAX_MESSAGE_SIZE": "100"}, wantErr: "WS_MAX_MESSAGE_SIZE"},
+		{name: "訊息大小太大", env: map[string]string{"WS_MAX_MESSAGE_SIZE": "2097152"}, wantErr: "WS_MAX_MESSAGE_SIZE"},
+		{name: "讀取緩衝區為 0", env: map[string]string{"WS_READ_BUFFER_SIZE": "0"}, wantErr: "WS_READ_BUFFER_SIZE"},
+		{name: "寫入緩衝區為負數", env: map[string]string{"WS_WRITE_BUFFER_SIZE": "-1"}, wantErr: "WS_WRITE_BUFFER_SIZE"},
+		{name: "重連等待時間為負數", env: map[string]string{"WS_RESUME_GRACE_SECONDS": "-5"}, wantErr: "WS_RESUME_GRACE_SECONDS"},
+		{name: "玩家上限太少", env: map[string]string{"MAX_PLAYERS_PER_ROOM": "1"}, wantErr: "MAX_PLAYERS_PER_ROOM"},
+		{name: "玩家上限不是整數", env: map[string]string{"MAX_PLAYERS_PER_ROOM": "many"}, wantErr: "MAX_PLAYERS_PER_ROOM"},
+		{name: "房間ID太長", env: map[string]string{"ROOM_ID_LENGTH": "20"}, wantErr: "ROOM_ID_LENGTH"},
+		{name: "作答秒數太短", env: map[string]string{"QUESTION_TIME_LIMIT": "5"}, wantErr: "QUESTION_TIME_LIMIT"},
+		{name: "題數為 0", env: map[string]string{"DEFAULT_TOTAL_QUESTIONS": "0"}, wantErr: "DEFAULT_TOTAL_QUESTIONS"},
+		{name: "管理憑證太短", env: map[string]string{"ADMIN_TOKEN": "secret"}, wantErr: "ADMIN_TOKEN"},
+		{
+			name:    "YAML 玩家上限太多",
+			file:    "config.yaml",
+			content: "game:\n  maxPlayersPerRoom: 1000\n",
+			wantErr: "game.maxPlayersPerRoom",
+		},
+		{
+			name:    "YAML 重連等待時間格式錯誤",
+			file:    "config.yaml",
+			content: "websocket:\n  resumeGracePeriod: soon\n",
+			wantErr: "websocket.resumeGracePeriod",
+		},
+		{
+			name:    "YAML 拼錯的欄位",
+			file:    "config.yaml",
+			content: "websocket:\n  maxMesageSize: 4096\n",
+			wantErr: "maxMesageSize",
+		},
+		{
+			name:    "TOML 房間ID太短",
+			file:    "config.toml",
+			content: "[game]\nroomIdLength = 2\n",
+			wantErr: "game.roomIdLength",
+		},
+		{
+			name:    "TOML 訊息大小太小",
+			file:    "config.toml",
+			content: "[websocket]\nmaxMessageSize = 64\n",
+			wantErr: "websocket.maxMessageSize",
+		},
+		{
+			name:    "環境變數修正設定檔中的錯誤值",
+			file:    "config.yaml",
+			content: "game:\n  maxPlayersPerRoom: 1000\n  questionTimeLimit: 500\n",
+			env:     map[string]string{"MAX_PLAYERS_PER_ROOM": "100"},
+			wantErr: "QUESTION_TIME_LIMIT",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := map[string]string{}
+			for key, value := range tt.env {
+				env[key] = value
+			}
+			if tt.file != "" {
+				env["CONFIG_FILE"] = writeConfigFile(t, tt.file, tt.content)
+			}
+			setConfigEnv(t, env)
+
+			cfg, err := Load()
+			if err == nil {
+				t.Fatalf("Load() 未回傳錯誤: %+v", cfg)
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Load() = %v, 預期包含 %q", err, tt.wantErr)
+			}
+		})
+	}
+}
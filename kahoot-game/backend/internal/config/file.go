@@ -0,0 +1,286 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// fileConfig 設定檔格式（YAML 或 TOML），欄位與 Config 對應
+// 時間以 "30s"、"15m"、"24h" 等格式表示；設定檔中未出現的欄位保留原值
+type fileConfig struct {
+	Port        int    `yaml:"port" toml:"port"`
+	Host        string `yaml:"host" toml:"host"`
+	Environment string `yaml:"environment" toml:"environment"`
+	FrontendURL string `yaml:"frontendUrl" toml:"frontendUrl"`
+
+	Database fileDatabaseConfig `yaml:"database" toml:"database"`
+	Redis    fileRedisConfig    `yaml:"redis" toml:"redis"`
+	Storage  fileStorageConfig  `yaml:"storage" toml:"storage"`
+
+	JWTSecret    string `yaml:"jwtSecret" toml:"jwtSecret"`
+	HostTokenTTL string `yaml:"hostTokenTtl" toml:"hostTokenTtl"`
+	AdminToken   string `yaml:"adminToken" toml:"adminToken"`
+
+	CORSOrigins []string `yaml:"corsOrigins" toml:"corsOrigins"`
+
+	WebSocket fileWebSocketConfig `yaml:"websocket" toml:"websocket"`
+	Game      fileGameConfig      `yaml:"game" toml:"game"`
+	Janitor   fileJanitorConfig   `yaml:"janitor" toml:"janitor"`
+	Log       fileLogConfig       `yaml:"log" toml:"log"`
+}
+
+type fileDatabaseConfig struct {
+	Host     string `yaml:"host" toml:"host"`
+	Port     int    `yaml:"port" toml:"port"`
+	User     string `yaml:"user" toml:"user"`
+	Password string `yaml:"password" toml:"password"`
+	Name     string `yaml:"name" toml:"name"`
+	SSLMode  string `yaml:"sslMode" toml:"sslMode"`
+	URL      string `yaml:"url" toml:"url"`
+}
+
+type fileRedisConfig struct {
+	Host     string `yaml:"host" toml:"host"`
+	Port     int    `yaml:"port" toml:"port"`
+	Password string `yaml:"password" toml:"password"`
+	DB       int    `yaml:"db" toml:"db"`
+	URL      string `yaml:"url" toml:"url"`
+}
+
+type fileStorageConfig struct {
+	Backend       string `yaml:"backend" toml:"backend"`
+	Fallback      bool   `yaml:"fallback" toml:"fallback"`
+	RetryInterval string `yaml:"retryInterval" toml:"retryInterval"`
+}
+
+type fileWebSocketConfig struct {
+	ReadBufferSize    int    `yaml:"readBufferSize" toml:"readBufferSize"`
+	WriteBufferSize   int    `yaml:"writeBufferSize" toml:"writeBufferSize"`
+	MaxMessageSize    int64  `yaml:"maxMessageSize" toml:"maxMessageSize"`
+	ResumeGracePeriod string `yaml:"resumeGracePeriod" toml:"resumeGracePeriod"`
+}
+
+type fileGameConfig struct {
+	MaxPlayersPerRoom     int `yaml:"maxPlayersPerRoom" toml:"maxPlayersPerRoom"`
+	RoomIDLength          int `yaml:"roomIdLength" toml:"roomIdLength"`
+	QuestionTimeLimit     int `yaml:"questionTimeLimit" toml:"questionTimeLimit"`
+	DefaultTotalQuestions int `yaml:"defaultTotalQuestions" toml:"defaultTotalQuestions"`
+}
+
+type fileJanitorConfig struct {
+	Interval    string `yaml:"interval" toml:"interval"`
+	EmptyTTL    string `yaml:"emptyTtl" toml:"emptyTtl"`
+	FinishedTTL string `yaml:"finishedTtl" toml:"finishedTtl"`
+	IdleTTL     string `yaml:"idleTtl" toml:"idleTtl"`
+}
+
+type fileLogConfig struct {
+	Level  string `yaml:"level" toml:"level"`
+	Format string `yaml:"format" toml:"format"`
+}
+
+// loadFile 讀取設定檔並覆蓋 cfg，格式依副檔名判斷（.yaml、.yml、.toml）
+// 設定檔中有無法識別的欄位時回傳錯誤，避免拼錯的設定被默默忽略
+func loadFile(path string, cfg *Config) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("無法讀取設定檔: %w", err)
+	}
+
+	fc := newFileConfig(cfg)
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		if err := decoder.Decode(fc); err != nil && !errors.Is(err, io.EOF) {
+			return fmt.Errorf("設定檔 %s 格式錯誤: %w", path, err)
+		}
+	case ".toml":
+		decoder := toml.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(fc); err != nil {
+			return tomlError(path, err)
+		}
+	default:
+		return fmt.Errorf("不支援的設定檔格式: %s（可用 .yaml、.yml、.toml）", path)
+	}
+
+	if err := fc.apply(cfg); err != nil {
+		return fmt.Errorf("設定檔 %s 內容錯誤:\n%w", path, err)
+	}
+	return nil
+}
+
+// tomlError 將 TOML 解析錯誤轉為包含位置或欄位名稱的訊息
+func tomlError(path string, err error) error {
+	var strictErr *toml.StrictMissingError
+	if errors.As(err, &strictErr) {
+		return fmt.Errorf("設定檔 %s 有無法識別的欄位:\n%s", path, strictErr.String())
+	}
+
+	var decodeErr *toml.DecodeError
+	if errors.As(err, &decodeErr) {
+		row, column := decodeErr.Position()
+		return fmt.Errorf("設定檔 %s 第 %d 行第 %d 欄格式錯誤: %w", path, row, column, err)
+	}
+
+	return fmt.Errorf("設定檔 %s 格式錯誤: %w", path, err)
+}
+
+// newFileConfig 以目前的配置作為設定檔的初始值
+func newFileConfig(cfg *Config) *fileConfig {
+	return &fileConfig{
+		Port:        atoi(cfg.Port),
+		Host:        cfg.Host,
+		Environment: cfg.Environment,
+		FrontendURL: cfg.FrontendURL,
+
+		Database: fileDatabaseConfig{
+			Host:     cfg.Database.Host,
+			Port:     atoi(cfg.Database.Port),
+			User:     cfg.Database.User,
+			Password: cfg.Database.Password,
+			Name:     cfg.Database.Name,
+			SSLMode:  cfg.Database.SSLMode,
+			URL:      cfg.Database.URL,
+		},
+
+		Redis: fileRedisConfig{
+			Host:     cfg.Redis.Host,
+			Port:     atoi(cfg.Redis.Port),
+			Password: cfg.Redis.Password,
+			DB:       cfg.Redis.DB,
+			URL:      cfg.Redis.URL,
+		},
+
+		Storage: fileStorageConfig{
+			Backend:       cfg.Storage.Backend,
+			Fallback:      cfg.Storage.Fallback,
+			RetryInterval: cfg.Storage.RetryInterval.String(),
+		},
+
+		JWTSecret:    cfg.JWTSecret,
+		HostTokenTTL: cfg.HostTokenTTL.String(),
+		AdminToken:   cfg.AdminToken,
+
+		CORSOrigins: cfg.CORSOrigins,
+
+		WebSocket: fileWebSocketConfig{
+			ReadBufferSize:    cfg.WebSocket.ReadBufferSize,
+			WriteBufferSize:   cfg.WebSocket.WriteBufferSize,
+			MaxMessageSize:    cfg.WebSocket.MaxMessageSize,
+			ResumeGracePeriod: cfg.WebSocket.ResumeGracePeriod.String(),
+		},
+
+		Game: fileGameConfig{
+			MaxPlayersPerRoom:     cfg.Game.MaxPlayersPerRoom,
+			RoomIDLength:          cfg.Game.RoomIDLength,
+			QuestionTimeLimit:     cfg.Game.QuestionTimeLimit,
+			DefaultTotalQuestions: cfg.Game.DefaultTotalQuestions,
+		},
+
+		Janitor: fileJanitorConfig{
+			Interval:    cfg.Janitor.Interval.String(),
+			EmptyTTL:    cfg.Janitor.EmptyTTL.String(),
+			FinishedTTL: cfg.Janitor.FinishedTTL.String(),
+			IdleTTL:     cfg.Janitor.IdleTTL.String(),
+		},
+
+		Log: fileLogConfig{
+			Level:  cfg.Log.Level,
+			Format: cfg.Log.Format,
+		},
+	}
+}
+
+// apply 將設定檔內容寫回配置，時間格式錯誤時回傳所有錯誤
+func (fc *fileConfig) apply(cfg *Config) error {
+	var errs []error
+	duration := func(key, value string) time.Duration {
+		parsed, err := time.ParseDuration(strings.TrimSpace(value))
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s=%q 不是有效的時間（例如 30s、15m、24h）", key, value))
+		}
+		return parsed
+	}
+
+	cfg.Port = strconv.Itoa(fc.Port)
+	cfg.Host = fc.Host
+	cfg.Environment = fc.Environment
+	cfg.FrontendURL = fc.FrontendURL
+
+	cfg.Database = DatabaseConfig{
+		Host:     fc.Database.Host,
+		Port:     strconv.Itoa(fc.Database.Port),
+		User:     fc.Database.User,
+		Password: fc.Database.Password,
+		Name:     fc.Database.Name,
+		SSLMode:  fc.Database.SSLMode,
+		URL:      fc.Database.URL,
+	}
+
+	cfg.Redis = RedisConfig{
+		Host:     fc.Redis.Host,
+		Port:     strconv.Itoa(fc.Redis.Port),
+		Password: fc.Redis.Password,
+		DB:       fc.Redis.DB,
+		URL:      fc.Redis.URL,
+	}
+
+	cfg.Storage = StorageConfig{
+		Backend:       fc.Storage.Backend,
+		Fallback:      fc.Storage.Fallback,
+		RetryInterval: duration("storage.retryInterval", fc.Storage.RetryInterval),
+	}
+
+	cfg.JWTSecret = fc.JWTSecret
+	cfg.HostTokenTTL = duration("hostTokenTtl", fc.HostTokenTTL)
+	cfg.AdminToken = fc.AdminToken
+
+	cfg.CORSOrigins = fc.CORSOrigins
+
+	cfg.WebSocket = WebSocketConfig{
+		ReadBufferSize:    fc.WebSocket.ReadBufferSize,
+		WriteBufferSize:   fc.WebSocket.WriteBufferSize,
+		MaxMessageSize:    fc.WebSocket.MaxMessageSize,
+		ResumeGracePeriod: duration("websocket.resumeGracePeriod", fc.WebSocket.ResumeGracePeriod),
+	}
+
+	cfg.Game = GameConfig{
+		MaxPlayersPerRoom:     fc.Game.MaxPlayersPerRoom,
+		RoomIDLength:          fc.Game.RoomIDLength,
+		QuestionTimeLimit:     fc.Game.QuestionTimeLimit,
+		DefaultTotalQuestions: fc.Game.DefaultTotalQuestions,
+	}
+
+	cfg.Janitor = JanitorConfig{
+		Interval:    duration("janitor.interval", fc.Janitor.Interval),
+		EmptyTTL:    duration("janitor.emptyTtl", fc.Janitor.EmptyTTL),
+		FinishedTTL: duration("janitor.finishedTtl", fc.Janitor.FinishedTTL),
+		IdleTTL:     duration("janitor.idleTtl", fc.Janitor.IdleTTL),
+	}
+
+	cfg.Log = LogConfig{
+		Level:  fc.Log.Level,
+		Format: fc.Log.Format,
+	}
+
+	return errors.Join(errs...)
+}
+
+// atoi 將埠號字串轉為數字，無法轉換時為 0（稍後由 Validate 檢查）
+func atoi(value string) int {
+	parsed, _ := strconv.Atoi(value)
+	return parsed
+}
@@ -0,0 +1,113 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 設定值的允許範圍
+const (
+	minPlayersPerRoom = 2
+	maxPlayersPerRoom = 500
+
+	minRoomIDLength = 4
+	maxRoomIDLength = 12
+
+	// 與建立房間請求的驗證規則一致
+	minQuestionTimeLimit = 10
+	maxQuestionTimeLimit = 120
+	minTotalQuestions    = 1
+	maxTotalQuestions    = 50
+
+	minMessageSize = 512
+	maxMessageSize = 1 << 20
+
+	minAdminTokenLength = 16
+)
+
+// 未設定時的 JWT 密鑰，只能用於開發環境
+const defaultJWTSecret = "your-super-secret-jwt-key"
+
+// 公開在程式碼與範例設定中的 JWT 密鑰，正式環境不可使用
+var knownJWTSecrets = []string{
+	defaultJWTSecret,
+	"your-super-secret-jwt-key-change-in-production", // .env 範例
+}
+
+// Validate 檢查設定值，回傳所有超出範圍的設定
+// 錯誤訊息同時列出設定檔欄位與環境變數名稱
+func (c *Config) Validate() error {
+	var errs []error
+	check := func(ok bool, field, env, format string, args ...interface{}) {
+		if !ok {
+			errs = append(errs, fmt.Errorf("%s（%s）%s", field, env, fmt.Sprintf(format, args...)))
+		}
+	}
+	intRange := func(value, min, max int, field, env string) {
+		check(value >= min && value <= max, field, env, "必須介於 %d 到 %d，目前為 %d", min, max, value)
+	}
+	positive := func(value time.Duration, field, env string) {
+		check(value > 0, field, env, "必須大於 0，目前為 %s", value)
+	}
+	nonNegative := func(value time.Duration, field, env string) {
+		check(value >= 0, field, env, "不可為負數，目前為 %s", value)
+	}
+
+	port, err := strconv.Atoi(c.Port)
+	check(err == nil && port > 0 && port <= 65535, "port", "PORT", "必須是 1 到 65535 的埠號，目前為 %q", c.Port)
+	check(c.Redis.DB >= 0, "redis.db", "REDIS_DB", "不可為負數，目前為 %d", c.Redis.DB)
+
+	switch strings.ToLower(strings.TrimSpace(c.Storage.Backend)) {
+	case "auto", "redis", "memory":
+	default:
+		check(false, "storage.backend", "ROOM_STORE", "必須是 auto、redis 或 memory，目前為 %q", c.Storage.Backend)
+	}
+	if c.Storage.Fallback {
+		positive(c.Storage.RetryInterval, "storage.retryInterval", "ROOM_STORE_RETRY_SECONDS")
+	}
+
+	positive(c.HostTokenTTL, "hostTokenTtl", "HOST_TOKEN_TTL_HOURS")
+	if c.Environment == "production" {
+		check(!isKnownJWTSecret(c.JWTSecret), "jwtSecret", "JWT_SECRET", "正式環境必須設定自己的密鑰，不可為空或使用預設值")
+	}
+	check(c.AdminToken == "" || len(c.AdminToken) >= minAdminTokenLength,
+		"adminToken", "ADMIN_TOKEN", "至少需要 %d 個字元，目前為 %d", minAdminTokenLength, len(c.AdminToken))
+
+	check(c.WebSocket.ReadBufferSize > 0, "websocket.readBufferSize", "WS_READ_BUFFER_SIZE", "必須大於 0，目前為 %d", c.WebSocket.ReadBufferSize)
+	check(c.WebSocket.WriteBufferSize > 0, "websocket.writeBufferSize", "WS_WRITE_BUFFER_SIZE", "必須大於 0，目前為 %d", c.WebSocket.WriteBufferSize)
+	check(c.WebSocket.MaxMessageSize >= minMessageSize && c.WebSocket.MaxMessageSize <= maxMessageSize,
+		"websocket.maxMessageSize", "WS_MAX_MESSAGE_SIZE", "必須介於 %d 到 %d 位元組，目前為 %d", minMessageSize, maxMessageSize, c.WebSocket.MaxMessageSize)
+	nonNegative(c.WebSocket.ResumeGracePeriod, "websocket.resumeGracePeriod", "WS_RESUME_GRACE_SECONDS")
+
+	intRange(c.Game.MaxPlayersPerRoom, minPlayersPerRoom, maxPlayersPerRoom, "game.maxPlayersPerRoom", "MAX_PLAYERS_PER_ROOM")
+	intRange(c.Game.RoomIDLength, minRoomIDLength, maxRoomIDLength, "game.roomIdLength", "ROOM_ID_LENGTH")
+	intRange(c.Game.QuestionTimeLimit, minQuestionTimeLimit, maxQuestionTimeLimit, "game.questionTimeLimit", "QUESTION_TIME_LIMIT")
+	intRange(c.Game.DefaultTotalQuestions, minTotalQuestions, maxTotalQuestions, "game.defaultTotalQuestions", "DEFAULT_TOTAL_QUESTIONS")
+
+	positive(c.Janitor.Interval, "janitor.interval", "JANITOR_INTERVAL_SECONDS")
+	nonNegative(c.Janitor.EmptyTTL, "janitor.emptyTtl", "ROOM_EMPTY_TTL_MINUTES")
+	nonNegative(c.Janitor.FinishedTTL, "janitor.finishedTtl", "ROOM_FINISHED_TTL_MINUTES")
+	nonNegative(c.Janitor.IdleTTL, "janitor.idleTtl", "ROOM_IDLE_TTL_MINUTES")
+
+	if len(errs) > 0 {
+		return fmt.Errorf("設定值錯誤:\n%w", errors.Join(errs...))
+	}
+	return nil
+}
+
+// isKnownJWTSecret 密鑰是否為空或為公開的預設值
+func isKnownJWTSecret(secret string) bool {
+	secret = strings.TrimSpace(secret)
+	if secret == "" {
+		return true
+	}
+	for _, known := range knownJWTSecrets {
+		if secret == known {
+			return true
+		}
+	}
+	return false
+}
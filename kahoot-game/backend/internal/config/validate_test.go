@@ -0,0 +1,41 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateJWTSecret(t *testing.T) {
+	tests := []struct {
+		name        string
+		environment string
+		secret      string
+		wantErr     bool
+	}{
+		{name: "開發環境可使用預設值", environment: "development", secret: defaultJWTSecret},
+		{name: "正式環境自訂密鑰", environment: "production", secret: "s3cr3t-from-vault"},
+		{name: "正式環境未設定", environment: "production", secret: "", wantErr: true},
+		{name: "正式環境只有空白", environment: "production", secret: "   ", wantErr: true},
+		{name: "正式環境使用程式預設值", environment: "production", secret: defaultJWTSecret, wantErr: true},
+		{name: "正式環境使用 .env 範例", environment: "production", secret: "your-super-secret-jwt-key-change-in-production", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := defaultConfig()
+			cfg.Environment = tt.environment
+			cfg.JWTSecret = tt.secret
+
+			err := cfg.Validate()
+			if tt.wantErr {
+				if err == nil || !strings.Contains(err.Error(), "JWT_SECRET") {
+					t.Fatalf("Validate() = %v, 預期 JWT_SECRET 錯誤", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Validate() = %v", err)
+			}
+		})
+	}
+}
@@ -43,7 +43,7 @@ func CreateTables(db *sql.DB) error {
 		-- 遊戲記錄表
 		CREATE TABLE IF NOT EXISTS games (
 			id SERIAL PRIMARY KEY,
-			room_id VARCHAR(10) UNIQUE NOT NULL,
+			room_id VARCHAR(10) NOT NULL,
 			host_name VARCHAR(50) NOT NULL,
 			total_players INTEGER DEFAULT 0,
 			total_questions INTEGER DEFAULT 10,
@@ -109,6 +109,25 @@ func CreateTables(db *sql.DB) error {
 			submitted_at TIMESTAMP DEFAULT NOW()
 		);
 
+		-- 「2種人」題庫
+		CREATE TABLE IF NOT EXISTS two_types_questions (
+			id SERIAL PRIMARY KEY,
+			question_text TEXT NOT NULL,
+			option_a VARCHAR(200) NOT NULL,
+			option_b VARCHAR(200) NOT NULL,
+			category VARCHAR(50) DEFAULT 'general',
+			difficulty INTEGER DEFAULT 1 CHECK (difficulty BETWEEN 1 AND 5),
+			pack_id VARCHAR(50) DEFAULT 'custom',
+			times_used INTEGER DEFAULT 0,
+			is_active BOOLEAN DEFAULT true,
+			created_by VARCHAR(50),
+			created_at TIMESTAMP DEFAULT NOW(),
+			updated_at TIMESTAMP DEFAULT NOW()
+		);
+
+		-- 舊版題庫沒有題目包欄位
+		ALTER TABLE two_types_questions ADD COLUMN IF NOT EXISTS pack_id VARCHAR(50) DEFAULT 'custom';
+
 		-- 房間活動日誌
 		CREATE TABLE IF NOT EXISTS room_logs (
 			id SERIAL PRIMARY KEY,
@@ -128,6 +147,11 @@ func CreateTables(db *sql.DB) error {
 		CREATE INDEX IF NOT EXISTS idx_answer_logs_game_id ON answer_logs(game_id);
 		CREATE INDEX IF NOT EXISTS idx_answer_logs_question_id ON answer_logs(question_id);
 		CREATE INDEX IF NOT EXISTS idx_room_logs_room_id ON room_logs(room_id);
+		CREATE INDEX IF NOT EXISTS idx_two_types_questions_category ON two_types_questions(category);
+		CREATE INDEX IF NOT EXISTS idx_two_types_questions_pack_id ON two_types_questions(pack_id);
+
+		-- 同一房間可以重新開始多場遊戲，每場各自保存一筆記錄
+		ALTER TABLE games DROP CONSTRAINT IF EXISTS games_room_id_key;
 	`
 
 	_, err := db.Exec(schema)
@@ -152,26 +176,7 @@ func SeedQuestions(db *sql.DB) error {
 		return nil
 	}
 
-	questions := []struct {
-		text, optA, optB, optC, optD, correct, explanation, category string
-		difficulty int
-	}{
-		{"台灣最高的山是？", "玉山", "雪山", "大霸尖山", "合歡山", "A", "玉山海拔3952公尺，是台灣最高峰", "地理", 1},
-		{"以下哪個不是程式語言？", "Python", "Java", "HTML", "JavaScript", "C", "HTML是標記語言，不是程式語言", "資訊", 2},
-		{"一年有幾個季節？", "2個", "3個", "4個", "5個", "C", "春夏秋冬四個季節", "常識", 1},
-		{"JavaScript 是由哪家公司開發的？", "Microsoft", "Google", "Netscape", "Apple", "C", "JavaScript最初由Netscape公司開發", "資訊", 3},
-		{"世界上最大的海洋是？", "大西洋", "太平洋", "印度洋", "北冰洋", "B", "太平洋是世界上面積最大的海洋", "地理", 1},
-		{"以下哪種動物是哺乳動物？", "鯨魚", "鯊魚", "金魚", "章魚", "A", "鯨魚是海洋哺乳動物", "生物", 2},
-		{"一個正方形有幾個邊？", "3個", "4個", "5個", "6個", "B", "正方形有四個相等的邊", "數學", 1},
-		{"CPU 的中文全名是？", "中央處理器", "記憶體", "硬碟", "主機板", "A", "CPU是Central Processing Unit的縮寫", "資訊", 2},
-		{"彩虹有幾種顏色？", "5種", "6種", "7種", "8種", "C", "彩虹有紅橙黃綠藍靛紫七種顏色", "常識", 2},
-		{"以下哪個是 NoSQL 資料庫？", "MySQL", "PostgreSQL", "MongoDB", "SQLite", "C", "MongoDB是著名的NoSQL文檔型資料庫", "資訊", 3},
-		{"台灣的首都是？", "台北", "高雄", "台中", "台南", "A", "台北是中華民國首都", "地理", 1},
-		{"HTTP 的預設連接埠是？", "80", "443", "21", "22", "A", "HTTP協議的預設連接埠是80", "資訊", 2},
-		{"一打等於幾個？", "10個", "12個", "20個", "24個", "B", "一打(dozen)等於12個", "常識", 1},
-		{"以下哪個是物件導向程式語言？", "C", "JavaScript", "Assembly", "SQL", "B", "JavaScript支援物件導向程式設計", "資訊", 2},
-		{"地球繞太陽一圈需要多久？", "1個月", "3個月", "1年", "2年", "C", "地球繞太陽公轉一圈約365.25天，即一年", "科學", 1},
-	}
+	questions := TriviaSeedQuestions()
 
 	stmt, err := db.Prepare(`
 		INSERT INTO questions (question_text, option_a, option_b, option_c, option_d, 
@@ -184,12 +189,47 @@ func SeedQuestions(db *sql.DB) error {
 	defer stmt.Close()
 
 	for _, q := range questions {
-		_, err := stmt.Exec(q.text, q.optA, q.optB, q.optC, q.optD, 
-			q.correct, q.explanation, q.category, q.difficulty)
+		_, err := stmt.Exec(q.Text, q.OptionA, q.OptionB, q.OptionC, q.OptionD,
+			q.CorrectAnswer, q.Explanation, q.Category, q.Difficulty)
 		if err != nil {
 			return fmt.Errorf("插入題目失敗: %w", err)
 		}
 	}
 
 	return nil
-}
\ No newline at end of file
+}
+
+// TriviaSeed 「你問我答」示例題目
+type TriviaSeed struct {
+	Text          string
+	OptionA       string
+	OptionB       string
+	OptionC       string
+	OptionD       string
+	CorrectAnswer string
+	Explanation   string
+	Category      string
+	Difficulty    int
+}
+
+// TriviaSeedQuestions 獲取「你問我答」示例題目
+// 同時作為資料庫種子數據與無資料庫時的內建題庫
+func TriviaSeedQuestions() []TriviaSeed {
+	return []TriviaSeed{
+		{"台灣最高的山是？", "玉山", "雪山", "大霸尖山", "合歡山", "A", "玉山海拔3952公尺，是台灣最高峰", "地理", 1},
+		{"以下哪個不是程式語言？", "Python", "Java", "HTML", "JavaScript", "C", "HTML是標記語言，不是程式語言", "資訊", 2},
+		{"一年有幾個季節？", "2個", "3個", "4個", "5個", "C", "春夏秋冬四個季節", "常識", 1},
+		{"JavaScript 是由哪家公司開發的？", "Microsoft", "Google", "Netscape", "Apple", "C", "JavaScript最初由Netscape公司開發", "資訊", 3},
+		{"世界上最大的海洋是？", "大西洋", "太平洋", "印度洋", "北冰洋", "B", "太平洋是世界上面積最大的海洋", "地理", 1},
+		{"以下哪種動物是哺乳動物？", "鯨魚", "鯊魚", "金魚", "章魚", "A", "鯨魚是海洋哺乳動物", "生物", 2},
+		{"一個正方形有幾個邊？", "3個", "4個", "5個", "6個", "B", "正方形有四個相等的邊", "數學", 1},
+		{"CPU 的中文全名是？", "中央處理器", "記憶體", "硬碟", "主機板", "A", "CPU是Central Processing Unit的縮寫", "資訊", 2},
+		{"彩虹有幾種顏色？", "5種", "6種", "7種", "8種", "C", "彩虹有紅橙黃綠藍靛紫七種顏色", "常識", 2},
+		{"以下哪個是 NoSQL 資料庫？", "MySQL", "PostgreSQL", "MongoDB", "SQLite", "C", "MongoDB是著名的NoSQL文檔型資料庫", "資訊", 3},
+		{"台灣的首都是？", "台北", "高雄", "台中", "台南", "A", "台北是中華民國首都", "地理", 1},
+		{"HTTP 的預設連接埠是？", "80", "443", "21", "22", "A", "HTTP協議的預設連接埠是80", "資訊", 2},
+		{"一打等於幾個？", "10個", "12個", "20個", "24個", "B", "一打(dozen)等於12個", "常識", 1},
+		{"以下哪個是物件導向程式語言？", "C", "JavaScript", "Assembly", "SQL", "B", "JavaScript支援物件導向程式設計", "資訊", 2},
+		{"地球繞太陽一圈需要多久？", "1個月", "3個月", "1年", "2年", "C", "地球繞太陽公轉一圈約365.25天，即一年", "科學", 1},
+	}
+}
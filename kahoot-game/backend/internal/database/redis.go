@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"sync"
 	"time"
 
 	"kahoot-game/internal/config"
@@ -12,21 +13,17 @@ import (
 )
 
 // NewRedisClient 創建新的 Redis 客戶端
-func NewRedisClient(cfg *config.Config) *redis.Client {
+// 設定了 REDIS_URL 時以它為準，格式錯誤時回傳錯誤，不會改用其他 Redis 設定
+func NewRedisClient(cfg *config.Config) (*redis.Client, error) {
 	var opts *redis.Options
-	var err error
 
 	if cfg.Redis.URL != "" {
+		var err error
 		opts, err = redis.ParseURL(cfg.Redis.URL)
 		if err != nil {
-			// 如果解析失敗，回退到普通配置或 panic
-			fmt.Printf("解析 Redis URL 失敗: %v\n", err)
-			// 這裡我們選擇繼續使用普通配置作為備案，或者直接返回錯誤（但函數簽名不支援）
-			// 為了簡單起見，我們假設 URL 正確，或者在下面覆蓋
+			return nil, fmt.Errorf("解析 REDIS_URL 失敗: %w", err)
 		}
-	}
-
-	if opts == nil {
+	} else {
 		opts = &redis.Options{
 			Addr:     cfg.GetRedisAddr(),
 			Password: cfg.Redis.Password,
@@ -46,30 +43,67 @@ func NewRedisClient(cfg *config.Config) *redis.Client {
 	opts.MaxRetryBackoff = 512 * time.Millisecond
 
 	rdb := redis.NewClient(opts)
-	return rdb
+	return rdb, nil
 }
 
-// TestConnections 測試資料庫連線
-func TestConnections(db interface{}, redisClient *redis.Client) error {
-	// 測試 PostgreSQL 連線
-	if postgresDB, ok := db.(*sql.DB); ok {
-		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-		defer cancel()
-		
-		if err := postgresDB.PingContext(ctx); err != nil {
-			return fmt.Errorf("PostgreSQL 連線失敗: %w", err)
-		}
+// ConnectionStatus 單一依賴服務的連線檢查結果
+type ConnectionStatus struct {
+	Configured bool          // 是否有使用此服務（未連線成功或未設定時為 false）
+	Err        error         // 連線失敗的原因，正常時為 nil
+	Latency    time.Duration // 檢查耗時
+}
+
+// ConnectionReport PostgreSQL 與 Redis 的連線檢查結果
+type ConnectionReport struct {
+	Postgres ConnectionStatus
+	Redis    ConnectionStatus
+}
+
+// TestConnections 同時測試 PostgreSQL 與 Redis 連線，db 或 redisClient 為 nil 時視為未使用
+// 每項檢查最多等待 ctx 的期限
+func TestConnections(ctx context.Context, db *sql.DB, redisClient *redis.Client) ConnectionReport {
+	var report ConnectionReport
+	var wg sync.WaitGroup
+
+	if db != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			report.Postgres = checkConnection(func() error {
+				if err := db.PingContext(ctx); err != nil {
+					return fmt.Errorf("PostgreSQL 連線失敗: %w", err)
+				}
+				return nil
+			})
+		}()
 	}
 
-	// 測試 Redis 連線
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-	
-	if err := redisClient.Ping(ctx).Err(); err != nil {
-		return fmt.Errorf("Redis 連線失敗: %w", err)
+	if redisClient != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			report.Redis = checkConnection(func() error {
+				if err := redisClient.Ping(ctx).Err(); err != nil {
+					return fmt.Errorf("Redis 連線失敗: %w", err)
+				}
+				return nil
+			})
+		}()
 	}
 
-	return nil
+	wg.Wait()
+	return report
+}
+
+// checkConnection 執行單項連線檢查並記錄耗時
+func checkConnection(ping func() error) ConnectionStatus {
+	started := time.Now()
+	err := ping()
+	return ConnectionStatus{
+		Configured: true,
+		Err:        err,
+		Latency:    time.Since(started),
+	}
 }
 
 // Redis Key 常數定義
@@ -90,6 +124,12 @@ const (
 	// 活躍房間列表
 	ActiveRoomsKey = "active_rooms"
 	
+	// 跨節點房間事件（pub/sub 頻道）
+	RoomEventsPrefix = "room_events:"     // room_events:{roomId}
+	
+	// 房間計時器租約（決定由哪個節點負責倒數）
+	SchedulerLeasePrefix = "room_scheduler:" // room_scheduler:{roomId}
+	
 	// 過期時間
 	RoomExpiration    = 24 * time.Hour    // 房間 24 小時後過期
 	PlayerExpiration  = 2 * time.Hour     // 玩家 2 小時後過期
@@ -129,5 +169,15 @@ func (r *RedisKeys) AnswersKey(roomID string, questionID int) string {
 	return fmt.Sprintf("%s%s:%d", AnswersPrefix, roomID, questionID)
 }
 
+// RoomEventsChannel 獲取房間事件頻道
+func (r *RedisKeys) RoomEventsChannel(roomID string) string {
+	return RoomEventsPrefix + roomID
+}
+
+// SchedulerLeaseKey 獲取房間計時器租約鍵值
+func (r *RedisKeys) SchedulerLeaseKey(roomID string) string {
+	return SchedulerLeasePrefix + roomID
+}
+
 // 全域 Redis 鍵值輔助器實例
 var Keys = NewRedisKeys()
\ No newline at end of file
@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequireAdminToken 需在 Authorization 標頭帶上管理憑證：Bearer <adminToken>
+// 未設定管理憑證時一律拒絕，避免題庫在沒有保護的情況下被任何人修改
+func RequireAdminToken(adminToken string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if adminToken == "" {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"success": false,
+				"error":   "伺服器未設定管理憑證，無法修改題庫",
+			})
+			return
+		}
+
+		token, ok := bearerToken(c)
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"success": false,
+				"error":   "缺少管理憑證",
+			})
+			return
+		}
+		if subtle.ConstantTimeCompare([]byte(token), []byte(adminToken)) != 1 {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"success": false,
+				"error":   "管理憑證無效",
+			})
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRequireAdminToken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	const adminToken = "0123456789abcdef"
+
+	tests := []struct {
+		name          string
+		adminToken    string // 伺服器設定的管理憑證
+		authorization string
+		wantStatus    int
+	}{
+		{name: "憑證正確", adminToken: adminToken, authorization: "Bearer " + adminToken, wantStatus: http.StatusOK},
+		{name: "Bearer 不分大小寫", adminToken: adminToken, authorization: "bearer " + adminToken, wantStatus: http.StatusOK},
+		{name: "缺少憑證", adminToken: adminToken, wantStatus: http.StatusUnauthorized},
+		{name: "憑證錯誤", adminToken: adminToken, authorization: "Bearer wrong-token", wantStatus: http.StatusUnauthorized},
+		{name: "不是 Bearer 憑證", adminToken: adminToken, authorization: "Basic " + adminToken, wantStatus: http.StatusUnauthorized},
+		{name: "未設定管理憑證時一律拒絕", authorization: "Bearer ", wantStatus: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.POST("/api/questions", RequireAdminToken(tt.adminToken), func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodPost, "/api/questions", nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("狀態碼 = %d, 預期 %d", rec.Code, tt.wantStatus)
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"kahoot-game/internal/services"
+	"kahoot-game/internal/websocket"
+
+	"github.com/gin-gonic/gin"
+)
+
+// HealthHandler 存活與就緒檢查處理器
+type HealthHandler struct {
+	healthService *services.HealthService
+	hub           *websocket.Hub
+}
+
+// NewHealthHandler 創建健康檢查處理器
+func NewHealthHandler(healthService *services.HealthService, hub *websocket.Hub) *HealthHandler {
+	return &HealthHandler{
+		healthService: healthService,
+		hub:           hub,
+	}
+}
+
+// Liveness 存活檢查：只要程式能回應就回傳 200，不檢查依賴服務
+func (h *HealthHandler) Liveness(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"status":        "alive",
+		"service":       "kahoot-game-backend",
+		"version":       h.healthService.Version(),
+		"uptimeSeconds": h.healthService.Uptime().Seconds(),
+		"timestamp":     time.Now().UTC(),
+	})
+}
+
+// Readiness 就緒檢查：房間資料存放在 Redis 且 Redis 無法連線時回傳 503，讓負載平衡器停止導入流量
+// PostgreSQL 無法連線時仍回傳 200，但 status 為 degraded
+func (h *HealthHandler) Readiness(c *gin.Context) {
+	report := h.healthService.Check(c.Request.Context())
+
+	statusCode := http.StatusOK
+	if !report.Ready {
+		statusCode = http.StatusServiceUnavailable
+	}
+
+	c.JSON(statusCode, gin.H{
+		"status":        report.Status,
+		"ready":         report.Ready,
+		"degraded":      report.Degraded,
+		"service":       "kahoot-game-backend",
+		"version":       report.Version,
+		"roomStorage":   report.RoomStorage,
+		"gameStorage":   report.GameStorage,
+		"dependencies":  report.Dependencies,
+		"uptimeSeconds": report.UptimeSeconds,
+		"hub": gin.H{
+			"clients": h.hub.GetTotalClients(),
+			"rooms":   h.hub.GetTotalRooms(),
+		},
+		"timestamp": report.Timestamp,
+	})
+}
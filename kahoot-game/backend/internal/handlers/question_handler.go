@@ -1,6 +1,9 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
 	"strconv"
 
@@ -55,7 +58,16 @@ func (h *QuestionHandler) GetQuestions(c *gin.Context) {
 		limit = 50 // 預設限制
 	}
 
-	questions, err := h.questionService.GetQuestions(category, difficulty, limit)
+	// 管理題庫時可帶 includeInactive=true 列出已停用的題目
+	includeInactive := c.Query("includeInactive") == "true"
+
+	questions, err := h.questionService.GetQuestions(models.QuestionFilter{
+		Category:        category,
+		Difficulty:      difficulty,
+		PackID:          c.Query("pack"),
+		IncludeInactive: includeInactive,
+		Limit:           limit,
+	})
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
@@ -128,4 +140,255 @@ func (h *QuestionHandler) CreateQuestion(c *gin.Context) {
 		"data":    question,
 		"message": "題目創建成功",
 	})
-}
\ No newline at end of file
+}
+// GetQuestion 獲取單一題目
+func (h *QuestionHandler) GetQuestion(c *gin.Context) {
+	questionID, ok := parseQuestionID(c)
+	if !ok {
+		return
+	}
+
+	question, err := h.questionService.GetQuestion(questionID)
+	if err != nil {
+		respondQuestionError(c, err, "獲取題目失敗")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    question,
+	})
+}
+
+// UpdateQuestion 更新題目
+func (h *QuestionHandler) UpdateQuestion(c *gin.Context) {
+	questionID, ok := parseQuestionID(c)
+	if !ok {
+		return
+	}
+
+	var req models.UpdateQuestionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "請求資料格式錯誤",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	question, err := h.questionService.UpdateQuestion(questionID, &req)
+	if err != nil {
+		respondQuestionError(c, err, "更新題目失敗")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    question,
+		"message": "題目更新成功",
+	})
+}
+
+// DeleteQuestion 刪除題目
+func (h *QuestionHandler) DeleteQuestion(c *gin.Context) {
+	questionID, ok := parseQuestionID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.questionService.DeleteQuestion(questionID); err != nil {
+		respondQuestionError(c, err, "刪除題目失敗")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "題目已刪除",
+	})
+}
+
+// DeactivateQuestion 停用題目，停用後不會再出現在遊戲中
+func (h *QuestionHandler) DeactivateQuestion(c *gin.Context) {
+	h.setQuestionActive(c, false)
+}
+
+// ActivateQuestion 重新啟用題目
+func (h *QuestionHandler) ActivateQuestion(c *gin.Context) {
+	h.setQuestionActive(c, true)
+}
+
+func (h *QuestionHandler) setQuestionActive(c *gin.Context, active bool) {
+	questionID, ok := parseQuestionID(c)
+	if !ok {
+		return
+	}
+
+	question, err := h.questionService.SetQuestionActive(questionID, active)
+	if err != nil {
+		respondQuestionError(c, err, "更新題目狀態失敗")
+		return
+	}
+
+	message := "題目已停用"
+	if active {
+		message = "題目已啟用"
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    question,
+		"message": message,
+	})
+}
+
+// parseQuestionID 解析路徑中的題目ID，失敗時直接回應錯誤
+func parseQuestionID(c *gin.Context) (int, bool) {
+	questionID, err := strconv.Atoi(c.Param("questionId"))
+	if err != nil || questionID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "無效的題目ID",
+		})
+		return 0, false
+	}
+	return questionID, true
+}
+
+// respondQuestionError 依錯誤類型回應題目操作失敗
+func respondQuestionError(c *gin.Context, err error, message string) {
+	status := http.StatusInternalServerError
+	if errors.Is(err, services.ErrQuestionNotFound) {
+		status = http.StatusNotFound
+	}
+
+	c.JSON(status, gin.H{
+		"success": false,
+		"error":   message,
+		"details": err.Error(),
+	})
+}
+
+// 匯入檔案大小上限
+const maxQuestionPackSize = 5 << 20
+
+// ImportQuestions 匯入題目包（JSON / CSV / YAML）
+// 可用 multipart 欄位 file 上傳檔案，或直接把檔案內容放在請求本文；
+// 查詢參數 format 指定格式（預設依檔名判斷），pack 指定題目包，dryRun=true 時只驗證不寫入
+func (h *QuestionHandler) ImportQuestions(c *gin.Context) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxQuestionPackSize)
+
+	filename := ""
+	var data []byte
+	var err error
+
+	if fileHeader, fileErr := c.FormFile("file"); fileErr == nil {
+		filename = fileHeader.Filename
+		file, openErr := fileHeader.Open()
+		if openErr != nil {
+			err = openErr
+		} else {
+			data, err = io.ReadAll(file)
+			file.Close()
+		}
+	} else {
+		data, err = io.ReadAll(c.Request.Body)
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "讀取題目包失敗",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	format, err := services.DetectPackFormat(c.Query("format"), filename)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "不支援的題目包格式",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	pack, err := services.ParseQuestionPack(data, format)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "題目包格式錯誤",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if packID := c.Query("pack"); packID != "" {
+		pack.PackID = packID
+	}
+
+	result, err := h.questionService.ImportQuestionPack(pack, c.Query("dryRun") == "true")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "匯入題目包失敗",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    result,
+	})
+}
+
+// ExportQuestions 匯出題庫為題目包（JSON / CSV / YAML）
+func (h *QuestionHandler) ExportQuestions(c *gin.Context) {
+	format, err := services.DetectPackFormat(c.Query("format"), "")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "不支援的題目包格式",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	packID := c.Query("pack")
+	pack, err := h.questionService.ExportQuestionPack(models.QuestionFilter{
+		Category:        c.Query("category"),
+		PackID:          packID,
+		IncludeInactive: c.Query("includeInactive") == "true",
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "匯出題目包失敗",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	data, err := services.EncodeQuestionPack(pack, format)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "匯出題目包失敗",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	contentTypes := map[string]string{
+		services.QuestionPackJSON: "application/json; charset=utf-8",
+		services.QuestionPackCSV:  "text/csv; charset=utf-8",
+		services.QuestionPackYAML: "application/yaml; charset=utf-8",
+	}
+
+	if packID == "" {
+		packID = "questions"
+	}
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", packID+"."+format))
+	c.Data(http.StatusOK, contentTypes[format], data)
+}
@@ -1,12 +1,15 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
 	"strings"
 
 	"kahoot-game/internal/models"
 	"kahoot-game/internal/services"
+	"kahoot-game/internal/websocket"
 
 	"github.com/gin-gonic/gin"
 )
@@ -14,13 +17,17 @@ import (
 // RoomHandler 房間處理器
 type RoomHandler struct {
 	roomService *services.RoomService
+	roomLogs    *services.RoomLogService
+	hostTokens  *services.HostTokenService
 	frontendURL string
 }
 
 // NewRoomHandler 創建房間處理器
-func NewRoomHandler(roomService *services.RoomService, frontendURL string) *RoomHandler {
+func NewRoomHandler(roomService *services.RoomService, roomLogs *services.RoomLogService, hostTokens *services.HostTokenService, frontendURL string) *RoomHandler {
 	return &RoomHandler{
 		roomService: roomService,
+		roomLogs:    roomLogs,
+		hostTokens:  hostTokens,
 		frontendURL: strings.TrimSuffix(frontendURL, "/"),
 	}
 }
@@ -37,8 +44,53 @@ func (h *RoomHandler) CreateRoom(c *gin.Context) {
 		return
 	}
 
-	room, err := h.roomService.CreateRoom(req.HostName, req.TotalQuestions, req.QuestionTimeLimit)
+	room, err := h.roomService.CreateRoom(req.HostName, req.GameMode, req.TotalQuestions, req.QuestionTimeLimit, &req.QuestionSelection, req.Teams, req.Passcode, req.Public)
+	if errors.Is(err, services.ErrUnknownGameMode) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "不支援的遊戲模式",
+			"details": err.Error(),
+		})
+		return
+	}
+	if errors.Is(err, services.ErrInvalidQuestionSelection) || errors.Is(err, services.ErrSelectionUnsupported) ||
+		errors.Is(err, services.ErrNotEnoughQuestions) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "選題條件無法使用",
+			"details": err.Error(),
+		})
+		return
+	}
+	if errors.Is(err, services.ErrInvalidTeams) || errors.Is(err, services.ErrTeamsUnsupported) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "隊伍設定錯誤",
+			"details": err.Error(),
+		})
+		return
+	}
+	if errors.Is(err, services.ErrInvalidPasscode) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "房間密碼格式錯誤",
+			"details": err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "創建房間失敗",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	// 簽發主持人憑證，之後以此憑證加入房間、控制遊戲與刪除房間
+	hostToken, err := h.hostTokens.Issue(room.ID, room.HostName)
 	if err != nil {
+		h.roomService.DeleteRoom(room.ID)
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
 			"error":   "創建房間失敗",
@@ -55,8 +107,15 @@ func (h *RoomHandler) CreateRoom(c *gin.Context) {
 		"data": gin.H{
 			"roomId":            room.ID,
 			"hostName":          room.HostName,
+			"gameMode":          room.GameMode,
 			"totalQuestions":    room.TotalQuestions,
 			"questionTimeLimit": room.QuestionTimeLimit,
+			"maxPlayers":        h.roomService.MaxPlayersPerRoom(),
+			"questionSelection": room.QuestionSelection,
+			"teams":             room.Teams,
+			"hasPasscode":       room.HasPasscode,
+			"isPublic":          room.IsPublic,
+			"hostToken":         hostToken,
 			"qrCode":            "https://api.qrserver.com/v1/create-qr-code/?size=200x200&data=" + qrCodeData,
 			"joinUrl":           joinUrl,
 			"createdAt":         room.CreatedAt,
@@ -64,6 +123,106 @@ func (h *RoomHandler) CreateRoom(c *gin.Context) {
 	})
 }
 
+// SetPasscode 主持人設定或更換房間密碼，passcode 為空字串時取消密碼
+// 需在 Authorization 標頭帶上主持人憑證：Bearer <hostToken>
+func (h *RoomHandler) SetPasscode(c *gin.Context) {
+	roomID := c.Param("roomId")
+	if !h.authorizeHost(c, roomID) {
+		return
+	}
+
+	var req models.SetPasscodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "請求資料格式錯誤",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	h.updatePasscode(c, roomID, req.Passcode)
+}
+
+// ClearPasscode 主持人取消房間密碼
+// 需在 Authorization 標頭帶上主持人憑證：Bearer <hostToken>
+func (h *RoomHandler) ClearPasscode(c *gin.Context) {
+	roomID := c.Param("roomId")
+	if !h.authorizeHost(c, roomID) {
+		return
+	}
+
+	h.updatePasscode(c, roomID, "")
+}
+
+func (h *RoomHandler) updatePasscode(c *gin.Context, roomID, passcode string) {
+	room, err := h.roomService.SetPasscode(roomID, passcode)
+	if errors.Is(err, services.ErrInvalidPasscode) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "房間密碼格式錯誤",
+			"details": err.Error(),
+		})
+		return
+	}
+	if errors.Is(err, services.ErrRoomNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "房間不存在",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "更新房間密碼失敗",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"roomId":      room.ID,
+			"hasPasscode": room.HasPasscode,
+		},
+	})
+}
+
+// authorizeHost 驗證 Authorization 標頭中的主持人憑證，失敗時直接回應 401
+func (h *RoomHandler) authorizeHost(c *gin.Context, roomID string) bool {
+	hostToken, ok := bearerToken(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "缺少主持人憑證",
+		})
+		return false
+	}
+	if _, err := h.hostTokens.Verify(hostToken, roomID); err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "主持人憑證無效",
+			"details": err.Error(),
+		})
+		return false
+	}
+	return true
+}
+
+// bearerToken 從 Authorization 標頭取出 Bearer 憑證
+func bearerToken(c *gin.Context) (string, bool) {
+	header := c.GetHeader("Authorization")
+	scheme, token, found := strings.Cut(header, " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
+
 func (h *RoomHandler) buildJoinURL(c *gin.Context, roomID string) string {
 	if h.frontendURL != "" {
 		return fmt.Sprintf("%s/join/%s", h.frontendURL, roomID)
@@ -76,7 +235,28 @@ func (h *RoomHandler) buildJoinURL(c *gin.Context, roomID string) string {
 	return fmt.Sprintf("%s://%s/join/%s", scheme, c.Request.Host, roomID)
 }
 
+// ListRooms 列出等待中的公開房間（?gameMode= 只列出指定遊戲模式）
+func (h *RoomHandler) ListRooms(c *gin.Context) {
+	rooms, err := h.roomService.ListPublicRooms(c.Query("gameMode"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "獲取房間列表失敗",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    rooms,
+		"count":   len(rooms),
+	})
+}
+
 // GetRoom 獲取房間資訊
+// 有密碼的房間只回傳摘要，需帶上主持人憑證（Authorization: Bearer <hostToken>）
+// 或房間密碼（X-Room-Passcode 標頭）才回傳完整房間資訊
 func (h *RoomHandler) GetRoom(c *gin.Context) {
 	roomID := c.Param("roomId")
 
@@ -90,15 +270,72 @@ func (h *RoomHandler) GetRoom(c *gin.Context) {
 		return
 	}
 
+	if room.HasPasscode {
+		allowed, ok := h.canViewPasscodeRoom(c, roomID)
+		if !ok {
+			return
+		}
+		if !allowed {
+			c.JSON(http.StatusOK, gin.H{
+				"success":          true,
+				"data":             room.Info(h.roomService.MaxPlayersPerRoom()),
+				"passcodeRequired": true,
+			})
+			return
+		}
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"data":    room,
+		"data":    room.PublicView(),
 	})
 }
 
+// canViewPasscodeRoom 是否可查看有密碼房間的完整資訊
+// 密碼錯誤或暫停嘗試時直接回應並回傳 ok=false；沒有提供憑證或密碼時回傳 allowed=false
+func (h *RoomHandler) canViewPasscodeRoom(c *gin.Context, roomID string) (allowed, ok bool) {
+	if hostToken, found := bearerToken(c); found {
+		if _, err := h.hostTokens.Verify(hostToken, roomID); err == nil {
+			return true, true
+		}
+	}
+
+	passcode := c.GetHeader("X-Room-Passcode")
+	if passcode == "" {
+		return false, true
+	}
+
+	err := h.roomService.CheckPasscode(roomID, passcode, websocket.RemoteIP(c.Request))
+	switch {
+	case err == nil:
+		return true, true
+	case errors.Is(err, services.ErrWrongPasscode):
+		c.JSON(http.StatusForbidden, gin.H{
+			"success": false,
+			"error":   "房間密碼錯誤",
+		})
+	case errors.Is(err, services.ErrPasscodeLocked):
+		c.JSON(http.StatusTooManyRequests, gin.H{
+			"success": false,
+			"error":   "密碼錯誤次數過多，請稍後再試",
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "檢查房間密碼失敗",
+			"details": err.Error(),
+		})
+	}
+	return false, false
+}
+
 // DeleteRoom 刪除房間
+// 需在 Authorization 標頭帶上主持人憑證：Bearer <hostToken>
 func (h *RoomHandler) DeleteRoom(c *gin.Context) {
 	roomID := c.Param("roomId")
+	if !h.authorizeHost(c, roomID) {
+		return
+	}
 
 	err := h.roomService.DeleteRoom(roomID)
 	if err != nil {
@@ -115,3 +352,63 @@ func (h *RoomHandler) DeleteRoom(c *gin.Context) {
 		"message": "房間已刪除",
 	})
 }
+
+// GetRoomEvents 分頁查詢房間事件時間軸
+// 以 after 傳入上一頁回傳的 nextCursor 取得下一頁；房間刪除後仍可查詢
+// 需在 Authorization 標頭帶上主持人憑證：Bearer <hostToken>
+func (h *RoomHandler) GetRoomEvents(c *gin.Context) {
+	roomID := c.Param("roomId")
+	if !h.authorizeHost(c, roomID) {
+		return
+	}
+	eventType := c.Query("type")
+
+	var afterID int64
+	if afterStr := c.Query("after"); afterStr != "" {
+		parsed, err := strconv.ParseInt(afterStr, 10, 64)
+		if err != nil || parsed < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   "無效的 after 參數",
+			})
+			return
+		}
+		afterID = parsed
+	}
+
+	limit := services.DefaultRoomLogPageSize
+	if limitStr := c.Query("limit"); limitStr != "" {
+		parsed, err := strconv.Atoi(limitStr)
+		if err != nil || parsed <= 0 || parsed > services.MaxRoomLogPageSize {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   fmt.Sprintf("無效的限制參數 (1-%d)", services.MaxRoomLogPageSize),
+			})
+			return
+		}
+		limit = parsed
+	}
+
+	events, err := h.roomLogs.ListEvents(roomID, afterID, eventType, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "獲取房間事件失敗",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	nextCursor := afterID
+	if len(events) > 0 {
+		nextCursor = events[len(events)-1].ID
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":    true,
+		"data":       events,
+		"count":      len(events),
+		"nextCursor": nextCursor,
+		"hasMore":    len(events) == limit,
+	})
+}
@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"kahoot-game/internal/config"
+	"kahoot-game/internal/models"
+	"kahoot-game/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// roomHandlerTest 使用記憶體房間存放的房間 API
+type roomHandlerTest struct {
+	router      *gin.Engine
+	roomService *services.RoomService
+	roomLogs    *services.RoomLogService
+	hostTokens  *services.HostTokenService
+}
+
+func newRoomHandlerTest(t *testing.T) *roomHandlerTest {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	gameService := services.NewGameService(nil, nil, services.NewQuestionService(nil, logger), logger)
+	roomLogs := services.NewRoomLogService(nil, logger)
+	roomService := services.NewRoomService(services.NewMemoryRoomStore(), gameService, roomLogs, config.GameConfig{}, logger)
+	hostTokens := services.NewHostTokenService("test-secret", time.Hour)
+	handler := NewRoomHandler(roomService, roomLogs, hostTokens, "")
+
+	router := gin.New()
+	router.GET("/api/rooms/:roomId", handler.GetRoom)
+	router.GET("/api/rooms/:roomId/events", handler.GetRoomEvents)
+
+	return &roomHandlerTest{router: router, roomService: roomService, roomLogs: roomLogs, hostTokens: hostTokens}
+}
+
+// createRoom 建立房間並回傳房間與主持人憑證
+func (h *roomHandlerTest) createRoom(t *testing.T, passcode string) (*models.Room, string) {
+	t.Helper()
+
+	room, err := h.roomService.CreateRoom("主持人", "", 0, 0, nil, nil, passcode, false)
+	if err != nil {
+		t.Fatalf("建立房間失敗: %v", err)
+	}
+	token, err := h.hostTokens.Issue(room.ID, room.HostName)
+	if err != nil {
+		t.Fatalf("簽發主持人憑證失敗: %v", err)
+	}
+	return room, token
+}
+
+// get 送出 GET 請求並回傳狀態碼與回應內容
+func (h *roomHandlerTest) get(t *testing.T, path string, header map[string]string) (int, map[string]interface{}) {
+	t.Helper()
+
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	for key, value := range header {
+		req.Header.Set(key, value)
+	}
+	rec := httptest.NewRecorder()
+	h.router.ServeHTTP(rec, req)
+
+	var body map[string]interface{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("回應解析失敗: %v", err)
+	}
+	return rec.Code, body
+}
+
+func TestGetRoomEventsRequiresHostToken(t *testing.T) {
+	h := newRoomHandlerTest(t)
+	room, hostToken := h.createRoom(t, "")
+	_, otherToken := h.createRoom(t, "")
+
+	tests := []struct {
+		name          string
+		authorization string
+		wantStatus    int
+	}{
+		{name: "主持人憑證", authorization: "Bearer " + hostToken, wantStatus: http.StatusOK},
+		{name: "缺少憑證", wantStatus: http.StatusUnauthorized},
+		{name: "其他房間的憑證", authorization: "Bearer " + otherToken, wantStatus: http.StatusUnauthorized},
+		{name: "憑證錯誤", authorization: "Bearer not-a-token", wantStatus: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := map[string]string{}
+			if tt.authorization != "" {
+				header["Authorization"] = tt.authorization
+			}
+
+			status, body := h.get(t, "/api/rooms/"+room.ID+"/events", header)
+			if status != tt.wantStatus {
+				t.Fatalf("狀態碼 = %d, 預期 %d", status, tt.wantStatus)
+			}
+			if status == http.StatusOK && body["count"] != float64(1) {
+				t.Errorf("事件數 = %v, 預期只有建立房間的事件", body["count"])
+			}
+		})
+	}
+}
+
+func TestGetPasscodeRoom(t *testing.T) {
+	const passcode = "1234"
+
+	h := newRoomHandlerTest(t)
+	room, hostToken := h.createRoom(t, passcode)
+	_, otherToken := h.createRoom(t, "")
+	if _, err := h.roomService.AddPlayer(room.ID, "p1", "小明", passcode, "198.51.100.2"); err != nil {
+		t.Fatalf("加入房間失敗: %v", err)
+	}
+	openRoom, _ := h.createRoom(t, "")
+
+	tests := []struct {
+		name       string
+		roomID     string
+		header     map[string]string
+		wantStatus int
+		wantFull   bool // 是否回傳完整房間資訊（包含玩家）
+	}{
+		{name: "沒有密碼的房間", roomID: openRoom.ID, wantStatus: http.StatusOK, wantFull: true},
+		{name: "未提供憑證只回傳摘要", roomID: room.ID, wantStatus: http.StatusOK},
+		{name: "其他房間的主持人憑證只回傳摘要", roomID: room.ID, header: map[string]string{"Authorization": "Bearer " + otherToken}, wantStatus: http.StatusOK},
+		{name: "主持人憑證", roomID: room.ID, header: map[string]string{"Authorization": "Bearer " + hostToken}, wantStatus: http.StatusOK, wantFull: true},
+		{name: "房間密碼", roomID: room.ID, header: map[string]string{"X-Room-Passcode": passcode}, wantStatus: http.StatusOK, wantFull: true},
+		{name: "密碼錯誤", roomID: room.ID, header: map[string]string{"X-Room-Passcode": "0000"}, wantStatus: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, body := h.get(t, "/api/rooms/"+tt.roomID, tt.header)
+			if status != tt.wantStatus {
+				t.Fatalf("狀態碼 = %d, 預期 %d", status, tt.wantStatus)
+			}
+			if status != http.StatusOK {
+				return
+			}
+
+			data, _ := body["data"].(map[string]interface{})
+			_, hasPlayers := data["players"]
+			if hasPlayers != tt.wantFull {
+				t.Errorf("回傳玩家資料 = %v, 預期 %v: %v", hasPlayers, tt.wantFull, data)
+			}
+			if required := body["passcodeRequired"] == true; required == tt.wantFull {
+				t.Errorf("passcodeRequired = %v", body["passcodeRequired"])
+			}
+		})
+	}
+}
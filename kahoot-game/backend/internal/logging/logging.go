@@ -0,0 +1,62 @@
+package logging
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+
+	"kahoot-game/internal/config"
+)
+
+// 各處共用的日誌欄位名稱，方便在日誌系統中依房間、連線或題目篩選
+const (
+	KeyRoomID      = "roomId"
+	KeyClientID    = "clientId"
+	KeyPlayerID    = "playerId"
+	KeyQuestionNum = "questionNum"
+	KeyGameMode    = "gameMode"
+	KeyError       = "error"
+)
+
+// New 依 LOG_LEVEL 與 LOG_FORMAT 建立日誌記錄器，輸出到 w
+func New(cfg config.LogConfig, w io.Writer) (*slog.Logger, error) {
+	level, err := ParseLevel(cfg.Level)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := &slog.HandlerOptions{Level: level}
+
+	var handler slog.Handler
+	switch strings.ToLower(strings.TrimSpace(cfg.Format)) {
+	case "", "json":
+		handler = slog.NewJSONHandler(w, opts)
+	case "text":
+		handler = slog.NewTextHandler(w, opts)
+	default:
+		return nil, fmt.Errorf("不支援的 LOG_FORMAT: %q（可用 json、text）", cfg.Format)
+	}
+
+	return slog.New(handler).With("service", "kahoot-game-backend"), nil
+}
+
+// ParseLevel 解析日誌等級（debug、info、warn、error）
+func ParseLevel(value string) (slog.Level, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "", "info":
+		return slog.LevelInfo, nil
+	case "warn", "warning":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	}
+	return slog.LevelInfo, fmt.Errorf("不支援的 LOG_LEVEL: %q（可用 debug、info、warn、error）", value)
+}
+
+// Err 錯誤欄位
+func Err(err error) slog.Attr {
+	return slog.Any(KeyError, err)
+}
@@ -0,0 +1,207 @@
+package metrics
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "kahoot"
+
+// 未列在 knownMessageTypes 中的訊息類型統一記為 unknown，避免客戶端任意送入的類型造成標籤爆量
+const unknownMessageType = "unknown"
+
+var knownMessageTypes = map[string]bool{
+	"CREATE_ROOM":       true,
+	"JOIN_ROOM":         true,
+	"QUICK_JOIN":        true,
+	"JOIN_AS_HOST":      true,
+	"JOIN_AS_SPECTATOR": true,
+	"ASSIGN_TEAM":       true,
+	"BALANCE_TEAMS":     true,
+	"START_GAME":        true,
+	"PAUSE_GAME":        true,
+	"RESUME_GAME":       true,
+	"SKIP_QUESTION":     true,
+	"EXTEND_TIME":       true,
+	"END_GAME":          true,
+	"KICK_PLAYER":       true,
+	"SUBMIT_ANSWER":     true,
+	"LEAVE_ROOM":        true,
+	"RESUME_SESSION":    true,
+	"PING":              true,
+}
+
+var registry = prometheus.NewRegistry()
+
+var (
+	websocketMessages = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "websocket_messages_total",
+		Help:      "已處理的 WebSocket 訊息數（依訊息類型）",
+	}, []string{"type"})
+
+	droppedSends = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "websocket_dropped_sends_total",
+		Help:      "因連線發送佇列已滿而丟棄的訊息數",
+	})
+
+	questionsInvalidated = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "questions_invalidated_total",
+		Help:      "作廢或跳過的題目數（依原因）",
+	}, []string{"reason"})
+
+	gamesFinished = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "games_finished_total",
+		Help:      "已結束的遊戲數（依遊戲模式）",
+	}, []string{"game_mode"})
+
+	answerResponseTime = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "answer_response_seconds",
+		Help:      "玩家答題所用時間（秒）",
+		Buckets:   []float64{1, 2, 3, 5, 7.5, 10, 15, 20, 30, 45, 60},
+	}, []string{"game_mode"})
+
+	redisRoomLatency = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "redis_room_operation_seconds",
+		Help:      "Redis 房間讀取與更新的耗時（秒），更新包含衝突重試",
+		Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1},
+	}, []string{"operation"})
+
+	janitorRoomsReaped = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "janitor_rooms_reaped_total",
+		Help:      "房間清理器回收的房間數（依原因）",
+	}, []string{"reason"})
+
+	janitorCleaned = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "janitor_cleaned_total",
+		Help:      "房間清理器整理的項目數（依種類）",
+	}, []string{"kind"})
+
+	janitorErrors = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "janitor_errors_total",
+		Help:      "房間清理器執行時發生的錯誤數",
+	})
+)
+
+// Redis 房間操作的種類
+const (
+	OperationGetRoom    = "get_room"
+	OperationUpdateRoom = "update_room"
+)
+
+// 房間清理器整理項目的種類
+const (
+	CleanedClientsNotified    = "clients_notified"
+	CleanedActiveRoomsPruned  = "active_rooms_pruned"
+	CleanedPlayerKeysDeleted  = "player_keys_deleted"
+	CleanedHubRoomsReconciled = "hub_rooms_reconciled"
+)
+
+func init() {
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		websocketMessages,
+		droppedSends,
+		questionsInvalidated,
+		gamesFinished,
+		answerResponseTime,
+		redisRoomLatency,
+		janitorRoomsReaped,
+		janitorCleaned,
+		janitorErrors,
+	)
+}
+
+// HubSource 提供即時連線狀態的來源（由 WebSocket Hub 實作）
+type HubSource interface {
+	GetTotalClients() int
+	GetTotalRooms() int
+}
+
+// RegisterHub 註冊目前連線數與房間數，每次抓取時即時向 Hub 查詢
+func RegisterHub(hub HubSource) {
+	registry.MustRegister(
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "connected_clients",
+			Help:      "本節點目前的 WebSocket 連線數",
+		}, func() float64 { return float64(hub.GetTotalClients()) }),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "active_rooms",
+			Help:      "本節點目前有連線的房間數",
+		}, func() float64 { return float64(hub.GetTotalRooms()) }),
+	)
+}
+
+// Handler 以 Prometheus 文字格式輸出所有指標
+func Handler() http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+}
+
+// MessageHandled 記錄處理了一則 WebSocket 訊息
+func MessageHandled(msgType string) {
+	if !knownMessageTypes[msgType] {
+		msgType = unknownMessageType
+	}
+	websocketMessages.WithLabelValues(msgType).Inc()
+}
+
+// SendDropped 記錄一則因發送佇列已滿而丟棄的訊息
+func SendDropped() {
+	droppedSends.Inc()
+}
+
+// QuestionInvalidated 記錄一題被作廢或跳過
+func QuestionInvalidated(reason string) {
+	questionsInvalidated.WithLabelValues(reason).Inc()
+}
+
+// GameFinished 記錄一場遊戲結束
+func GameFinished(gameMode string) {
+	gamesFinished.WithLabelValues(gameMode).Inc()
+}
+
+// AnswerSubmitted 記錄玩家答題所用時間
+func AnswerSubmitted(gameMode string, responseTime float64) {
+	answerResponseTime.WithLabelValues(gameMode).Observe(responseTime)
+}
+
+// ObserveRedis 記錄一次 Redis 房間操作的耗時，用法：defer metrics.ObserveRedis(op, time.Now())
+func ObserveRedis(operation string, started time.Time) {
+	redisRoomLatency.WithLabelValues(operation).Observe(time.Since(started).Seconds())
+}
+
+// RoomsReaped 記錄房間清理器依原因回收的房間數
+func RoomsReaped(reason string, count int64) {
+	if count > 0 {
+		janitorRoomsReaped.WithLabelValues(reason).Add(float64(count))
+	}
+}
+
+// JanitorCleaned 記錄房間清理器整理的項目數
+func JanitorCleaned(kind string, count int64) {
+	if count > 0 {
+		janitorCleaned.WithLabelValues(kind).Add(float64(count))
+	}
+}
+
+// JanitorErrors 記錄房間清理器執行時發生的錯誤數
+func JanitorErrors(count int64) {
+	if count > 0 {
+		janitorErrors.Add(float64(count))
+	}
+}
@@ -1,6 +1,9 @@
 package models
 
 import (
+	"encoding/json"
+	"sort"
+	"strings"
 	"time"
 )
 
@@ -11,6 +14,7 @@ type Player struct {
 	RoomID       string    `json:"roomId"`
 	Score        int       `json:"score"`
 	IsHost       bool      `json:"isHost"`       // 是否為房間主持人
+	Team         string    `json:"team,omitempty"` // 所屬隊伍（只有隊伍模式使用）
 	IsConnected  bool      `json:"isConnected"`
 	LastActivity time.Time `json:"lastActivity"`
 	SocketConn   interface{} `json:"-"` // WebSocket 連線，不序列化
@@ -21,6 +25,7 @@ type Room struct {
 	ID                string            `json:"id"`
 	HostID            string            `json:"hostId"`
 	HostName          string            `json:"hostName"`
+	GameMode          string            `json:"gameMode"`          // 遊戲模式代碼
 	Status            RoomStatus        `json:"status"`
 	Players           map[string]*Player `json:"players"`
 	CurrentQuestion   int               `json:"currentQuestion"`
@@ -29,14 +34,42 @@ type Room struct {
 	CurrentHost       string            `json:"currentHost"`       // 當前題目的主角玩家
 	NextHostOverride  string            `json:"nextHostOverride,omitempty"`
 	TimeLeft          int               `json:"timeLeft"`
+	IsPaused          bool              `json:"isPaused,omitempty"`  // 主持人暫停遊戲中
+	PausedAt          *time.Time        `json:"pausedAt,omitempty"`          // 開始暫停的時間
+	QuestionStartedAt *time.Time        `json:"questionStartedAt,omitempty"` // 當前題目開始作答的時間（暫停期間會順延）
 	Questions         []Question        `json:"questions"`
 	Answers           map[string]*Answer `json:"answers"`           // 當前題目的玩家答案
 	GameHistory       []QuestionHistory `json:"gameHistory"`       // 所有題目的答題記錄
+	Undercover        *UndercoverState  `json:"undercover,omitempty"` // 「誰是臥底」狀態
+	QuestionSelection *QuestionSelection `json:"questionSelection,omitempty"` // 房間選題條件
+	Teams             []string          `json:"teams,omitempty"`         // 隊伍名稱，設定兩隊以上即為隊伍模式
+	TeamScores        map[string]int    `json:"teamScores,omitempty"`    // 隊伍累計分數
+	TeamHostTurns     map[string]int    `json:"teamHostTurns,omitempty"` // 各隊輪到主角的次數
+	Bans              []RoomBan         `json:"bans,omitempty"`          // 被禁止再加入的玩家
+	IsPublic          bool              `json:"isPublic,omitempty"`      // 公開房間，會出現在房間列表與快速配對中
+	QuickJoin         bool              `json:"quickJoin,omitempty"`     // 快速配對建立的房間，沒有主持人
+	HasPasscode       bool              `json:"hasPasscode,omitempty"`   // 加入房間需要密碼
+	PasscodeHash      string            `json:"passcodeHash,omitempty"`  // 房間密碼的 bcrypt 雜湊
+	PasscodeAttempts  map[string]*PasscodeAttempt `json:"passcodeAttempts,omitempty"` // 依來源記錄的密碼錯誤次數
 	CreatedAt         time.Time         `json:"createdAt"`
 	StartedAt         *time.Time        `json:"startedAt,omitempty"`
 	FinishedAt        *time.Time        `json:"finishedAt,omitempty"`
 }
 
+// PasscodeAttempt 同一來源（連線 IP）輸入房間密碼錯誤的記錄
+type PasscodeAttempt struct {
+	Failures      int        `json:"failures"`              // 連續輸入錯誤次數
+	LastFailureAt time.Time  `json:"lastFailureAt"`
+	LockedUntil   *time.Time `json:"lockedUntil,omitempty"` // 錯誤次數過多時暫停此來源加入到此時間
+}
+
+// RoomBan 被主持人禁止再加入房間的玩家
+type RoomBan struct {
+	PlayerID   string    `json:"playerId"`
+	PlayerName string    `json:"playerName"`
+	BannedAt   time.Time `json:"bannedAt"`
+}
+
 // RoomStatus 房間狀態枚舉
 type RoomStatus string
 
@@ -49,13 +82,62 @@ const (
 	RoomStatusFinished        RoomStatus = "finished"         // 遊戲結束
 )
 
+// 遊戲模式代碼
+const (
+	GameModeTwoTypes = "two_types" // 2種人
+	GameModeTrivia   = "trivia"    // 你問我答
+	GameModeUndercover = "undercover" // 誰是臥底
+)
+
+// 「誰是臥底」身份與階段
+const (
+	UndercoverRoleCivilian   = "civilian"   // 平民
+	UndercoverRoleUndercover = "undercover" // 臥底
+
+	UndercoverPhaseDescribe = "describe" // 描述階段
+	UndercoverPhaseVote     = "vote"     // 投票階段
+)
+
+// UndercoverState 「誰是臥底」遊戲狀態（包含秘密資訊，不可直接對外公開）
+type UndercoverState struct {
+	Category       string            `json:"category"`
+	CivilianWord   string            `json:"civilianWord"`
+	UndercoverWord string            `json:"undercoverWord"`
+	Roles          map[string]string `json:"roles"`      // 玩家ID → 身份
+	Eliminated     map[string]bool   `json:"eliminated"` // 已淘汰的玩家
+	Phase          string            `json:"phase"`
+	Round          int               `json:"round"`
+	Winner         string            `json:"winner,omitempty"` // 獲勝陣營
+}
+
+// IsAlive 玩家是否仍在場上
+func (u *UndercoverState) IsAlive(playerID string) bool {
+	_, hasRole := u.Roles[playerID]
+	return hasRole && !u.Eliminated[playerID]
+}
+
+// WordFor 獲取玩家拿到的詞語
+func (u *UndercoverState) WordFor(playerID string) string {
+	if u.Roles[playerID] == UndercoverRoleUndercover {
+		return u.UndercoverWord
+	}
+	return u.CivilianWord
+}
+
 // Question 題目結構 - 適用於「2種人」遊戲
+// OptionC 之後的欄位只有「你問我答」使用
 type Question struct {
 	ID            int      `json:"id" db:"id"`
 	QuestionText  string   `json:"questionText" db:"question_text"`
 	OptionA       string   `json:"optionA" db:"option_a"`
 	OptionB       string   `json:"optionB" db:"option_b"`
+	OptionC       string   `json:"optionC,omitempty" db:"option_c"`
+	OptionD       string   `json:"optionD,omitempty" db:"option_d"`
+	CorrectAnswer string   `json:"correctAnswer,omitempty" db:"correct_answer"`
+	Explanation   string   `json:"explanation,omitempty" db:"explanation"`
+	Difficulty    int      `json:"difficulty,omitempty" db:"difficulty"`
 	Category      string   `json:"category" db:"category"`
+	PackID        string   `json:"packId,omitempty" db:"pack_id"` // 所屬題目包（只有「2種人」題庫使用）
 	TimesUsed     int      `json:"timesUsed" db:"times_used"`
 	IsActive      bool     `json:"isActive" db:"is_active"`
 	CreatedAt     time.Time `json:"createdAt" db:"created_at"`
@@ -63,15 +145,18 @@ type Question struct {
 
 // GetOptions 獲取題目選項陣列
 func (q *Question) GetOptions() []string {
-	return []string{q.OptionA, q.OptionB}
+	if q.OptionC == "" && q.OptionD == "" {
+		return []string{q.OptionA, q.OptionB}
+	}
+	return []string{q.OptionA, q.OptionB, q.OptionC, q.OptionD}
 }
 
 // Answer 答案結構 - 適用於「2種人」遊戲
 type Answer struct {
 	PlayerID     string  `json:"playerId"`
 	QuestionID   int     `json:"questionId"`
-	Answer       string  `json:"answer"`        // A 或 B
-	IsCorrect    bool    `json:"isCorrect"`     // 猜測是否與主角一致
+	Answer       string  `json:"answer"`        // A 或 B（你問我答為 A-D）
+	IsCorrect    bool    `json:"isCorrect"`     // 猜測是否與主角一致（你問我答為是否答對）
 	ResponseTime float64 `json:"responseTime"`  // 答題時間（秒）
 	ScoreGained  int     `json:"scoreGained"`
 	WasHost      bool    `json:"wasHost"`       // 該題是否為主角
@@ -120,6 +205,33 @@ type ScoreInfo struct {
 	Score       int    `json:"score"`
 	Rank        int    `json:"rank"`
 	ScoreGained int    `json:"scoreGained"`
+	Team        string `json:"team,omitempty"`
+}
+
+// TeamScore 隊伍計分資訊
+type TeamScore struct {
+	Team        string `json:"team"`
+	Score       int    `json:"score"`
+	Rank        int    `json:"rank"`
+	ScoreGained int    `json:"scoreGained"`
+	Members     int    `json:"members"`
+}
+
+// TeamGameStats 隊伍遊戲統計
+type TeamGameStats struct {
+	Team           string   `json:"team"`
+	TotalScore     int      `json:"totalScore"`
+	Rank           int      `json:"rank"`
+	Members        []string `json:"members"`        // 隊員名稱
+	Guesses        int      `json:"guesses"`        // 隊員猜測次數
+	CorrectGuesses int      `json:"correctGuesses"` // 隊員猜對次數
+	GuessAccuracy  float64  `json:"guessAccuracy"`
+}
+
+// TeamRoster 隊伍名單
+type TeamRoster struct {
+	Team    string    `json:"team"`
+	Players []*Player `json:"players"`
 }
 
 // QuestionHistory 題目歷史記錄
@@ -142,6 +254,7 @@ type PlayerGameStats struct {
 	AsGuesser       int     `json:"asGuesser"`       // 當猜測者次數
 	CorrectGuesses  int     `json:"correctGuesses"`  // 猜對次數 (只計算猜測部分)
 	GuessAccuracy   float64 `json:"guessAccuracy"`   // 猜測正確率 (只計算猜測部分)
+	Team            string  `json:"team,omitempty"`  // 所屬隊伍（隊伍模式）
 }
 
 // QuestionResult 題目結果
@@ -151,7 +264,9 @@ type QuestionResult struct {
 	Explanation   string                 `json:"explanation"`
 	PlayerAnswers map[string]Answer      `json:"playerAnswers"`
 	Scores        []ScoreInfo            `json:"scores"`
+	TeamScores    []TeamScore            `json:"teamScores,omitempty"` // 隊伍模式的隊伍排名
 	NextHost      string                 `json:"nextHost"`
+	Details       map[string]interface{} `json:"details,omitempty"` // 遊戲模式專屬的結算資訊
 }
 
 // GameStatistics 遊戲統計
@@ -171,8 +286,41 @@ type GameStatistics struct {
 // CreateRoomRequest 創建房間請求
 type CreateRoomRequest struct {
 	HostName          string `json:"hostName" binding:"required,min=1,max=50"`
-	TotalQuestions    int    `json:"totalQuestions" binding:"min=1,max=50"`
-	QuestionTimeLimit int    `json:"questionTimeLimit" binding:"min=10,max=120"`
+	GameMode          string `json:"gameMode" binding:"max=30"`
+	TotalQuestions    int    `json:"totalQuestions" binding:"omitempty,min=1,max=50"`      // 省略時使用 DEFAULT_TOTAL_QUESTIONS
+	QuestionTimeLimit int    `json:"questionTimeLimit" binding:"omitempty,min=10,max=120"` // 省略時使用 QUESTION_TIME_LIMIT
+
+	// 隊伍名稱（可省略），設定兩隊以上即為隊伍模式
+	Teams []string `json:"teams,omitempty" binding:"omitempty,max=8,dive,max=30"`
+
+	// 房間密碼（可省略），設定後加入房間需要輸入密碼
+	Passcode string `json:"passcode,omitempty" binding:"max=32"`
+
+	// 公開房間，會出現在房間列表與快速配對中
+	Public bool `json:"public,omitempty"`
+
+	// 選題條件（可省略）
+	QuestionSelection
+}
+
+// SetPasscodeRequest 更換房間密碼請求，空字串代表取消密碼
+type SetPasscodeRequest struct {
+	Passcode string `json:"passcode" binding:"max=32"`
+}
+
+// QuestionSelection 房間選題條件，零值代表從整個題庫隨機選題
+type QuestionSelection struct {
+	Categories         []string       `json:"categories,omitempty" binding:"omitempty,dive,max=50"`         // 只使用這些分類
+	PackID             string         `json:"packId,omitempty" binding:"max=50"`                            // 只使用此題目包
+	CategoryWeights    map[string]int `json:"categoryWeights,omitempty" binding:"omitempty,dive,min=0,max=100"` // 各分類的抽題權重，未列出的分類權重為 1，0 代表不使用
+	ExcludeCategories  []string       `json:"excludeCategories,omitempty" binding:"omitempty,dive,max=50"`  // 排除這些分類
+	ExcludeQuestionIDs []int          `json:"excludeQuestionIds,omitempty"`                                 // 排除這些題目
+}
+
+// IsEmpty 是否沒有設定任何選題條件
+func (s *QuestionSelection) IsEmpty() bool {
+	return s == nil || (len(s.Categories) == 0 && s.PackID == "" && len(s.CategoryWeights) == 0 &&
+		len(s.ExcludeCategories) == 0 && len(s.ExcludeQuestionIDs) == 0)
 }
 
 // JoinRoomRequest 加入房間請求
@@ -185,7 +333,7 @@ type JoinRoomRequest struct {
 type SubmitAnswerRequest struct {
 	RoomID       string  `json:"roomId" binding:"required"`
 	QuestionID   int     `json:"questionId" binding:"required"`
-	Answer       string  `json:"answer" binding:"required,oneof=A B"`
+	Answer       string  `json:"answer" binding:"required,oneof=A B C D"`
 	TimeUsed     float64 `json:"timeUsed" binding:"min=0"`
 }
 
@@ -195,12 +343,68 @@ type CreateQuestionRequest struct {
 	OptionA       string `json:"optionA" binding:"required,min=1,max=200"`
 	OptionB       string `json:"optionB" binding:"required,min=1,max=200"`
 	Category      string `json:"category" binding:"max=50"`
+	Difficulty    int    `json:"difficulty" binding:"omitempty,min=1,max=5"`
+	PackID        string `json:"packId" binding:"max=50"`
+}
+
+// UpdateQuestionRequest 更新題目請求，未提供的欄位維持不變
+type UpdateQuestionRequest struct {
+	QuestionText *string `json:"questionText" binding:"omitempty,min=5,max=500"`
+	OptionA      *string `json:"optionA" binding:"omitempty,min=1,max=200"`
+	OptionB      *string `json:"optionB" binding:"omitempty,min=1,max=200"`
+	Category     *string `json:"category" binding:"omitempty,max=50"`
+	Difficulty   *int    `json:"difficulty" binding:"omitempty,min=1,max=5"`
+	PackID       *string `json:"packId" binding:"omitempty,max=50"`
+	IsActive     *bool   `json:"isActive"`
+}
+
+// QuestionFilter 題目查詢條件，零值代表不篩選
+type QuestionFilter struct {
+	Category        string
+	Difficulty      int
+	PackID          string
+	IncludeInactive bool // 包含已停用的題目
+	Limit           int  // 0 代表不限制
+}
+
+// QuestionPack 題目包（匯入 / 匯出檔案格式）
+type QuestionPack struct {
+	PackID    string             `json:"packId" yaml:"packId"`
+	Questions []QuestionPackItem `json:"questions" yaml:"questions"`
+}
+
+// QuestionPackItem 題目包中的一題
+type QuestionPackItem struct {
+	Row          int    `json:"-" yaml:"-"` // 在原始檔案中的列號，用於回報錯誤
+	QuestionText string `json:"questionText" yaml:"questionText"`
+	OptionA      string `json:"optionA" yaml:"optionA"`
+	OptionB      string `json:"optionB" yaml:"optionB"`
+	Category     string `json:"category,omitempty" yaml:"category,omitempty"`
+	Difficulty   int    `json:"difficulty,omitempty" yaml:"difficulty,omitempty"`
+}
+
+// QuestionImportResult 題目包匯入結果
+type QuestionImportResult struct {
+	PackID     string                `json:"packId"`
+	DryRun     bool                  `json:"dryRun"`
+	Total      int                   `json:"total"`
+	Imported   int                   `json:"imported"`
+	Duplicates int                   `json:"duplicates"`
+	Errors     []QuestionImportError `json:"errors"`
+}
+
+// QuestionImportError 匯入時單一列的錯誤
+type QuestionImportError struct {
+	Row     int    `json:"row"`
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
 }
 
 // RoomInfo 房間資訊（用於列表顯示）
 type RoomInfo struct {
 	ID               string     `json:"id"`
 	HostName         string     `json:"hostName"`
+	GameMode         string     `json:"gameMode"`
 	Status           RoomStatus `json:"status"`
 	PlayerCount      int        `json:"playerCount"`
 	MaxPlayers       int        `json:"maxPlayers"`
@@ -209,6 +413,112 @@ type RoomInfo struct {
 	CreatedAt        time.Time  `json:"createdAt"`
 }
 
+// Info 房間列表顯示用的摘要
+func (r *Room) Info(maxPlayers int) RoomInfo {
+	return RoomInfo{
+		ID:              r.ID,
+		HostName:        r.HostName,
+		GameMode:        r.GameMode,
+		Status:          r.Status,
+		PlayerCount:     r.GetPlayerCount(),
+		MaxPlayers:      maxPlayers,
+		CurrentQuestion: r.CurrentQuestion,
+		TotalQuestions:  r.TotalQuestions,
+		CreatedAt:       r.CreatedAt,
+	}
+}
+
+// RoomLog 房間事件記錄
+type RoomLog struct {
+	ID         int64           `json:"id" db:"id"`
+	RoomID     string          `json:"roomId" db:"room_id"`
+	EventType  string          `json:"eventType" db:"event_type"`
+	PlayerName string          `json:"playerName,omitempty" db:"player_name"`
+	EventData  json.RawMessage `json:"eventData,omitempty" db:"event_data"`
+	CreatedAt  time.Time       `json:"createdAt" db:"created_at"`
+}
+
+// 房間事件類型
+const (
+	RoomEventCreated         = "created"          // 房間建立
+	RoomEventPlayerJoined    = "player_joined"    // 玩家加入
+	RoomEventPlayerLeft      = "player_left"      // 玩家離開
+	RoomEventPlayerKicked    = "player_kicked"    // 玩家被主持人踢出
+	RoomEventGameStarted     = "game_started"     // 遊戲開始
+	RoomEventQuestionSent    = "question_sent"    // 發送題目
+	RoomEventAnswerSubmitted = "answer_submitted" // 玩家答題
+	RoomEventQuestionInvalid = "question_invalid" // 題目無效
+	RoomEventScoresUpdate    = "scores_update"    // 題目計分
+	RoomEventGameFinished    = "game_finished"    // 遊戲結束
+	RoomEventGamePaused      = "game_paused"      // 主持人暫停遊戲
+	RoomEventGameResumed     = "game_resumed"     // 主持人繼續遊戲
+	RoomEventQuestionSkipped = "question_skipped" // 主持人略過題目
+	RoomEventTimeExtended    = "time_extended"    // 主持人延長答題時間
+	RoomEventPasscodeChanged = "passcode_changed" // 主持人更換房間密碼
+	RoomEventRoomClosed      = "room_closed"      // 房間閒置過久被系統關閉
+)
+
+// IsInProgress 遊戲是否正在進行中
+func (r *Room) IsInProgress() bool {
+	switch r.Status {
+	case RoomStatusStarting, RoomStatusQuestionDisplay, RoomStatusAnswering, RoomStatusShowResult:
+		return true
+	default:
+		return false
+	}
+}
+
+// StartQuestionTimer 記錄當前題目開始作答的時間
+func (r *Room) StartQuestionTimer(now time.Time) {
+	r.QuestionStartedAt = &now
+}
+
+// SetPaused 更新暫停狀態；繼續時將題目開始時間順延，暫停期間不計入答題時間
+func (r *Room) SetPaused(paused bool, now time.Time) {
+	if paused {
+		if !r.IsPaused {
+			r.PausedAt = &now
+		}
+	} else if r.PausedAt != nil {
+		if r.QuestionStartedAt != nil {
+			startedAt := r.QuestionStartedAt.Add(now.Sub(*r.PausedAt))
+			r.QuestionStartedAt = &startedAt
+		}
+		r.PausedAt = nil
+	}
+	r.IsPaused = paused
+}
+
+// QuestionElapsed 當前題目已作答的秒數（由伺服器計算，不含暫停時間）
+func (r *Room) QuestionElapsed(now time.Time) float64 {
+	if r.QuestionStartedAt == nil {
+		return 0
+	}
+
+	elapsed := now.Sub(*r.QuestionStartedAt).Seconds()
+	if elapsed < 0 {
+		return 0
+	}
+	return elapsed
+}
+
+// LastActivityAt 房間最後一次有動靜的時間（建立、開始、結束或玩家活動）
+func (r *Room) LastActivityAt() time.Time {
+	last := r.CreatedAt
+	if r.StartedAt != nil && r.StartedAt.After(last) {
+		last = *r.StartedAt
+	}
+	if r.FinishedAt != nil && r.FinishedAt.After(last) {
+		last = *r.FinishedAt
+	}
+	for _, player := range r.Players {
+		if player.LastActivity.After(last) {
+			last = player.LastActivity
+		}
+	}
+	return last
+}
+
 // GetPlayerCount 獲取房間玩家數量
 func (r *Room) GetPlayerCount() int {
 	return len(r.Players)
@@ -242,6 +552,90 @@ func (r *Room) GetPlayerList() []*Player {
 	return players
 }
 
+// IsBanned 玩家ID或名稱（不分大小寫）是否已被禁止加入
+func (r *Room) IsBanned(playerID, playerName string) bool {
+	playerName = strings.TrimSpace(playerName)
+	for _, ban := range r.Bans {
+		if ban.PlayerID == playerID || strings.EqualFold(ban.PlayerName, playerName) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsTeamMode 是否為隊伍模式
+func (r *Room) IsTeamMode() bool {
+	return len(r.Teams) >= 2
+}
+
+// HasTeam 房間是否有此隊伍
+func (r *Room) HasTeam(team string) bool {
+	for _, name := range r.Teams {
+		if name == team {
+			return true
+		}
+	}
+	return false
+}
+
+// GetTeamRosters 依隊伍順序列出各隊隊員，隊員依玩家ID排序確保順序固定
+func (r *Room) GetTeamRosters() []TeamRoster {
+	rosters := make([]TeamRoster, 0, len(r.Teams))
+	for _, team := range r.Teams {
+		members := make([]*Player, 0)
+		for _, player := range r.Players {
+			if player.Team == team {
+				members = append(members, player)
+			}
+		}
+		sort.Slice(members, func(i, j int) bool {
+			return members[i].ID < members[j].ID
+		})
+		rosters = append(rosters, TeamRoster{Team: team, Players: members})
+	}
+	return rosters
+}
+
+// PublicView 獲取可對外公開的房間副本
+// 隱藏尚未公布的正確答案、「誰是臥底」的詞語和身份，以及房間密碼；
+// 公布結果前玩家答案只保留「誰已作答」
+func (r *Room) PublicView() *Room {
+	view := *r
+	view.PasscodeHash = ""
+	view.PasscodeAttempts = nil
+
+	if r.Status != RoomStatusFinished {
+		view.Questions = make([]Question, len(r.Questions))
+		for i, question := range r.Questions {
+			question.CorrectAnswer = ""
+			question.Explanation = ""
+			view.Questions[i] = question
+		}
+
+		if r.Undercover != nil {
+			undercover := *r.Undercover
+			undercover.CivilianWord = ""
+			undercover.UndercoverWord = ""
+			undercover.Roles = nil
+			view.Undercover = &undercover
+		}
+	}
+
+	if r.Status != RoomStatusShowResult && r.Status != RoomStatusFinished && r.Answers != nil {
+		view.Answers = make(map[string]*Answer, len(r.Answers))
+		for playerID, answer := range r.Answers {
+			view.Answers[playerID] = &Answer{
+				PlayerID:    answer.PlayerID,
+				QuestionID:  answer.QuestionID,
+				WasHost:     answer.WasHost,
+				SubmittedAt: answer.SubmittedAt,
+			}
+		}
+	}
+
+	return &view
+}
+
 // GetSortedPlayersByScore 按分數排序獲取玩家列表
 func (r *Room) GetSortedPlayersByScore() []ScoreInfo {
 	scores := make([]ScoreInfo, 0, len(r.Players))
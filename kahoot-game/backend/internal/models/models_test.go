@@ -0,0 +1,113 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRoomPublicViewAnswers(t *testing.T) {
+	tests := []struct {
+		status     RoomStatus
+		wantAnswer string
+	}{
+		{status: RoomStatusQuestionDisplay, wantAnswer: ""},
+		{status: RoomStatusAnswering, wantAnswer: ""},
+		{status: RoomStatusShowResult, wantAnswer: "A"},
+		{status: RoomStatusFinished, wantAnswer: "A"},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.status), func(t *testing.T) {
+			room := &Room{
+				Status: tt.status,
+				Answers: map[string]*Answer{
+					"p1": {PlayerID: "p1", QuestionID: 1, Answer: "A", IsCorrect: true, ScoreGained: 100, HostAnswer: "A", WasHost: true},
+				},
+			}
+
+			answer, answered := room.PublicView().Answers["p1"]
+			if !answered {
+				t.Fatal("公開資料缺少已作答的玩家")
+			}
+			if answer.Answer != tt.wantAnswer {
+				t.Errorf("Answer = %q, 預期 %q", answer.Answer, tt.wantAnswer)
+			}
+			if tt.wantAnswer == "" && (answer.IsCorrect || answer.ScoreGained != 0 || answer.HostAnswer != "") {
+				t.Errorf("公布結果前洩漏答案內容: %+v", answer)
+			}
+			if room.Answers["p1"].Answer != "A" {
+				t.Error("PublicView 修改了原本的房間")
+			}
+		})
+	}
+}
+
+func TestRoomQuestionElapsed(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	at := func(seconds int) time.Time {
+		return start.Add(time.Duration(seconds) * time.Second)
+	}
+
+	tests := []struct {
+		name   string
+		setup  func(room *Room)
+		now    time.Time
+		expect float64
+	}{
+		{
+			name:   "尚未開始作答",
+			setup:  func(room *Room) {},
+			now:    at(5),
+			expect: 0,
+		},
+		{
+			name:   "開始後經過的秒數",
+			setup:  func(room *Room) { room.StartQuestionTimer(start) },
+			now:    at(7),
+			expect: 7,
+		},
+		{
+			name: "暫停期間不計入",
+			setup: func(room *Room) {
+				room.StartQuestionTimer(start)
+				room.SetPaused(true, at(3))
+				room.SetPaused(false, at(13))
+			},
+			now:    at(15),
+			expect: 5,
+		},
+		{
+			name: "重複暫停以第一次為準",
+			setup: func(room *Room) {
+				room.StartQuestionTimer(start)
+				room.SetPaused(true, at(2))
+				room.SetPaused(true, at(4))
+				room.SetPaused(false, at(6))
+			},
+			now:    at(6),
+			expect: 2,
+		},
+		{
+			name: "時鐘倒退時為 0",
+			setup: func(room *Room) {
+				room.StartQuestionTimer(start)
+			},
+			now:    at(-1),
+			expect: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			room := &Room{}
+			tt.setup(room)
+
+			if got := room.QuestionElapsed(tt.now); got != tt.expect {
+				t.Errorf("QuestionElapsed = %v, 預期 %v", got, tt.expect)
+			}
+			if room.IsPaused || room.PausedAt != nil {
+				t.Errorf("繼續後仍為暫停狀態: %v %v", room.IsPaused, room.PausedAt)
+			}
+		})
+	}
+}
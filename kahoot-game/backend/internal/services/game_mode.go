@@ -0,0 +1,131 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"kahoot-game/internal/models"
+)
+
+var (
+	// ErrUnknownGameMode 不支援的遊戲模式
+	ErrUnknownGameMode = errors.New("不支援的遊戲模式")
+
+	// ErrSelectionUnsupported 遊戲模式不支援選題條件
+	ErrSelectionUnsupported = errors.New("此遊戲模式不支援選題條件")
+
+	// ErrTeamsUnsupported 遊戲模式不支援隊伍模式
+	ErrTeamsUnsupported = errors.New("此遊戲模式不支援隊伍模式")
+)
+
+// GameMode 遊戲模式介面
+// 每種玩法實作自己的開局、答案驗證、計分與換題規則，
+// websocket 層只負責驅動共用的房間狀態機（出題 → 作答 → 結算 → 下一題）
+type GameMode interface {
+	// ID 模式代碼，記錄在 models.Room.GameMode
+	ID() string
+
+	// Name 模式顯示名稱
+	Name() string
+
+	// MinPlayers 開始遊戲所需的最少玩家數
+	MinPlayers() int
+
+	// LoadQuestions 為房間準備題目
+	LoadQuestions(count int) ([]models.Question, error)
+
+	// StartGame 重置房間狀態並進入第一題
+	StartGame(room *models.Room) error
+
+	// QuestionPayload 當前題目要廣播給房間的內容（NEW_QUESTION）
+	QuestionPayload(room *models.Room) map[string]interface{}
+
+	// SubmitAnswer 驗證並建立玩家答案
+	SubmitAnswer(room *models.Room, playerID, answer string, timeUsed float64) (*models.Answer, error)
+
+	// AllAnswered 本題需要作答的玩家是否都已作答
+	AllAnswered(room *models.Room) bool
+
+	// ValidateRound 答題時間結束時檢查本題能否計分
+	ValidateRound(room *models.Room) *RoundError
+
+	// ScoreRound 計算本題分數並寫入題目歷史
+	ScoreRound(room *models.Room) *models.QuestionResult
+
+	// NextQuestion 進入下一題，遊戲結束時將房間狀態設為 finished
+	NextQuestion(room *models.Room)
+
+	// PlayerLeft 玩家已從房間移除、答案也已刪除後調整模式狀態（例如「2種人」重新選擇主角），
+	// 回傳主角是否因此更換；清除本題其他作答與略過本題由 Hub 依回傳值處理
+	PlayerLeft(room *models.Room, playerID string) bool
+
+	// FinalRanking 最終排名與統計
+	FinalRanking(room *models.Room) []models.PlayerGameStats
+}
+
+// PrivatePayloader 需要私下發送內容給個別玩家的遊戲模式（例如臥底詞）
+// 回傳值的 key 為玩家ID，內容只會透過該玩家自己的連線送出
+type PrivatePayloader interface {
+	PrivatePayloads(room *models.Room) map[string]map[string]interface{}
+}
+
+// QuestionSelector 支援依房間選題條件出題的遊戲模式
+// 不支援的模式建立房間時不能指定選題條件
+type QuestionSelector interface {
+	SelectQuestions(count int, selection *models.QuestionSelection) ([]models.Question, error)
+}
+
+// TeamRanker 支援隊伍模式的遊戲模式
+// 不支援的模式建立房間時不能設定隊伍
+type TeamRanker interface {
+	FinalTeamRanking(room *models.Room, playerStats []models.PlayerGameStats) []models.TeamGameStats
+}
+
+// RoundError 本題無法計分的原因
+type RoundError struct {
+	Reason  string // 給前端判斷用的代碼，例如 host_no_answer
+	Message string // 顯示給玩家的訊息
+}
+
+func (e *RoundError) Error() string {
+	return e.Message
+}
+
+// RegisterMode 註冊遊戲模式
+func (s *GameService) RegisterMode(mode GameMode) {
+	s.modes[mode.ID()] = mode
+}
+
+// GetMode 依代碼取得遊戲模式，空字串代表預設的「2種人」
+func (s *GameService) GetMode(modeID string) (GameMode, error) {
+	if modeID == "" {
+		modeID = models.GameModeTwoTypes
+	}
+
+	mode, exists := s.modes[modeID]
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownGameMode, modeID)
+	}
+
+	return mode, nil
+}
+
+// ModeForRoom 取得房間使用的遊戲模式
+func (s *GameService) ModeForRoom(room *models.Room) (GameMode, error) {
+	return s.GetMode(room.GameMode)
+}
+
+// ListModes 列出所有已註冊的遊戲模式
+func (s *GameService) ListModes() []GameMode {
+	modes := make([]GameMode, 0, len(s.modes))
+	for _, mode := range s.modes {
+		modes = append(modes, mode)
+	}
+
+	sort.Slice(modes, func(i, j int) bool {
+		return modes[i].ID() < modes[j].ID()
+	})
+
+	return modes
+}
@@ -1,12 +1,14 @@
 package services
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
-	"log"
+	"log/slog"
 	"math/rand"
 	"time"
 
+	"kahoot-game/internal/logging"
 	"kahoot-game/internal/models"
 
 	"github.com/go-redis/redis/v8"
@@ -14,16 +16,33 @@ import (
 
 // GameService 遊戲服務
 type GameService struct {
-	db          *sql.DB
-	redisClient *redis.Client
+	db              *sql.DB
+	redisClient     *redis.Client
+	questionService *QuestionService
+	modes           map[string]GameMode
+	logger          *slog.Logger
 }
 
-// NewGameService 創建遊戲服務
-func NewGameService(db *sql.DB, redisClient *redis.Client) *GameService {
-	return &GameService{
-		db:          db,
-		redisClient: redisClient,
+// NewGameService 創建遊戲服務，questionService 為 nil 時使用內建題庫
+func NewGameService(db *sql.DB, redisClient *redis.Client, questionService *QuestionService, logger *slog.Logger) *GameService {
+	if questionService == nil {
+		questionService = NewQuestionService(nil, logger)
 	}
+
+	s := &GameService{
+		db:              db,
+		redisClient:     redisClient,
+		questionService: questionService,
+		modes:           make(map[string]GameMode),
+		logger:          logger,
+	}
+
+	// 註冊內建遊戲模式
+	s.RegisterMode(NewTwoTypesMode(s))
+	s.RegisterMode(NewTriviaMode(s, db))
+	s.RegisterMode(NewUndercoverMode(s))
+
+	return s
 }
 
 // CreateGame 創建遊戲記錄
@@ -125,6 +144,139 @@ func (s *GameService) GetGameStats(gameID int) (*models.GameStatistics, error) {
 	return &stats, nil
 }
 
+// SaveFinishedGame 將結束的遊戲、玩家統計與答題記錄寫入資料庫（同一個交易），回傳遊戲記錄ID
+func (s *GameService) SaveFinishedGame(room *models.Room, finalStats []models.PlayerGameStats) (int, error) {
+	if s.db == nil {
+		return 0, fmt.Errorf("資料庫未連接")
+	}
+
+	finishedAt := time.Now()
+	if room.FinishedAt != nil {
+		finishedAt = *room.FinishedAt
+	}
+
+	startedAt := finishedAt
+	if room.StartedAt != nil {
+		startedAt = *room.StartedAt
+	}
+	durationSeconds := int(finishedAt.Sub(startedAt).Seconds())
+
+	var winnerName sql.NullString
+	var winnerScore sql.NullInt32
+	if len(finalStats) > 0 {
+		winnerName = sql.NullString{String: finalStats[0].PlayerName, Valid: true}
+		winnerScore = sql.NullInt32{Int32: int32(finalStats[0].TotalScore), Valid: true}
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("開始交易失敗: %w", err)
+	}
+	defer tx.Rollback()
+
+	var gameID int
+	err = tx.QueryRow(`
+		INSERT INTO games (room_id, host_name, total_players, total_questions, question_time_limit,
+						   winner_name, winner_score, duration_seconds, started_at, finished_at, status)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, 'finished')
+		RETURNING id
+	`, room.ID, room.HostName, len(finalStats), room.TotalQuestions, room.QuestionTimeLimit,
+		winnerName, winnerScore, durationSeconds, startedAt, finishedAt,
+	).Scan(&gameID)
+	if err != nil {
+		return 0, fmt.Errorf("寫入遊戲記錄失敗: %w", err)
+	}
+
+	// 玩家名稱（離開的玩家已不在房間內）
+	playerNames := make(map[string]string)
+	for playerID, player := range room.Players {
+		playerNames[playerID] = player.Name
+	}
+	for _, stats := range finalStats {
+		playerNames[stats.PlayerID] = stats.PlayerName
+	}
+
+	// 從題目歷史計算作答時間統計
+	responseTimes := make(map[string][]float64)
+	for _, history := range room.GameHistory {
+		for playerID, answer := range history.PlayerAnswers {
+			responseTimes[playerID] = append(responseTimes[playerID], answer.ResponseTime)
+		}
+	}
+
+	for _, stats := range finalStats {
+		times := responseTimes[stats.PlayerID]
+
+		var avgResponseTime float64
+		var fastest, slowest sql.NullFloat64
+		for i, t := range times {
+			avgResponseTime += t
+			if i == 0 || t < fastest.Float64 {
+				fastest = sql.NullFloat64{Float64: t, Valid: true}
+			}
+			if i == 0 || t > slowest.Float64 {
+				slowest = sql.NullFloat64{Float64: t, Valid: true}
+			}
+		}
+		if len(times) > 0 {
+			avgResponseTime /= float64(len(times))
+		}
+
+		_, err = tx.Exec(`
+			INSERT INTO player_stats (game_id, player_name, final_score, final_rank, correct_answers, total_answers,
+									  accuracy_percentage, avg_response_time, times_as_host,
+									  fastest_answer_time, slowest_answer_time)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		`, gameID, stats.PlayerName, stats.TotalScore, stats.Rank, stats.CorrectGuesses, len(times),
+			stats.GuessAccuracy, avgResponseTime, stats.AsHost, fastest, slowest,
+		)
+		if err != nil {
+			return 0, fmt.Errorf("寫入玩家統計失敗: %w", err)
+		}
+	}
+
+	for _, history := range room.GameHistory {
+		// 只有「你問我答」的題目來自 questions 表，其他模式的題目不建立關聯
+		var questionID sql.NullInt64
+		if room.GameMode == models.GameModeTrivia {
+			questionID = sql.NullInt64{Int64: int64(history.QuestionID), Valid: true}
+		}
+
+		for playerID, answer := range history.PlayerAnswers {
+			// submitted_answer 只能存選項代號（「誰是臥底」的描述與投票不記錄內容）
+			var submittedAnswer sql.NullString
+			switch answer.Answer {
+			case "A", "B", "C", "D":
+				submittedAnswer = sql.NullString{String: answer.Answer, Valid: true}
+			}
+
+			playerName, exists := playerNames[playerID]
+			if !exists {
+				playerName = "未知玩家"
+			}
+
+			_, err = tx.Exec(`
+				INSERT INTO answer_logs (game_id, question_id, player_name, submitted_answer, is_correct,
+										 response_time, score_gained, was_host, submitted_at)
+				VALUES ($1, (SELECT id FROM questions WHERE id = $2), $3, $4, $5, $6, $7, $8, $9)
+			`, gameID, questionID, playerName, submittedAnswer, answer.IsCorrect,
+				answer.ResponseTime, answer.ScoreGained, answer.WasHost, answer.SubmittedAt,
+			)
+			if err != nil {
+				return 0, fmt.Errorf("寫入答題記錄失敗: %w", err)
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("提交交易失敗: %w", err)
+	}
+
+	s.logger.Info("遊戲記錄已保存",
+		logging.KeyRoomID, room.ID, "gameId", gameID, "players", len(finalStats), "questions", len(room.GameHistory))
+	return gameID, nil
+}
+
 // StartTwoTypesGame 開始「2種人」遊戲
 func (s *GameService) StartTwoTypesGame(room *models.Room) error {
 	if len(room.Players) < 2 {
@@ -132,7 +284,11 @@ func (s *GameService) StartTwoTypesGame(room *models.Room) error {
 	}
 	
 	// 每次開始遊戲都重新載入題目，確保遊戲能正常進行
-	room.Questions = GetRandomQuestions(room.TotalQuestions)
+	questions, err := s.LoadTwoTypesQuestions(room.TotalQuestions, room.QuestionSelection)
+	if err != nil {
+		return err
+	}
+	room.Questions = questions
 	if len(room.Questions) == 0 {
 		return fmt.Errorf("無法載入遊戲題目")
 	}
@@ -146,6 +302,13 @@ func (s *GameService) StartTwoTypesGame(room *models.Room) error {
 		player.Score = 0
 	}
 	
+	// 隊伍模式：重置隊伍分數，尚未分隊的玩家自動分隊
+	if room.IsTeamMode() {
+		assignUnassignedPlayers(room)
+		room.TeamScores = make(map[string]int)
+		room.TeamHostTurns = make(map[string]int)
+	}
+	
 	// 設定第一題的主角
 	room.CurrentHost = s.SelectNextHost(room, "")
 	room.NextHostOverride = ""
@@ -154,6 +317,14 @@ func (s *GameService) StartTwoTypesGame(room *models.Room) error {
 	return nil
 }
 
+// LoadTwoTypesQuestions 從「2種人」題庫選題，沒有選題條件時從整個題庫隨機選題
+func (s *GameService) LoadTwoTypesQuestions(count int, selection *models.QuestionSelection) ([]models.Question, error) {
+	if selection.IsEmpty() {
+		return s.questionService.GetRandomQuestions(count)
+	}
+	return s.questionService.SelectQuestions(count, selection)
+}
+
 // SelectNextHost 選擇下一個主角（輪流）
 func (s *GameService) SelectNextHost(room *models.Room, currentHost string) string {
 	players := room.GetPlayerList()
@@ -161,6 +332,11 @@ func (s *GameService) SelectNextHost(room *models.Room, currentHost string) stri
 		return ""
 	}
 	
+	// 隊伍模式：主角在各隊間輪流
+	if room.IsTeamMode() {
+		return s.selectNextTeamHost(room, currentHost)
+	}
+	
 	// 如果是第一題，隨機選擇
 	if currentHost == "" {
 		rand.Seed(time.Now().UnixNano())
@@ -215,22 +391,19 @@ func (s *GameService) SubmitTwoTypesAnswer(room *models.Room, playerID, answer s
 
 // CalculateTwoTypesScores 計算「2種人」遊戲分數
 func (s *GameService) CalculateTwoTypesScores(room *models.Room, answers map[string]*models.Answer) []models.ScoreInfo {
-	log.Printf("🔢 === 開始計算第 %d 題分數 ===", room.CurrentQuestion)
-	log.Printf("🎯 當前主角: %s", room.CurrentHost)
-	log.Printf("📊 收到答案數量: %d", len(answers))
+	logger := s.roundLogger(room)
 	
 	// 找到主角的答案
 	var hostAnswer string
 	for playerID, answer := range answers {
 		if playerID == room.CurrentHost {
 			hostAnswer = answer.Answer
-			log.Printf("👑 主角答案: %s (玩家ID: %s)", hostAnswer, playerID)
 			break
 		}
 	}
 	
 	if hostAnswer == "" {
-		log.Printf("⚠️ 警告: 沒有找到主角答案!")
+		logger.Warn("沒有找到主角答案", "currentHost", room.CurrentHost)
 	}
 	
 	scores := make([]models.ScoreInfo, 0, len(room.Players))
@@ -239,18 +412,10 @@ func (s *GameService) CalculateTwoTypesScores(room *models.Room, answers map[str
 		answer, hasAnswered := answers[playerID]
 		scoreGained := 0
 		
-		log.Printf("👤 計算玩家分數: %s (ID: %s)", player.Name, playerID)
-		log.Printf("   ├─ 是否答題: %t", hasAnswered)
-		if hasAnswered {
-			log.Printf("   ├─ 玩家答案: %s", answer.Answer)
-			log.Printf("   ├─ 答題時間: %.2f秒", answer.ResponseTime)
-		}
-		
 		if hasAnswered {
 			if playerID == room.CurrentHost {
 				// 主角得分邏輯：有答題就得基礎分
 				scoreGained = 50
-				log.Printf("   ├─ 主角基礎分: %d", scoreGained)
 			} else if answer.Answer == hostAnswer {
 				// 其他玩家：猜對主角答案得分，越快越高分
 				baseScore := 100
@@ -265,25 +430,26 @@ func (s *GameService) CalculateTwoTypesScores(room *models.Room, answers map[str
 				}
 				
 				scoreGained = baseScore + timeBonus
-				log.Printf("   ├─ 猜對主角! 基礎分: %d, 速度獎勵: %d, 總得分: %d", baseScore, timeBonus, scoreGained)
-			} else {
-				log.Printf("   ├─ 猜錯主角 (答案: %s, 主角答案: %s), 得分: 0", answer.Answer, hostAnswer)
 			}
 			// 如果猜錯主角答案，得0分
-		} else {
-			log.Printf("   ├─ 未答題，得分: 0")
 		}
 		
 		// 更新玩家總分
-		oldScore := player.Score
 		player.Score += scoreGained
-		log.Printf("   └─ 分數更新: %d + %d = %d", oldScore, scoreGained, player.Score)
+		if logger.Enabled(context.Background(), slog.LevelDebug) {
+			attrs := []any{logging.KeyPlayerID, playerID, "answered", hasAnswered, "scoreGained", scoreGained, "score", player.Score}
+			if hasAnswered {
+				attrs = append(attrs, "answer", answer.Answer, "responseTime", answer.ResponseTime)
+			}
+			logger.Debug("玩家本題得分", attrs...)
+		}
 		
 		scores = append(scores, models.ScoreInfo{
 			PlayerID:    playerID,
 			PlayerName:  player.Name,
 			Score:       player.Score,
 			ScoreGained: scoreGained,
+			Team:        player.Team,
 		})
 		
 		// 更新答案記錄
@@ -295,11 +461,6 @@ func (s *GameService) CalculateTwoTypesScores(room *models.Room, answers map[str
 		}
 	}
 	
-	log.Printf("📊 排序前的分數:")
-	for i, score := range scores {
-		log.Printf("   %d. %s: %d分 (本題+%d)", i+1, score.PlayerName, score.Score, score.ScoreGained)
-	}
-	
 	// 按總分排序
 	for i := 0; i < len(scores); i++ {
 		for j := i + 1; j < len(scores); j++ {
@@ -314,15 +475,16 @@ func (s *GameService) CalculateTwoTypesScores(room *models.Room, answers map[str
 		scores[i].Rank = i + 1
 	}
 	
-	log.Printf("🏆 排序後的排名:")
-	for _, score := range scores {
-		log.Printf("   第%d名: %s - %d分 (本題+%d)", score.Rank, score.PlayerName, score.Score, score.ScoreGained)
-	}
-	log.Printf("🔢 === 第 %d 題分數計算完成 ===", room.CurrentQuestion)
+	logger.Info("本題計分完成", "answers", len(answers), "players", len(scores))
 	
 	return scores
 }
 
+// roundLogger 帶有房間與當前題號欄位的日誌記錄器
+func (s *GameService) roundLogger(room *models.Room) *slog.Logger {
+	return s.logger.With(logging.KeyRoomID, room.ID, logging.KeyQuestionNum, room.CurrentQuestion)
+}
+
 // NextTwoTypesQuestion 進入下一題
 func (s *GameService) NextTwoTypesQuestion(room *models.Room) {
 	// 選擇下一個主角
@@ -344,27 +506,55 @@ func (s *GameService) NextTwoTypesQuestion(room *models.Room) {
 	}
 }
 
+// RecordQuestionHistory 記錄題目歷史
+func (s *GameService) RecordQuestionHistory(room *models.Room, hostAnswer string) {
+	if len(room.Answers) == 0 {
+		s.roundLogger(room).Debug("沒有答案記錄，跳過歷史記錄")
+		return
+	}
+
+	questionID := 0
+	if room.CurrentQuestion >= 1 && room.CurrentQuestion <= len(room.Questions) {
+		questionID = room.Questions[room.CurrentQuestion-1].ID
+	}
+
+	// 創建題目歷史記錄
+	history := models.QuestionHistory{
+		QuestionID:    questionID,
+		QuestionNum:   room.CurrentQuestion,
+		HostPlayerID:  room.CurrentHost,
+		HostAnswer:    hostAnswer,
+		PlayerAnswers: make(map[string]*models.Answer),
+	}
+
+	// 複製所有玩家答案
+	for playerID, answer := range room.Answers {
+		answerCopy := *answer
+		answerCopy.HostAnswer = hostAnswer
+		history.PlayerAnswers[playerID] = &answerCopy
+	}
+
+	// 添加到房間歷史
+	room.GameHistory = append(room.GameHistory, history)
+
+	s.roundLogger(room).Debug("記錄題目歷史",
+		"currentHost", room.CurrentHost, "hostAnswer", hostAnswer, "answers", len(history.PlayerAnswers))
+}
+
 // GetFinalRanking 獲取最終排名
 func (s *GameService) GetFinalRanking(room *models.Room) []models.PlayerGameStats {
-	log.Printf("🏁 === 遊戲結束 - 最終結算 ===")
-	log.Printf("🎮 房間ID: %s", room.ID)
-	log.Printf("📝 總題數: %d", room.TotalQuestions)
-	log.Printf("👥 參與玩家數: %d", len(room.Players))
-	log.Printf("📚 遊戲歷史記錄數: %d", len(room.GameHistory))
-	
 	// 計算每個玩家的詳細統計
 	playerStats := s.calculatePlayerGameStats(room)
 	
-	log.Printf("🏆 最終排名與統計:")
+	logger := s.logger.With(logging.KeyRoomID, room.ID)
 	for i, stats := range playerStats {
-		log.Printf("   第%d名: %s - %d分", i+1, stats.PlayerName, stats.TotalScore)
-		log.Printf("      ├─ 當主角: %d次", stats.AsHost)
-		log.Printf("      ├─ 當猜測者: %d次", stats.AsGuesser)
-		log.Printf("      ├─ 猜對次數: %d次", stats.CorrectGuesses)
-		log.Printf("      └─ 猜測正確率: %.1f%%", stats.GuessAccuracy)
+		logger.Debug("最終排名",
+			"rank", i+1, logging.KeyPlayerID, stats.PlayerID, "totalScore", stats.TotalScore,
+			"asHost", stats.AsHost, "asGuesser", stats.AsGuesser,
+			"correctGuesses", stats.CorrectGuesses, "guessAccuracy", stats.GuessAccuracy)
 	}
-	
-	log.Printf("🏁 === 最終結算完成 ===")
+	logger.Info("最終結算完成",
+		"totalQuestions", room.TotalQuestions, "players", len(room.Players), "history", len(room.GameHistory))
 	return playerStats
 }
 
@@ -383,28 +573,22 @@ func (s *GameService) calculatePlayerGameStats(room *models.Room) []models.Playe
 			AsGuesser:      0,
 			CorrectGuesses: 0,
 			GuessAccuracy:  0.0,
+			Team:           player.Team,
 		}
 	}
 	
 	// 分析每題的歷史記錄
 	for _, history := range room.GameHistory {
-		log.Printf("📊 分析第%d題統計:", history.QuestionNum)
-		log.Printf("   主角: %s, 主角答案: %s", history.HostPlayerID, history.HostAnswer)
-		
 		for playerID, answer := range history.PlayerAnswers {
 			if stats, exists := statsMap[playerID]; exists {
 				if answer.WasHost {
 					// 當主角
 					stats.AsHost++
-					log.Printf("   玩家 %s: 當主角", stats.PlayerName)
 				} else {
 					// 當猜測者
 					stats.AsGuesser++
 					if answer.IsCorrect {
 						stats.CorrectGuesses++
-						log.Printf("   玩家 %s: 猜對 (答案:%s)", stats.PlayerName, answer.Answer)
-					} else {
-						log.Printf("   玩家 %s: 猜錯 (答案:%s, 主角答案:%s)", stats.PlayerName, answer.Answer, history.HostAnswer)
 					}
 				}
 			}
@@ -421,13 +605,6 @@ func (s *GameService) calculatePlayerGameStats(room *models.Room) []models.Playe
 			stats.GuessAccuracy = 0.0
 		}
 		
-		log.Printf("🔢 玩家 %s 最終統計:", stats.PlayerName)
-		log.Printf("   ├─ 總分: %d", stats.TotalScore)
-		log.Printf("   ├─ 當主角: %d/%d", stats.AsHost, stats.TotalQuestions)
-		log.Printf("   ├─ 當猜測者: %d/%d", stats.AsGuesser, stats.TotalQuestions)
-		log.Printf("   ├─ 猜對次數: %d/%d", stats.CorrectGuesses, stats.AsGuesser)
-		log.Printf("   └─ 猜測正確率: %.1f%%", stats.GuessAccuracy)
-		
 		result = append(result, *stats)
 	}
 	
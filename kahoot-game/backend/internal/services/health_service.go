@@ -0,0 +1,153 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"kahoot-game/internal/database"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// 依賴服務檢查的等待上限，避免健康檢查本身卡住
+const healthCheckTimeout = 2 * time.Second
+
+// 服務整體狀態
+const (
+	HealthStatusOK          = "ok"          // 所有依賴服務正常
+	HealthStatusDegraded    = "degraded"    // 可以提供服務，但部分功能無法使用（例如遊戲記錄無法保存）
+	HealthStatusUnavailable = "unavailable" // 房間資料無法存取，不應再接收流量
+)
+
+// 房間與遊戲記錄的存放方式
+const (
+	StorageModeRedis    = "redis"
+	StorageModePostgres = "postgres"
+	StorageModeMemory   = "memory"
+)
+
+// 依賴服務狀態
+const (
+	DependencyUp       = "up"
+	DependencyDown     = "down"
+	DependencyDisabled = "disabled" // 未使用（記憶體模式）
+)
+
+// DependencyHealth 單一依賴服務的檢查結果
+type DependencyHealth struct {
+	Status    string  `json:"status"`
+	Required  bool    `json:"required"`
+	LatencyMs float64 `json:"latencyMs,omitempty"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// HealthReport 服務就緒狀態
+type HealthReport struct {
+	Status        string                      `json:"status"`
+	Ready         bool                        `json:"ready"`
+	Degraded      bool                        `json:"degraded"`
+	RoomStorage   string                      `json:"roomStorage"`
+	GameStorage   string                      `json:"gameStorage"`
+	Dependencies  map[string]DependencyHealth `json:"dependencies"`
+	Version       string                      `json:"version"`
+	UptimeSeconds float64                     `json:"uptimeSeconds"`
+	Timestamp     time.Time                   `json:"timestamp"`
+}
+
+// HealthService 檢查 Redis 與 PostgreSQL 狀態，判斷服務是否就緒
+type HealthService struct {
+	db          *sql.DB
+	redisClient *redis.Client
+	roomStore   RoomStore
+	version     string
+	startedAt   time.Time
+}
+
+// NewHealthService 創建健康檢查服務
+// db 為 nil 代表遊戲記錄使用記憶體模式；redisClient 為 nil 代表未使用 Redis；roomStore 為房間資料的存放位置
+func NewHealthService(db *sql.DB, redisClient *redis.Client, roomStore RoomStore, version string) *HealthService {
+	return &HealthService{
+		db:          db,
+		redisClient: redisClient,
+		roomStore:   roomStore,
+		version:     version,
+		startedAt:   time.Now(),
+	}
+}
+
+// Version 建置版本
+func (s *HealthService) Version() string {
+	return s.version
+}
+
+// Uptime 服務已運行的時間
+func (s *HealthService) Uptime() time.Duration {
+	return time.Since(s.startedAt)
+}
+
+// Check 檢查所有依賴服務
+// 房間資料存放在 Redis 時，Redis 無法連線代表服務未就緒；已改用記憶體存放時 Redis 無法連線只是降級。
+// PostgreSQL 只影響遊戲記錄與題庫，無法連線時為降級模式
+func (s *HealthService) Check(ctx context.Context) *HealthReport {
+	ctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+	defer cancel()
+
+	connections := database.TestConnections(ctx, s.db, s.redisClient)
+	roomStorage := s.roomStore.Name()
+
+	report := &HealthReport{
+		Status:      HealthStatusOK,
+		Ready:       true,
+		RoomStorage: roomStorage,
+		GameStorage: StorageModePostgres,
+		Dependencies: map[string]DependencyHealth{
+			"redis":    dependencyHealth(connections.Redis, roomStorage == StorageModeRedis),
+			"postgres": dependencyHealth(connections.Postgres, false),
+		},
+		Version:       s.version,
+		UptimeSeconds: s.Uptime().Seconds(),
+		Timestamp:     time.Now().UTC(),
+	}
+
+	if !connections.Postgres.Configured {
+		report.GameStorage = StorageModeMemory
+	}
+
+	for _, dep := range report.Dependencies {
+		if dep.Status == DependencyUp {
+			continue
+		}
+		if dep.Required && dep.Status == DependencyDown {
+			report.Ready = false
+		}
+		report.Degraded = true
+	}
+
+	switch {
+	case !report.Ready:
+		report.Status = HealthStatusUnavailable
+	case report.Degraded:
+		report.Status = HealthStatusDegraded
+	}
+
+	return report
+}
+
+// dependencyHealth 將連線檢查結果轉為回應格式
+func dependencyHealth(status database.ConnectionStatus, required bool) DependencyHealth {
+	if !status.Configured {
+		return DependencyHealth{Status: DependencyDisabled}
+	}
+
+	health := DependencyHealth{
+		Status:    DependencyUp,
+		Required:  required,
+		LatencyMs: float64(status.Latency.Microseconds()) / 1000,
+	}
+	if status.Err != nil {
+		health.Status = DependencyDown
+		health.Error = status.Err.Error()
+	}
+	return health
+}
@@ -0,0 +1,87 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// 主持人憑證的角色與簽發者
+const (
+	hostTokenRole   = "host"
+	hostTokenIssuer = "kahoot-game"
+)
+
+// ErrInvalidHostToken 主持人憑證無效、過期或不屬於此房間
+var ErrInvalidHostToken = errors.New("主持人憑證無效")
+
+// HostClaims 主持人憑證內容，Subject 為房間ID
+type HostClaims struct {
+	HostName string `json:"hostName"`
+	Role     string `json:"role"`
+	jwt.RegisteredClaims
+}
+
+// HostTokenService 簽發與驗證主持人憑證（HS256 JWT）
+// 建立房間時簽發，主持人加入房間、控制遊戲與刪除房間時都需要
+type HostTokenService struct {
+	secret []byte
+	ttl    time.Duration
+}
+
+// NewHostTokenService 創建主持人憑證服務
+func NewHostTokenService(secret string, ttl time.Duration) *HostTokenService {
+	return &HostTokenService{
+		secret: []byte(secret),
+		ttl:    ttl,
+	}
+}
+
+// Issue 為房間簽發主持人憑證
+func (s *HostTokenService) Issue(roomID, hostName string) (string, error) {
+	now := time.Now()
+	claims := HostClaims{
+		HostName: hostName,
+		Role:     hostTokenRole,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    hostTokenIssuer,
+			Subject:   roomID,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(s.ttl)),
+		},
+	}
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(s.secret)
+	if err != nil {
+		return "", fmt.Errorf("簽發主持人憑證失敗: %w", err)
+	}
+	return token, nil
+}
+
+// Verify 驗證主持人憑證是否有效且屬於指定房間
+func (s *HostTokenService) Verify(tokenString, roomID string) (*HostClaims, error) {
+	if tokenString == "" {
+		return nil, fmt.Errorf("%w: 缺少主持人憑證", ErrInvalidHostToken)
+	}
+
+	claims := &HostClaims{}
+	_, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		return s.secret, nil
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithIssuer(hostTokenIssuer),
+		jwt.WithSubject(roomID),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidHostToken, err)
+	}
+
+	if claims.Role != hostTokenRole {
+		return nil, fmt.Errorf("%w: 角色錯誤", ErrInvalidHostToken)
+	}
+
+	return claims, nil
+}
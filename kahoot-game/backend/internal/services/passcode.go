@@ -0,0 +1,161 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+	"unicode/utf8"
+
+	"kahoot-game/internal/models"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	// 房間密碼長度限制（bcrypt 只使用前 72 個位元組）
+	minPasscodeLength = 4
+	maxPasscodeLength = 32
+
+	// 同一來源連續輸入錯誤達上限後暫停加入的時間；超過此時間沒有再輸入錯誤則重新計算
+	maxPasscodeFailures = 5
+	passcodeLockout     = time.Minute
+)
+
+var (
+	// ErrInvalidPasscode 房間密碼格式不合理
+	ErrInvalidPasscode = errors.New("房間密碼格式錯誤")
+
+	// ErrWrongPasscode 房間密碼錯誤
+	ErrWrongPasscode = errors.New("房間密碼錯誤")
+
+	// ErrPasscodeLocked 密碼錯誤次數過多，暫時無法加入
+	ErrPasscodeLocked = errors.New("密碼錯誤次數過多，請稍後再試")
+)
+
+// hashPasscode 檢查並雜湊房間密碼，空字串代表不設密碼
+func hashPasscode(passcode string) (string, error) {
+	if passcode == "" {
+		return "", nil
+	}
+
+	length := utf8.RuneCountInString(passcode)
+	if length < minPasscodeLength || length > maxPasscodeLength {
+		return "", fmt.Errorf("%w: 密碼長度必須在 %d 到 %d 個字之間", ErrInvalidPasscode, minPasscodeLength, maxPasscodeLength)
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(passcode), bcrypt.DefaultCost)
+	if err != nil {
+		return "", fmt.Errorf("雜湊房間密碼失敗: %w", err)
+	}
+	return string(hash), nil
+}
+
+// CheckPasscode 檢查加入房間的密碼，沒有設定密碼的房間直接通過
+// 錯誤次數依 requester（連線來源 IP）分開計算：同一來源連續錯誤 maxPasscodeFailures 次後，
+// 在 passcodeLockout 內拒絕該來源的密碼嘗試，其他來源不受影響
+func (s *RoomService) CheckPasscode(roomID, passcode, requester string) error {
+	room, err := s.GetRoom(roomID)
+	if err != nil {
+		return err
+	}
+	if !room.HasPasscode {
+		return nil
+	}
+	if passcodeLocked(room, requester, time.Now()) {
+		return ErrPasscodeLocked
+	}
+
+	// bcrypt 比對較慢，在房間原子更新之外進行
+	matched := passcode != "" && bcrypt.CompareHashAndPassword([]byte(room.PasscodeHash), []byte(passcode)) == nil
+	hash := room.PasscodeHash
+
+	var result error
+	_, err = s.MutateRoom(roomID, func(room *models.Room) error {
+		result = nil
+
+		// 比對期間密碼被主持人更換，視為密碼錯誤但不計入次數
+		if room.PasscodeHash != hash {
+			result = ErrWrongPasscode
+			return nil
+		}
+		now := time.Now()
+		prunePasscodeAttempts(room, now)
+		if passcodeLocked(room, requester, now) {
+			result = ErrPasscodeLocked
+			return nil
+		}
+
+		if matched {
+			delete(room.PasscodeAttempts, requester)
+			return nil
+		}
+
+		if room.PasscodeAttempts == nil {
+			room.PasscodeAttempts = make(map[string]*models.PasscodeAttempt)
+		}
+		attempt, exists := room.PasscodeAttempts[requester]
+		if !exists {
+			attempt = &models.PasscodeAttempt{}
+			room.PasscodeAttempts[requester] = attempt
+		}
+
+		attempt.Failures++
+		attempt.LastFailureAt = now
+		result = ErrWrongPasscode
+		if attempt.Failures >= maxPasscodeFailures {
+			lockedUntil := now.Add(passcodeLockout)
+			attempt.Failures = 0
+			attempt.LockedUntil = &lockedUntil
+			result = ErrPasscodeLocked
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return result
+}
+
+// passcodeLocked 此來源是否正暫停加入
+func passcodeLocked(room *models.Room, requester string, now time.Time) bool {
+	attempt, exists := room.PasscodeAttempts[requester]
+	return exists && attempt.LockedUntil != nil && now.Before(*attempt.LockedUntil)
+}
+
+// prunePasscodeAttempts 移除已解除暫停且超過 passcodeLockout 沒有再輸入錯誤的記錄，避免房間資料無限增長
+func prunePasscodeAttempts(room *models.Room, now time.Time) {
+	for requester, attempt := range room.PasscodeAttempts {
+		if attempt.LockedUntil != nil && now.Before(*attempt.LockedUntil) {
+			continue
+		}
+		if now.Sub(attempt.LastFailureAt) > passcodeLockout {
+			delete(room.PasscodeAttempts, requester)
+		}
+	}
+}
+
+// SetPasscode 主持人更換房間密碼，空字串代表取消密碼
+// 更換後清除錯誤次數與暫停加入的狀態，已在房間中的玩家不受影響
+func (s *RoomService) SetPasscode(roomID, passcode string) (*models.Room, error) {
+	hash, err := hashPasscode(passcode)
+	if err != nil {
+		return nil, err
+	}
+
+	room, err := s.MutateRoom(roomID, func(room *models.Room) error {
+		room.PasscodeHash = hash
+		room.HasPasscode = hash != ""
+		room.PasscodeAttempts = nil
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.roomLogs.Record(roomID, models.RoomEventPasscodeChanged, room.HostName, map[string]interface{}{
+		"hasPasscode": room.HasPasscode,
+	})
+
+	return room, nil
+}
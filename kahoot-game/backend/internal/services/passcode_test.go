@@ -0,0 +1,167 @@
+package services
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"kahoot-game/internal/config"
+	"kahoot-game/internal/models"
+)
+
+// newTestRoomService 使用記憶體房間存放與內建題庫的房間服務
+func newTestRoomService(t *testing.T, store RoomStore) *RoomService {
+	t.Helper()
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	if store == nil {
+		store = NewMemoryRoomStore()
+	}
+	gameService := NewGameService(nil, nil, nil, logger)
+	return NewRoomService(store, gameService, NewRoomLogService(nil, logger), config.GameConfig{}, logger)
+}
+
+func TestCheckPasscode(t *testing.T) {
+	const (
+		passcode = "1234"
+		attacker = "203.0.113.1"
+		player   = "198.51.100.2"
+	)
+
+	type attempt struct {
+		requester string
+		passcode  string
+		want      error
+	}
+	wrong := func(requester string, want error) attempt {
+		return attempt{requester: requester, passcode: "0000", want: want}
+	}
+	right := func(requester string, want error) attempt {
+		return attempt{requester: requester, passcode: passcode, want: want}
+	}
+
+	tests := []struct {
+		name     string
+		attempts []attempt
+	}{
+		{
+			name: "連續錯誤達上限後暫停該來源",
+			attempts: []attempt{
+				wrong(attacker, ErrWrongPasscode),
+				wrong(attacker, ErrWrongPasscode),
+				wrong(attacker, ErrWrongPasscode),
+				wrong(attacker, ErrWrongPasscode),
+				wrong(attacker, ErrPasscodeLocked),
+				right(attacker, ErrPasscodeLocked),
+			},
+		},
+		{
+			name: "其他來源不受暫停影響",
+			attempts: []attempt{
+				wrong(attacker, ErrWrongPasscode),
+				wrong(attacker, ErrWrongPasscode),
+				wrong(attacker, ErrWrongPasscode),
+				wrong(attacker, ErrWrongPasscode),
+				wrong(attacker, ErrPasscodeLocked),
+				right(player, nil),
+				wrong(player, ErrWrongPasscode),
+			},
+		},
+		{
+			name: "輸入正確後重新計算錯誤次數",
+			attempts: []attempt{
+				wrong(player, ErrWrongPasscode),
+				wrong(player, ErrWrongPasscode),
+				wrong(player, ErrWrongPasscode),
+				wrong(player, ErrWrongPasscode),
+				right(player, nil),
+				wrong(player, ErrWrongPasscode),
+				wrong(player, ErrWrongPasscode),
+				wrong(player, ErrWrongPasscode),
+				wrong(player, ErrWrongPasscode),
+				right(player, nil),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestRoomService(t, nil)
+			room, err := s.CreateRoom("主持人", "", 0, 0, nil, nil, passcode, false)
+			if err != nil {
+				t.Fatalf("建立房間失敗: %v", err)
+			}
+
+			for i, a := range tt.attempts {
+				if err := s.CheckPasscode(room.ID, a.passcode, a.requester); !errors.Is(err, a.want) {
+					t.Fatalf("第 %d 次嘗試 (%s) = %v, 預期 %v", i+1, a.requester, err, a.want)
+				}
+			}
+		})
+	}
+}
+
+func TestPasscodeLockoutReset(t *testing.T) {
+	const (
+		passcode  = "1234"
+		requester = "203.0.113.1"
+	)
+
+	tests := []struct {
+		name  string
+		reset func(s *RoomService, roomID string) error
+	}{
+		{
+			name: "暫停時間結束",
+			reset: func(s *RoomService, roomID string) error {
+				_, err := s.MutateRoom(roomID, func(room *models.Room) error {
+					expired := time.Now().Add(-time.Second)
+					room.PasscodeAttempts[requester].LockedUntil = &expired
+					return nil
+				})
+				return err
+			},
+		},
+		{
+			name: "主持人更換密碼",
+			reset: func(s *RoomService, roomID string) error {
+				_, err := s.SetPasscode(roomID, passcode)
+				return err
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestRoomService(t, nil)
+			room, err := s.CreateRoom("主持人", "", 0, 0, nil, nil, passcode, false)
+			if err != nil {
+				t.Fatalf("建立房間失敗: %v", err)
+			}
+
+			for i := 0; i < maxPasscodeFailures; i++ {
+				s.CheckPasscode(room.ID, "0000", requester)
+			}
+			if err := s.CheckPasscode(room.ID, passcode, requester); !errors.Is(err, ErrPasscodeLocked) {
+				t.Fatalf("錯誤次數達上限後 = %v, 預期 %v", err, ErrPasscodeLocked)
+			}
+
+			if err := tt.reset(s, room.ID); err != nil {
+				t.Fatalf("解除暫停失敗: %v", err)
+			}
+			if err := s.CheckPasscode(room.ID, passcode, requester); err != nil {
+				t.Fatalf("解除暫停後輸入正確密碼 = %v", err)
+			}
+
+			room, err = s.GetRoom(room.ID)
+			if err != nil {
+				t.Fatalf("讀取房間失敗: %v", err)
+			}
+			if len(room.PasscodeAttempts) != 0 {
+				t.Errorf("解除暫停後仍保留錯誤記錄: %v", room.PasscodeAttempts)
+			}
+		})
+	}
+}
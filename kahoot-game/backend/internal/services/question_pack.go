@@ -0,0 +1,415 @@
+package services
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"kahoot-game/internal/models"
+
+	"github.com/go-playground/validator/v10"
+	"gopkg.in/yaml.v3"
+)
+
+// 題目包檔案格式
+const (
+	QuestionPackJSON = "json"
+	QuestionPackCSV  = "csv"
+	QuestionPackYAML = "yaml"
+)
+
+// ErrUnsupportedPackFormat 不支援的題目包格式
+var ErrUnsupportedPackFormat = errors.New("不支援的題目包格式")
+
+// CSV 欄位名稱（不分大小寫，可使用底線或中文欄名）
+var questionPackCSVColumns = map[string]string{
+	"questiontext": "questionText",
+	"question":     "questionText",
+	"題目":           "questionText",
+	"optiona":      "optionA",
+	"選項a":          "optionA",
+	"optionb":      "optionB",
+	"選項b":          "optionB",
+	"category":     "category",
+	"分類":           "category",
+	"difficulty":   "difficulty",
+	"難度":           "difficulty",
+}
+
+// 以 binding 標籤驗證，與 API 建立題目時的規則一致
+var questionValidator = newQuestionValidator()
+
+func newQuestionValidator() *validator.Validate {
+	v := validator.New()
+	v.SetTagName("binding")
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		return strings.Split(field.Tag.Get("json"), ",")[0]
+	})
+	return v
+}
+
+// DetectPackFormat 依指定格式或檔名判斷題目包格式，無法判斷時使用 JSON
+func DetectPackFormat(format, filename string) (string, error) {
+	if format == "" {
+		format = strings.TrimPrefix(filepath.Ext(filename), ".")
+	}
+
+	switch strings.ToLower(format) {
+	case "", QuestionPackJSON:
+		return QuestionPackJSON, nil
+	case QuestionPackCSV:
+		return QuestionPackCSV, nil
+	case QuestionPackYAML, "yml":
+		return QuestionPackYAML, nil
+	}
+
+	return "", fmt.Errorf("%w: %s", ErrUnsupportedPackFormat, format)
+}
+
+// ParseQuestionPack 解析題目包檔案
+// JSON / YAML 可以是 {packId, questions} 物件或直接是題目陣列；CSV 第一列必須是欄位名稱
+func ParseQuestionPack(data []byte, format string) (*models.QuestionPack, error) {
+	// 試算表匯出的 CSV 常帶有 UTF-8 BOM
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+
+	var pack models.QuestionPack
+	var err error
+
+	switch format {
+	case QuestionPackJSON:
+		trimmed := bytes.TrimSpace(data)
+		if len(trimmed) > 0 && trimmed[0] == '[' {
+			err = json.Unmarshal(trimmed, &pack.Questions)
+		} else {
+			err = json.Unmarshal(trimmed, &pack)
+		}
+	case QuestionPackYAML:
+		var node yaml.Node
+		if err = yaml.Unmarshal(data, &node); err == nil && len(node.Content) > 0 && node.Content[0].Kind == yaml.SequenceNode {
+			err = node.Content[0].Decode(&pack.Questions)
+		} else if err == nil {
+			err = yaml.Unmarshal(data, &pack)
+		}
+	case QuestionPackCSV:
+		return parseQuestionPackCSV(data)
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedPackFormat, format)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("解析題目包失敗: %w", err)
+	}
+
+	for i := range pack.Questions {
+		pack.Questions[i].Row = i + 1
+	}
+
+	return &pack, nil
+}
+
+// parseQuestionPackCSV 解析 CSV 題目包，列號與試算表一致（欄位名稱為第 1 列）
+func parseQuestionPackCSV(data []byte) (*models.QuestionPack, error) {
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("讀取 CSV 欄位名稱失敗: %w", err)
+	}
+
+	columns := make(map[string]int)
+	for i, name := range header {
+		key := strings.ToLower(strings.ReplaceAll(strings.TrimSpace(name), "_", ""))
+		if field, exists := questionPackCSVColumns[key]; exists {
+			columns[field] = i
+		}
+	}
+	for _, required := range []string{"questionText", "optionA", "optionB"} {
+		if _, exists := columns[required]; !exists {
+			return nil, fmt.Errorf("CSV 缺少必要欄位: %s", required)
+		}
+	}
+
+	pack := &models.QuestionPack{}
+	row := 1
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		row++
+		if err != nil {
+			return nil, fmt.Errorf("讀取 CSV 第 %d 列失敗: %w", row, err)
+		}
+
+		cell := func(field string) string {
+			index, exists := columns[field]
+			if !exists || index >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[index])
+		}
+
+		// 略過試算表中的空白列
+		if strings.TrimSpace(strings.Join(record, "")) == "" {
+			continue
+		}
+
+		item := models.QuestionPackItem{
+			Row:          row,
+			QuestionText: cell("questionText"),
+			OptionA:      cell("optionA"),
+			OptionB:      cell("optionB"),
+			Category:     cell("category"),
+		}
+
+		// 難度格式錯誤時設為無效值，交給驗證回報
+		if difficulty := cell("difficulty"); difficulty != "" {
+			value, err := strconv.Atoi(difficulty)
+			if err != nil {
+				value = -1
+			}
+			item.Difficulty = value
+		}
+
+		pack.Questions = append(pack.Questions, item)
+	}
+
+	return pack, nil
+}
+
+// EncodeQuestionPack 將題目包輸出為指定格式
+func EncodeQuestionPack(pack *models.QuestionPack, format string) ([]byte, error) {
+	switch format {
+	case QuestionPackJSON:
+		return json.MarshalIndent(pack, "", "  ")
+
+	case QuestionPackYAML:
+		return yaml.Marshal(pack)
+
+	case QuestionPackCSV:
+		var buf bytes.Buffer
+		writer := csv.NewWriter(&buf)
+		writer.Write([]string{"questionText", "optionA", "optionB", "category", "difficulty"})
+		for _, item := range pack.Questions {
+			writer.Write([]string{
+				item.QuestionText, item.OptionA, item.OptionB, item.Category, strconv.Itoa(item.Difficulty),
+			})
+		}
+		writer.Flush()
+		return buf.Bytes(), writer.Error()
+	}
+
+	return nil, fmt.Errorf("%w: %s", ErrUnsupportedPackFormat, format)
+}
+
+// ExportQuestionPack 依條件匯出題庫
+func (s *QuestionService) ExportQuestionPack(filter models.QuestionFilter) (*models.QuestionPack, error) {
+	questions, err := s.GetQuestions(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	pack := &models.QuestionPack{
+		PackID:    filter.PackID,
+		Questions: make([]models.QuestionPackItem, 0, len(questions)),
+	}
+	for _, q := range questions {
+		pack.Questions = append(pack.Questions, models.QuestionPackItem{
+			QuestionText: q.QuestionText,
+			OptionA:      q.OptionA,
+			OptionB:      q.OptionB,
+			Category:     q.Category,
+			Difficulty:   q.Difficulty,
+		})
+	}
+
+	return pack, nil
+}
+
+// ImportQuestionPack 驗證並匯入題目包
+// 不合格的列會記錄在結果中並略過；與題庫或同一個檔案中重複的題目不會重複匯入；
+// dryRun 為 true 時只驗證不寫入
+func (s *QuestionService) ImportQuestionPack(pack *models.QuestionPack, dryRun bool) (*models.QuestionImportResult, error) {
+	packID := strings.TrimSpace(pack.PackID)
+	if packID == "" {
+		packID = DefaultQuestionPack
+	}
+
+	result := &models.QuestionImportResult{
+		PackID: packID,
+		DryRun: dryRun,
+		Total:  len(pack.Questions),
+		Errors: []models.QuestionImportError{},
+	}
+
+	if err := questionValidator.Var(packID, "max=50"); err != nil {
+		return nil, fmt.Errorf("題目包名稱不可超過 50 個字")
+	}
+
+	existing, err := s.GetQuestions(models.QuestionFilter{IncludeInactive: true})
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool, len(existing))
+	for _, q := range existing {
+		seen[questionDedupeKey(q.QuestionText, q.OptionA, q.OptionB)] = true
+	}
+
+	var accepted []models.CreateQuestionRequest
+	for _, item := range pack.Questions {
+		req := models.CreateQuestionRequest{
+			QuestionText: strings.TrimSpace(item.QuestionText),
+			OptionA:      strings.TrimSpace(item.OptionA),
+			OptionB:      strings.TrimSpace(item.OptionB),
+			Category:     strings.TrimSpace(item.Category),
+			Difficulty:   item.Difficulty,
+			PackID:       packID,
+		}
+
+		if rowErrors := validateQuestionRow(item.Row, &req); len(rowErrors) > 0 {
+			result.Errors = append(result.Errors, rowErrors...)
+			continue
+		}
+
+		key := questionDedupeKey(req.QuestionText, req.OptionA, req.OptionB)
+		if seen[key] {
+			result.Duplicates++
+			continue
+		}
+		seen[key] = true
+
+		accepted = append(accepted, req)
+	}
+
+	if !dryRun && len(accepted) > 0 {
+		if err := s.createQuestions(accepted); err != nil {
+			return nil, err
+		}
+		s.logger.Info("題目包匯入完成",
+			"packId", packID, "imported", len(accepted), "duplicates", result.Duplicates, "errors", len(result.Errors))
+	}
+
+	result.Imported = len(accepted)
+	return result, nil
+}
+
+// createQuestions 在同一個交易中新增多個題目
+func (s *QuestionService) createQuestions(reqs []models.CreateQuestionRequest) error {
+	if s.db == nil {
+		for i := range reqs {
+			if _, err := s.CreateQuestion(&reqs[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("開始交易失敗: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`
+		INSERT INTO two_types_questions (question_text, option_a, option_b, category, difficulty, pack_id, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`)
+	if err != nil {
+		return fmt.Errorf("準備插入語句失敗: %w", err)
+	}
+	defer stmt.Close()
+
+	now := time.Now()
+	for _, req := range reqs {
+		question := &models.Question{
+			QuestionText: req.QuestionText,
+			OptionA:      req.OptionA,
+			OptionB:      req.OptionB,
+			Category:     req.Category,
+			Difficulty:   req.Difficulty,
+			PackID:       req.PackID,
+		}
+		normalizeQuestion(question)
+
+		_, err := stmt.Exec(question.QuestionText, question.OptionA, question.OptionB,
+			question.Category, question.Difficulty, question.PackID, now)
+		if err != nil {
+			return fmt.Errorf("插入題目失敗: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("提交交易失敗: %w", err)
+	}
+
+	return nil
+}
+
+// validateQuestionRow 以建立題目的規則驗證單一列
+func validateQuestionRow(row int, req *models.CreateQuestionRequest) []models.QuestionImportError {
+	err := questionValidator.Struct(req)
+	if err == nil {
+		return nil
+	}
+
+	var validationErrors validator.ValidationErrors
+	if !errors.As(err, &validationErrors) {
+		return []models.QuestionImportError{{Row: row, Message: err.Error()}}
+	}
+
+	rowErrors := make([]models.QuestionImportError, 0, len(validationErrors))
+	for _, fieldErr := range validationErrors {
+		rowErrors = append(rowErrors, models.QuestionImportError{
+			Row:     row,
+			Field:   fieldErr.Field(),
+			Message: describeValidationError(fieldErr),
+		})
+	}
+	return rowErrors
+}
+
+// describeValidationError 將驗證錯誤轉為中文說明
+func describeValidationError(fieldErr validator.FieldError) string {
+	isNumber := fieldErr.Kind() == reflect.Int
+
+	switch fieldErr.Tag() {
+	case "required":
+		return "必填欄位"
+	case "min":
+		if isNumber {
+			return fmt.Sprintf("不可小於 %s", fieldErr.Param())
+		}
+		return fmt.Sprintf("長度至少 %s 個字", fieldErr.Param())
+	case "max":
+		if isNumber {
+			return fmt.Sprintf("不可大於 %s", fieldErr.Param())
+		}
+		return fmt.Sprintf("長度不可超過 %s 個字", fieldErr.Param())
+	}
+
+	return fmt.Sprintf("不符合規則: %s", fieldErr.Tag())
+}
+
+// questionDedupeKey 題目去重用的鍵：題目文字與兩個選項（不分大小寫與選項順序）
+func questionDedupeKey(questionText, optionA, optionB string) string {
+	normalize := func(value string) string {
+		return strings.ToLower(strings.Join(strings.Fields(value), " "))
+	}
+
+	a, b := normalize(optionA), normalize(optionB)
+	if a > b {
+		a, b = b, a
+	}
+	return normalize(questionText) + "\x00" + a + "\x00" + b
+}
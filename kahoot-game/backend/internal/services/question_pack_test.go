@@ -0,0 +1,204 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+
+	"kahoot-game/internal/models"
+)
+
+func TestQuestionPackRoundTrip(t *testing.T) {
+	pack := &models.QuestionPack{
+		PackID: "office",
+		Questions: []models.QuestionPackItem{
+			{QuestionText: "午餐你會選？", OptionA: "便當", OptionB: "麵店", Category: "food", Difficulty: 1},
+			{QuestionText: "開會時你會，\"先發言\"還是聽完再說？", OptionA: "先發言", OptionB: "聽完再說", Category: "work", Difficulty: 3},
+		},
+	}
+
+	for _, format := range []string{QuestionPackJSON, QuestionPackCSV, QuestionPackYAML} {
+		t.Run(format, func(t *testing.T) {
+			data, err := EncodeQuestionPack(pack, format)
+			if err != nil {
+				t.Fatalf("EncodeQuestionPack() error = %v", err)
+			}
+			got, err := ParseQuestionPack(data, format)
+			if err != nil {
+				t.Fatalf("ParseQuestionPack() error = %v", err)
+			}
+
+			if len(got.Questions) != len(pack.Questions) {
+				t.Fatalf("解析出 %d 題，預期 %d 題", len(got.Questions), len(pack.Questions))
+			}
+			for i, item := range got.Questions {
+				// CSV 的第 1 列是欄位名稱
+				wantRow := i + 1
+				if format == QuestionPackCSV {
+					wantRow = i + 2
+				}
+				if item.Row != wantRow {
+					t.Errorf("第 %d 題的列號 = %d, 預期 %d", i+1, item.Row, wantRow)
+				}
+				item.Row = 0
+				if !reflect.DeepEqual(item, pack.Questions[i]) {
+					t.Errorf("第 %d 題 = %+v, 預期 %+v", i+1, item, pack.Questions[i])
+				}
+			}
+		})
+	}
+}
+
+func TestParseQuestionPack(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  string
+		data    string
+		want    []models.QuestionPackItem
+		wantErr bool
+	}{
+		{
+			name:   "JSON 題目陣列",
+			format: QuestionPackJSON,
+			data:   `[{"questionText":"早餐你會選？","optionA":"蛋餅","optionB":"飯糰"}]`,
+			want:   []models.QuestionPackItem{{Row: 1, QuestionText: "早餐你會選？", OptionA: "蛋餅", OptionB: "飯糰"}},
+		},
+		{
+			name:   "YAML 題目陣列",
+			format: QuestionPackYAML,
+			data:   "- questionText: 早餐你會選？\n  optionA: 蛋餅\n  optionB: 飯糰\n",
+			want:   []models.QuestionPackItem{{Row: 1, QuestionText: "早餐你會選？", OptionA: "蛋餅", OptionB: "飯糰"}},
+		},
+		{
+			name:   "CSV 帶 BOM、中文欄名並略過空白列",
+			format: QuestionPackCSV,
+			data:   "\xef\xbb\xbf題目,選項A,選項B,難度\n早餐你會選？,蛋餅,飯糰,2\n,,,\n晚餐你會選？,火鍋,燒肉,\n",
+			want: []models.QuestionPackItem{
+				{Row: 2, QuestionText: "早餐你會選？", OptionA: "蛋餅", OptionB: "飯糰", Difficulty: 2},
+				{Row: 4, QuestionText: "晚餐你會選？", OptionA: "火鍋", OptionB: "燒肉"},
+			},
+		},
+		{
+			name:   "CSV 難度格式錯誤時設為無效值",
+			format: QuestionPackCSV,
+			data:   "question,option_a,option_b,difficulty\n早餐你會選？,蛋餅,飯糰,簡單\n",
+			want:   []models.QuestionPackItem{{Row: 2, QuestionText: "早餐你會選？", OptionA: "蛋餅", OptionB: "飯糰", Difficulty: -1}},
+		},
+		{
+			name:    "CSV 缺少必要欄位",
+			format:  QuestionPackCSV,
+			data:    "questionText,optionA\n早餐你會選？,蛋餅\n",
+			wantErr: true,
+		},
+		{
+			name:    "JSON 格式錯誤",
+			format:  QuestionPackJSON,
+			data:    `{"questions": [`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pack, err := ParseQuestionPack([]byte(tt.data), tt.format)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("ParseQuestionPack() 預期失敗，得到 %+v", pack)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseQuestionPack() error = %v", err)
+			}
+			if !reflect.DeepEqual(pack.Questions, tt.want) {
+				t.Errorf("ParseQuestionPack() =\n%+v\n預期\n%+v", pack.Questions, tt.want)
+			}
+		})
+	}
+}
+
+func TestImportQuestionPack(t *testing.T) {
+	tests := []struct {
+		name           string
+		csv            string
+		dryRun         bool
+		wantImported   int
+		wantDuplicates int
+		wantErrors     []models.QuestionImportError
+	}{
+		{
+			name:         "匯入所有合格的題目",
+			csv:          "questionText,optionA,optionB\n早餐你會選？,蛋餅,飯糰\n晚餐你會選？,火鍋,燒肉\n",
+			wantImported: 2,
+		},
+		{
+			name:         "缺少題目的列回報列號與欄位",
+			csv:          "questionText,optionA,optionB\n早餐你會選？,蛋餅,飯糰\n,火鍋,燒肉\n",
+			wantImported: 1,
+			wantErrors:   []models.QuestionImportError{{Row: 3, Field: "questionText", Message: "必填欄位"}},
+		},
+		{
+			name:         "難度錯誤的列回報列號與欄位",
+			csv:          "questionText,optionA,optionB,difficulty\n早餐你會選？,蛋餅,飯糰,簡單\n晚餐你會選？,火鍋,燒肉,6\n",
+			wantImported: 0,
+			wantErrors: []models.QuestionImportError{
+				{Row: 2, Field: "difficulty", Message: "不可小於 1"},
+				{Row: 3, Field: "difficulty", Message: "不可大於 5"},
+			},
+		},
+		{
+			name:           "略過與題庫或同一檔案中重複的題目",
+			csv:            "questionText,optionA,optionB\n公司聚餐你會選？,火鍋,燒肉\n早餐你會選？,蛋餅,飯糰\n早餐你會選？,蛋餅,飯糰\n",
+			wantImported:   1,
+			wantDuplicates: 2,
+		},
+		{
+			name:         "只驗證時不寫入",
+			csv:          "questionText,optionA,optionB\n早餐你會選？,蛋餅,飯糰\n,火鍋,燒肉\n",
+			dryRun:       true,
+			wantImported: 1,
+			wantErrors:   []models.QuestionImportError{{Row: 3, Field: "questionText", Message: "必填欄位"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestQuestionService(t, 1)
+			before, _ := s.GetQuestions(models.QuestionFilter{IncludeInactive: true})
+
+			pack, err := ParseQuestionPack([]byte(tt.csv), QuestionPackCSV)
+			if err != nil {
+				t.Fatalf("ParseQuestionPack() error = %v", err)
+			}
+			pack.PackID = "morning"
+
+			result, err := s.ImportQuestionPack(pack, tt.dryRun)
+			if err != nil {
+				t.Fatalf("ImportQuestionPack() error = %v", err)
+			}
+			if result.Imported != tt.wantImported || result.Duplicates != tt.wantDuplicates {
+				t.Errorf("匯入 %d 題、重複 %d 題，預期匯入 %d 題、重複 %d 題",
+					result.Imported, result.Duplicates, tt.wantImported, tt.wantDuplicates)
+			}
+			wantErrors := tt.wantErrors
+			if wantErrors == nil {
+				wantErrors = []models.QuestionImportError{}
+			}
+			if !reflect.DeepEqual(result.Errors, wantErrors) {
+				t.Errorf("Errors = %+v, 預期 %+v", result.Errors, wantErrors)
+			}
+
+			after, _ := s.GetQuestions(models.QuestionFilter{IncludeInactive: true})
+			wantAdded := tt.wantImported
+			if tt.dryRun {
+				wantAdded = 0
+			}
+			if added := len(after) - len(before); added != wantAdded {
+				t.Errorf("題庫新增 %d 題，預期 %d 題", added, wantAdded)
+			}
+			imported, _ := s.GetQuestions(models.QuestionFilter{PackID: "morning"})
+			if len(imported) != wantAdded {
+				t.Errorf("題目包 morning 有 %d 題，預期 %d 題", len(imported), wantAdded)
+			}
+		})
+	}
+}
@@ -0,0 +1,135 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+
+	"kahoot-game/internal/logging"
+	"kahoot-game/internal/models"
+)
+
+var (
+	// ErrInvalidQuestionSelection 選題條件不合理
+	ErrInvalidQuestionSelection = errors.New("選題條件錯誤")
+
+	// ErrNotEnoughQuestions 符合選題條件的題目不足
+	ErrNotEnoughQuestions = errors.New("符合選題條件的題目不足")
+)
+
+// ValidateQuestionSelection 檢查選題條件是否合理
+func ValidateQuestionSelection(selection *models.QuestionSelection) error {
+	if selection.IsEmpty() {
+		return nil
+	}
+
+	if err := questionValidator.Struct(selection); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidQuestionSelection, err)
+	}
+
+	for _, category := range selection.Categories {
+		for _, excluded := range selection.ExcludeCategories {
+			if category == excluded {
+				return fmt.Errorf("%w: 分類 %s 同時被指定與排除", ErrInvalidQuestionSelection, category)
+			}
+		}
+	}
+
+	return nil
+}
+
+// SelectQuestions 依選題條件從題庫選題，符合條件的題目少於 count 時回傳錯誤
+// 有設定分類權重時，權重越高的分類越容易被抽中
+func (s *QuestionService) SelectQuestions(count int, selection *models.QuestionSelection) ([]models.Question, error) {
+	if err := ValidateQuestionSelection(selection); err != nil {
+		return nil, err
+	}
+
+	candidates, err := s.GetQuestions(models.QuestionFilter{PackID: selection.PackID})
+	if err != nil {
+		s.logger.Warn("從資料庫載入「2種人」題目失敗，改用內建題庫", logging.Err(err))
+		candidates = ConvertToGameQuestions(GetTwoTypesQuestions())
+	}
+
+	candidates = filterQuestions(candidates, selection)
+	if len(candidates) < count {
+		return nil, fmt.Errorf("%w: 只有 %d 題，需要 %d 題", ErrNotEnoughQuestions, len(candidates), count)
+	}
+
+	return weightedSample(candidates, selection.CategoryWeights, count), nil
+}
+
+// filterQuestions 依分類、題目包與排除清單篩選題目
+func filterQuestions(questions []models.Question, selection *models.QuestionSelection) []models.Question {
+	toSet := func(values []string) map[string]bool {
+		set := make(map[string]bool, len(values))
+		for _, value := range values {
+			set[value] = true
+		}
+		return set
+	}
+
+	categories := toSet(selection.Categories)
+	excludedCategories := toSet(selection.ExcludeCategories)
+	excludedIDs := make(map[int]bool, len(selection.ExcludeQuestionIDs))
+	for _, id := range selection.ExcludeQuestionIDs {
+		excludedIDs[id] = true
+	}
+
+	filtered := make([]models.Question, 0, len(questions))
+	for _, q := range questions {
+		if len(categories) > 0 && !categories[q.Category] {
+			continue
+		}
+		if excludedCategories[q.Category] || excludedIDs[q.ID] {
+			continue
+		}
+		if selection.PackID != "" && q.PackID != selection.PackID {
+			continue
+		}
+		// 權重為 0 的分類不使用
+		if weight, exists := selection.CategoryWeights[q.Category]; exists && weight <= 0 {
+			continue
+		}
+		filtered = append(filtered, q)
+	}
+
+	return filtered
+}
+
+// weightedSample 依分類權重不重複抽出 count 題（Efraimidis-Spirakis 加權抽樣）
+// 未列出權重的分類權重為 1
+func weightedSample(questions []models.Question, weights map[string]int, count int) []models.Question {
+	type keyedQuestion struct {
+		key      float64
+		question models.Question
+	}
+
+	keyed := make([]keyedQuestion, len(questions))
+	for i, q := range questions {
+		weight := 1.0
+		if w, exists := weights[q.Category]; exists {
+			weight = float64(w)
+		}
+		keyed[i] = keyedQuestion{
+			key:      math.Pow(rand.Float64(), 1/weight),
+			question: q,
+		}
+	}
+
+	sort.Slice(keyed, func(i, j int) bool {
+		return keyed[i].key > keyed[j].key
+	})
+
+	if count > len(keyed) {
+		count = len(keyed)
+	}
+
+	selected := make([]models.Question, count)
+	for i := 0; i < count; i++ {
+		selected[i] = keyed[i].question
+	}
+	return selected
+}
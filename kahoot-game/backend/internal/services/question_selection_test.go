@@ -0,0 +1,194 @@
+package services
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+
+	"kahoot-game/internal/config"
+	"kahoot-game/internal/models"
+)
+
+// newTestQuestionService 使用內建題庫（每個分類 10 題）並加入自訂題目包的題目服務
+func newTestQuestionService(t *testing.T, customQuestions int) *QuestionService {
+	t.Helper()
+
+	s := NewQuestionService(nil, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	for i := 0; i < customQuestions; i++ {
+		_, err := s.CreateQuestion(&models.CreateQuestionRequest{
+			QuestionText: "公司聚餐你會選？",
+			OptionA:      "火鍋",
+			OptionB:      "燒肉",
+			Category:     "work",
+			PackID:       "office",
+		})
+		if err != nil {
+			t.Fatalf("建立題目失敗: %v", err)
+		}
+	}
+	return s
+}
+
+func TestSelectQuestions(t *testing.T) {
+	tests := []struct {
+		name      string
+		count     int
+		selection models.QuestionSelection
+		wantErr   error
+		accept    func(q models.Question) bool // 每一題都必須符合，nil 代表不檢查
+	}{
+		{
+			name:  "未設定條件時從整個題庫選題",
+			count: 10,
+		},
+		{
+			name:      "只使用指定分類",
+			count:     20,
+			selection: models.QuestionSelection{Categories: []string{"food", "habit"}},
+			accept: func(q models.Question) bool {
+				return q.Category == "food" || q.Category == "habit"
+			},
+		},
+		{
+			name:      "指定分類的題目不足",
+			count:     21,
+			selection: models.QuestionSelection{Categories: []string{"food", "habit"}},
+			wantErr:   ErrNotEnoughQuestions,
+		},
+		{
+			name:      "排除分類",
+			count:     48,
+			selection: models.QuestionSelection{ExcludeCategories: []string{"personality"}},
+			accept:    func(q models.Question) bool { return q.Category != "personality" },
+		},
+		{
+			name:      "排除分類後題目不足",
+			count:     49,
+			selection: models.QuestionSelection{ExcludeCategories: []string{"personality"}},
+			wantErr:   ErrNotEnoughQuestions,
+		},
+		{
+			name:      "排除指定題目",
+			count:     7,
+			selection: models.QuestionSelection{PackID: "office", ExcludeQuestionIDs: []int{51}},
+			accept:    func(q models.Question) bool { return q.ID != 51 },
+		},
+		{
+			name:      "只使用指定題目包",
+			count:     6,
+			selection: models.QuestionSelection{PackID: "office"},
+			accept:    func(q models.Question) bool { return q.PackID == "office" },
+		},
+		{
+			name:      "指定題目包的題目不足",
+			count:     9,
+			selection: models.QuestionSelection{PackID: "office"},
+			wantErr:   ErrNotEnoughQuestions,
+		},
+		{
+			name:      "權重為 0 的分類不使用",
+			count:     48,
+			selection: models.QuestionSelection{CategoryWeights: map[string]int{"tech": 0, "food": 5}},
+			accept:    func(q models.Question) bool { return q.Category != "tech" },
+		},
+		{
+			name:  "同一分類同時被指定與排除",
+			count: 5,
+			selection: models.QuestionSelection{
+				Categories:        []string{"food"},
+				ExcludeCategories: []string{"food"},
+			},
+			wantErr: ErrInvalidQuestionSelection,
+		},
+		{
+			name:      "權重超出範圍",
+			count:     5,
+			selection: models.QuestionSelection{CategoryWeights: map[string]int{"food": 101}},
+			wantErr:   ErrInvalidQuestionSelection,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestQuestionService(t, 8)
+
+			questions, err := s.SelectQuestions(tt.count, &tt.selection)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("SelectQuestions() error = %v, 預期 %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("SelectQuestions() error = %v", err)
+			}
+
+			if len(questions) != tt.count {
+				t.Fatalf("選出 %d 題，預期 %d 題", len(questions), tt.count)
+			}
+			seen := make(map[int]bool, len(questions))
+			for _, q := range questions {
+				if seen[q.ID] {
+					t.Errorf("題目 %d 重複", q.ID)
+				}
+				seen[q.ID] = true
+				if tt.accept != nil && !tt.accept(q) {
+					t.Errorf("題目 %d（分類 %s，題目包 %s）不符合選題條件", q.ID, q.Category, q.PackID)
+				}
+			}
+		})
+	}
+}
+
+func TestWeightedSample(t *testing.T) {
+	questions := []models.Question{
+		{ID: 1, Category: "food"},
+		{ID: 2, Category: "habit"},
+	}
+	weights := map[string]int{"food": 9}
+
+	// 權重 9:1 時抽中 food 的機率為 90%，重複抽樣後比例應明顯偏向 food
+	const trials = 1000
+	food := 0
+	for i := 0; i < trials; i++ {
+		selected := weightedSample(questions, weights, 1)
+		if len(selected) != 1 {
+			t.Fatalf("抽出 %d 題，預期 1 題", len(selected))
+		}
+		if selected[0].Category == "food" {
+			food++
+		}
+	}
+	if food < trials*8/10 {
+		t.Errorf("food 被抽中 %d/%d 次，預期約 90%%", food, trials)
+	}
+
+	if selected := weightedSample(questions, nil, 5); len(selected) != len(questions) {
+		t.Errorf("題目不足時抽出 %d 題，預期 %d 題", len(selected), len(questions))
+	}
+}
+
+func TestCreateRoomQuestionSelection(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	gameService := NewGameService(nil, nil, newTestQuestionService(t, 0), logger)
+	s := NewRoomService(NewMemoryRoomStore(), gameService, NewRoomLogService(nil, logger), config.GameConfig{}, logger)
+
+	familyEvent := &models.QuestionSelection{Categories: []string{"food", "habit"}}
+	room, err := s.CreateRoom("主持人", models.GameModeTwoTypes, 15, 0, familyEvent, nil, "", false)
+	if err != nil {
+		t.Fatalf("CreateRoom() error = %v", err)
+	}
+	if len(room.Questions) != 15 {
+		t.Fatalf("房間有 %d 題，預期 15 題", len(room.Questions))
+	}
+	for _, q := range room.Questions {
+		if q.Category != "food" && q.Category != "habit" {
+			t.Errorf("題目 %d 的分類 %s 不在選題條件中", q.ID, q.Category)
+		}
+	}
+
+	if _, err := s.CreateRoom("主持人", models.GameModeTwoTypes, 21, 0, familyEvent, nil, "", false); !errors.Is(err, ErrNotEnoughQuestions) {
+		t.Errorf("題目不足時 CreateRoom() error = %v, 預期 %v", err, ErrNotEnoughQuestions)
+	}
+}
@@ -2,65 +2,400 @@ package services
 
 import (
 	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand"
+	"sort"
+	"sync"
 	"time"
 
+	"kahoot-game/internal/logging"
 	"kahoot-game/internal/models"
 )
 
+const (
+	// 未指定分類時使用的預設分類
+	defaultQuestionCategory = "general"
+
+	// BuiltinQuestionPack 內建題目所屬的題目包
+	BuiltinQuestionPack = "builtin"
+
+	// DefaultQuestionPack 未指定題目包時使用的題目包
+	DefaultQuestionPack = "custom"
+)
+
+// ErrQuestionNotFound 題目不存在
+var ErrQuestionNotFound = errors.New("題目不存在")
+
 // QuestionService 題目服務
+// 管理「2種人」題庫：有資料庫時存放在 two_types_questions 表，
+// 否則使用以內建題庫初始化的記憶體題庫
 type QuestionService struct {
-	db *sql.DB
+	db     *sql.DB
+	logger *slog.Logger
+
+	// 記憶體模式的題庫
+	memoryQuestions []models.Question
+	memoryNextID    int
+	memoryMutex     sync.RWMutex
 }
 
 // NewQuestionService 創建題目服務
-func NewQuestionService(db *sql.DB) *QuestionService {
-	return &QuestionService{
-		db: db,
+func NewQuestionService(db *sql.DB, logger *slog.Logger) *QuestionService {
+	s := &QuestionService{
+		db:     db,
+		logger: logger,
+	}
+
+	if db == nil {
+		s.memoryQuestions = ConvertToGameQuestions(GetTwoTypesQuestions())
+		s.memoryNextID = len(s.memoryQuestions) + 1
+	}
+
+	return s
+}
+
+// SeedBuiltinQuestions 題庫為空時寫入內建題目
+func (s *QuestionService) SeedBuiltinQuestions() error {
+	if s.db == nil {
+		return nil
+	}
+
+	var count int
+	if err := s.db.QueryRow("SELECT COUNT(*) FROM two_types_questions").Scan(&count); err != nil {
+		return fmt.Errorf("檢查題目數量失敗: %w", err)
 	}
+
+	// 已有題目時不覆蓋使用者編輯過的題庫
+	if count > 0 {
+		return nil
+	}
+
+	stmt, err := s.db.Prepare(`
+		INSERT INTO two_types_questions (question_text, option_a, option_b, category, pack_id, created_by)
+		VALUES ($1, $2, $3, $4, $5, 'system')
+	`)
+	if err != nil {
+		return fmt.Errorf("準備插入語句失敗: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, q := range GetTwoTypesQuestions() {
+		if _, err := stmt.Exec(q.Question, q.OptionA, q.OptionB, q.Category, BuiltinQuestionPack); err != nil {
+			return fmt.Errorf("插入題目失敗: %w", err)
+		}
+	}
+
+	s.logger.Info("已寫入內建「2種人」題目", "count", len(GetTwoTypesQuestions()))
+	return nil
 }
 
-// GetRandomQuestions 獲取隨機題目 (已廢棄，使用 two_types_questions.go)
+// GetRandomQuestions 從題庫隨機選取啟用中的題目
+// 資料庫查詢失敗時改用內建題庫，避免遊戲無法開始
 func (s *QuestionService) GetRandomQuestions(count int) ([]models.Question, error) {
-	// 現在使用內建的「2種人」題庫，此方法已廢棄
-	return GetRandomQuestions(count), nil
-}
-
-// GetQuestions 獲取題目列表 (已廢棄，使用 two_types_questions.go)
-func (s *QuestionService) GetQuestions(category string, difficulty int, limit int) ([]models.Question, error) {
-	// 現在使用內建的「2種人」題庫，此方法已廢棄
-	allQuestions := GetRandomQuestions(50) // 取得所有題目
-	
-	// 簡單的分類過濾
-	var filteredQuestions []models.Question
-	for _, q := range allQuestions {
-		if category == "" || q.Category == category {
-			filteredQuestions = append(filteredQuestions, q)
+	if s.db == nil {
+		return s.randomMemoryQuestions(count), nil
+	}
+
+	query := `
+		SELECT id, question_text, option_a, option_b, COALESCE(category, ''),
+			   difficulty, COALESCE(pack_id, ''), times_used, is_active, created_at
+		FROM two_types_questions
+		WHERE is_active = true
+		ORDER BY RANDOM()
+		LIMIT $1
+	`
+
+	questions, err := s.queryQuestions(query, count)
+	if err != nil {
+		s.logger.Warn("從資料庫載入「2種人」題目失敗，改用內建題庫", logging.Err(err))
+		return GetRandomQuestions(count), nil
+	}
+
+	return questions, nil
+}
+
+// GetQuestions 依條件獲取題目列表
+func (s *QuestionService) GetQuestions(filter models.QuestionFilter) ([]models.Question, error) {
+	if s.db == nil {
+		return s.listMemoryQuestions(filter), nil
+	}
+
+	// LIMIT NULL 代表不限制筆數
+	var limit interface{}
+	if filter.Limit > 0 {
+		limit = filter.Limit
+	}
+
+	query := `
+		SELECT id, question_text, option_a, option_b, COALESCE(category, ''),
+			   difficulty, COALESCE(pack_id, ''), times_used, is_active, created_at
+		FROM two_types_questions
+		WHERE ($1 = '' OR category = $1)
+		  AND ($2 = 0 OR difficulty = $2)
+		  AND ($3 = '' OR pack_id = $3)
+		  AND ($4 OR is_active = true)
+		ORDER BY id
+		LIMIT $5
+	`
+
+	return s.queryQuestions(query, filter.Category, filter.Difficulty, filter.PackID, filter.IncludeInactive, limit)
+}
+
+// GetQuestion 獲取單一題目
+func (s *QuestionService) GetQuestion(id int) (*models.Question, error) {
+	if s.db == nil {
+		s.memoryMutex.RLock()
+		defer s.memoryMutex.RUnlock()
+
+		index := s.memoryIndex(id)
+		if index < 0 {
+			return nil, ErrQuestionNotFound
 		}
+		question := s.memoryQuestions[index]
+		return &question, nil
 	}
-	
-	// 限制數量
-	if limit > 0 && len(filteredQuestions) > limit {
-		filteredQuestions = filteredQuestions[:limit]
+
+	query := `
+		SELECT id, question_text, option_a, option_b, COALESCE(category, ''),
+			   difficulty, COALESCE(pack_id, ''), times_used, is_active, created_at
+		FROM two_types_questions
+		WHERE id = $1
+	`
+
+	questions, err := s.queryQuestions(query, id)
+	if err != nil {
+		return nil, err
 	}
-	
-	return filteredQuestions, nil
+	if len(questions) == 0 {
+		return nil, ErrQuestionNotFound
+	}
+
+	return &questions[0], nil
 }
 
 // CreateQuestion 創建新題目 (適用於「2種人」遊戲)
 func (s *QuestionService) CreateQuestion(req *models.CreateQuestionRequest) (*models.Question, error) {
-	// 由於我們現在使用內建題庫，這個方法簡化為記憶體操作
-	// 如果需要持久化存儲，可以後續擴展
-	
 	question := &models.Question{
-		ID:           0, // 新題目暫時設為0
 		QuestionText: req.QuestionText,
 		OptionA:      req.OptionA,
 		OptionB:      req.OptionB,
 		Category:     req.Category,
-		TimesUsed:    0,
+		Difficulty:   req.Difficulty,
+		PackID:       req.PackID,
 		IsActive:     true,
-		CreatedAt:    time.Now(),
 	}
-	
+	normalizeQuestion(question)
+
+	if s.db == nil {
+		s.memoryMutex.Lock()
+		defer s.memoryMutex.Unlock()
+
+		question.ID = s.memoryNextID
+		question.CreatedAt = time.Now()
+		s.memoryNextID++
+		s.memoryQuestions = append(s.memoryQuestions, *question)
+		return question, nil
+	}
+
+	query := `
+		INSERT INTO two_types_questions (question_text, option_a, option_b, category, difficulty, pack_id)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at
+	`
+
+	err := s.db.QueryRow(query, question.QuestionText, question.OptionA, question.OptionB,
+		question.Category, question.Difficulty, question.PackID).Scan(&question.ID, &question.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("創建題目失敗: %w", err)
+	}
+
+	return question, nil
+}
+
+// UpdateQuestion 更新題目，只修改請求中有提供的欄位
+func (s *QuestionService) UpdateQuestion(id int, req *models.UpdateQuestionRequest) (*models.Question, error) {
+	if s.db == nil {
+		s.memoryMutex.Lock()
+		defer s.memoryMutex.Unlock()
+
+		index := s.memoryIndex(id)
+		if index < 0 {
+			return nil, ErrQuestionNotFound
+		}
+		applyQuestionUpdate(&s.memoryQuestions[index], req)
+		question := s.memoryQuestions[index]
+		return &question, nil
+	}
+
+	question, err := s.GetQuestion(id)
+	if err != nil {
+		return nil, err
+	}
+	applyQuestionUpdate(question, req)
+
+	query := `
+		UPDATE two_types_questions
+		SET question_text = $2, option_a = $3, option_b = $4, category = $5,
+			difficulty = $6, pack_id = $7, is_active = $8, updated_at = NOW()
+		WHERE id = $1
+	`
+
+	result, err := s.db.Exec(query, id, question.QuestionText, question.OptionA, question.OptionB,
+		question.Category, question.Difficulty, question.PackID, question.IsActive)
+	if err != nil {
+		return nil, fmt.Errorf("更新題目失敗: %w", err)
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return nil, ErrQuestionNotFound
+	}
+
 	return question, nil
-}
\ No newline at end of file
+}
+
+// SetQuestionActive 啟用或停用題目，停用的題目不會出現在遊戲中
+func (s *QuestionService) SetQuestionActive(id int, active bool) (*models.Question, error) {
+	return s.UpdateQuestion(id, &models.UpdateQuestionRequest{IsActive: &active})
+}
+
+// DeleteQuestion 刪除題目
+func (s *QuestionService) DeleteQuestion(id int) error {
+	if s.db == nil {
+		s.memoryMutex.Lock()
+		defer s.memoryMutex.Unlock()
+
+		index := s.memoryIndex(id)
+		if index < 0 {
+			return ErrQuestionNotFound
+		}
+		s.memoryQuestions = append(s.memoryQuestions[:index], s.memoryQuestions[index+1:]...)
+		return nil
+	}
+
+	result, err := s.db.Exec("DELETE FROM two_types_questions WHERE id = $1", id)
+	if err != nil {
+		return fmt.Errorf("刪除題目失敗: %w", err)
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return ErrQuestionNotFound
+	}
+
+	return nil
+}
+
+// queryQuestions 執行題目查詢並掃描結果
+func (s *QuestionService) queryQuestions(query string, args ...interface{}) ([]models.Question, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("查詢題目失敗: %w", err)
+	}
+	defer rows.Close()
+
+	questions := []models.Question{}
+	for rows.Next() {
+		var q models.Question
+		err := rows.Scan(
+			&q.ID, &q.QuestionText, &q.OptionA, &q.OptionB, &q.Category,
+			&q.Difficulty, &q.PackID, &q.TimesUsed, &q.IsActive, &q.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("掃描題目資料失敗: %w", err)
+		}
+		questions = append(questions, q)
+	}
+
+	return questions, rows.Err()
+}
+
+// randomMemoryQuestions 記憶體模式：隨機選取啟用中的題目
+func (s *QuestionService) randomMemoryQuestions(count int) []models.Question {
+	questions := s.listMemoryQuestions(models.QuestionFilter{})
+
+	rand.Shuffle(len(questions), func(i, j int) {
+		questions[i], questions[j] = questions[j], questions[i]
+	})
+
+	if count < len(questions) {
+		questions = questions[:count]
+	}
+	return questions
+}
+
+// listMemoryQuestions 記憶體模式的題目查詢（回傳副本）
+func (s *QuestionService) listMemoryQuestions(filter models.QuestionFilter) []models.Question {
+	s.memoryMutex.RLock()
+	defer s.memoryMutex.RUnlock()
+
+	questions := []models.Question{}
+	for _, q := range s.memoryQuestions {
+		if filter.Category != "" && q.Category != filter.Category {
+			continue
+		}
+		if filter.Difficulty != 0 && q.Difficulty != filter.Difficulty {
+			continue
+		}
+		if filter.PackID != "" && q.PackID != filter.PackID {
+			continue
+		}
+		if !filter.IncludeInactive && !q.IsActive {
+			continue
+		}
+		questions = append(questions, q)
+		if filter.Limit > 0 && len(questions) >= filter.Limit {
+			break
+		}
+	}
+
+	return questions
+}
+
+// memoryIndex 依 ID 找出記憶體題庫中的位置（需已持有鎖），找不到時回傳 -1
+func (s *QuestionService) memoryIndex(id int) int {
+	index := sort.Search(len(s.memoryQuestions), func(i int) bool {
+		return s.memoryQuestions[i].ID >= id
+	})
+	if index < len(s.memoryQuestions) && s.memoryQuestions[index].ID == id {
+		return index
+	}
+	return -1
+}
+
+// applyQuestionUpdate 套用更新請求中有提供的欄位
+func applyQuestionUpdate(question *models.Question, req *models.UpdateQuestionRequest) {
+	if req.QuestionText != nil {
+		question.QuestionText = *req.QuestionText
+	}
+	if req.OptionA != nil {
+		question.OptionA = *req.OptionA
+	}
+	if req.OptionB != nil {
+		question.OptionB = *req.OptionB
+	}
+	if req.Category != nil {
+		question.Category = *req.Category
+	}
+	if req.Difficulty != nil {
+		question.Difficulty = *req.Difficulty
+	}
+	if req.PackID != nil {
+		question.PackID = *req.PackID
+	}
+	if req.IsActive != nil {
+		question.IsActive = *req.IsActive
+	}
+	normalizeQuestion(question)
+}
+
+// normalizeQuestion 補上未指定欄位的預設值
+func normalizeQuestion(question *models.Question) {
+	if question.Category == "" {
+		question.Category = defaultQuestionCategory
+	}
+	if question.Difficulty == 0 {
+		question.Difficulty = 1
+	}
+	if question.PackID == "" {
+		question.PackID = DefaultQuestionPack
+	}
+}
@@ -0,0 +1,62 @@
+package services
+
+import (
+	"sort"
+
+	"kahoot-game/internal/models"
+)
+
+// 快速配對建立的房間主持人名稱
+const quickJoinHostName = "快速配對"
+
+// ListPublicRooms 列出等待中、仍有空位的公開房間（設有密碼的房間不列出）
+// gameMode 不為空時只列出該遊戲模式；結果依玩家數由多到少排列，人數相同時較早建立的在前
+func (s *RoomService) ListPublicRooms(gameMode string) ([]models.RoomInfo, error) {
+	rooms, _, err := s.activeRooms()
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]models.RoomInfo, 0)
+	for _, room := range rooms {
+		if !room.IsPublic || room.HasPasscode || room.Status != models.RoomStatusWaiting {
+			continue
+		}
+		if gameMode != "" && room.GameMode != gameMode {
+			continue
+		}
+		if room.GetPlayerCount() >= s.settings.MaxPlayersPerRoom {
+			continue
+		}
+		result = append(result, room.Info(s.settings.MaxPlayersPerRoom))
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].PlayerCount != result[j].PlayerCount {
+			return result[i].PlayerCount > result[j].PlayerCount
+		}
+		return result[i].CreatedAt.Before(result[j].CreatedAt)
+	})
+
+	return result, nil
+}
+
+// activeRooms 讀取所有活躍房間
+// Redis 中已過期的房間會順便從活躍房間列表移除，並回傳移除的數量
+func (s *RoomService) activeRooms() ([]*models.Room, int, error) {
+	return s.store.ListRooms()
+}
+
+// CreateQuickJoinRoom 快速配對找不到房間時建立公開房間
+// 快速配對的房間沒有主持人，房間中的玩家都可以開始遊戲；題數與作答秒數使用設定的預設值
+func (s *RoomService) CreateQuickJoinRoom(gameMode string) (*models.Room, error) {
+	room, err := s.CreateRoom(quickJoinHostName, gameMode, 0, 0, nil, nil, "", true)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.MutateRoom(room.ID, func(room *models.Room) error {
+		room.QuickJoin = true
+		return nil
+	})
+}
@@ -0,0 +1,125 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"kahoot-game/internal/logging"
+	"kahoot-game/internal/models"
+)
+
+// 房間被回收的原因
+const (
+	ReapReasonEmpty    = "empty"    // 沒有玩家
+	ReapReasonFinished = "finished" // 遊戲已結束
+	ReapReasonIdle     = "idle"     // 長時間沒有活動
+)
+
+// RoomReapPolicy 房間回收條件，0 代表不使用該條件
+type RoomReapPolicy struct {
+	EmptyAfter    time.Duration // 沒有玩家超過此時間
+	FinishedAfter time.Duration // 遊戲結束超過此時間
+	IdleAfter     time.Duration // 沒有任何活動超過此時間
+}
+
+// ReapReason 房間符合回收條件時回傳原因，否則回傳空字串
+func (p RoomReapPolicy) ReapReason(room *models.Room, now time.Time) string {
+	idle := now.Sub(room.LastActivityAt())
+
+	if p.EmptyAfter > 0 && room.GetPlayerCount() == 0 && idle > p.EmptyAfter {
+		return ReapReasonEmpty
+	}
+	if p.FinishedAfter > 0 && room.Status == models.RoomStatusFinished &&
+		room.FinishedAt != nil && now.Sub(*room.FinishedAt) > p.FinishedAfter {
+		return ReapReasonFinished
+	}
+	if p.IdleAfter > 0 && idle > p.IdleAfter {
+		return ReapReasonIdle
+	}
+	return ""
+}
+
+// StaleRoom 符合回收條件的房間
+type StaleRoom struct {
+	RoomID string
+	Reason string
+}
+
+// FindStaleRooms 找出符合回收條件的房間，並回傳從活躍房間列表移除的過期房間數
+func (s *RoomService) FindStaleRooms(policy RoomReapPolicy, now time.Time) ([]StaleRoom, int, error) {
+	rooms, pruned, err := s.activeRooms()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var stale []StaleRoom
+	for _, room := range rooms {
+		if reason := policy.ReapReason(room, now); reason != "" {
+			stale = append(stale, StaleRoom{RoomID: room.ID, Reason: reason})
+		}
+	}
+
+	return stale, pruned, nil
+}
+
+// ReapRoom 刪除過期房間與其玩家資料
+// 刪除前會重新確認房間仍符合回收條件，回傳 false 代表房間已有新動靜或已被刪除
+func (s *RoomService) ReapRoom(roomID string, policy RoomReapPolicy, now time.Time) (bool, error) {
+	room, err := s.GetRoom(roomID)
+	if errors.Is(err, ErrRoomNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	reason := policy.ReapReason(room, now)
+	if reason == "" {
+		return false, nil
+	}
+
+	if len(room.Players) > 0 {
+		playerIDs := make([]string, 0, len(room.Players))
+		for playerID := range room.Players {
+			playerIDs = append(playerIDs, playerID)
+		}
+		if err := s.store.DeletePlayers(playerIDs...); err != nil {
+			s.logger.Warn("刪除房間的玩家資料失敗", logging.KeyRoomID, roomID, logging.Err(err))
+		}
+	}
+
+	if err := s.DeleteRoom(roomID); err != nil {
+		return false, err
+	}
+
+	s.roomLogs.Record(roomID, models.RoomEventRoomClosed, "", map[string]interface{}{
+		"reason":       reason,
+		"totalPlayers": room.GetPlayerCount(),
+		"lastActivity": room.LastActivityAt(),
+	})
+
+	return true, nil
+}
+
+// CleanupPlayerKeys 刪除房間已不存在、或已不在房間中的玩家資料
+func (s *RoomService) CleanupPlayerKeys() (int, error) {
+	rooms := make(map[string]*models.Room)
+
+	return s.store.CleanupPlayers(func(player *models.Player) (bool, error) {
+		room, checked := rooms[player.RoomID]
+		if !checked {
+			var err error
+			room, err = s.GetRoom(player.RoomID)
+			if err != nil && !errors.Is(err, ErrRoomNotFound) {
+				return false, err
+			}
+			rooms[player.RoomID] = room
+		}
+
+		if room == nil {
+			return true, nil
+		}
+		_, exists := room.Players[player.ID]
+		return !exists, nil
+	})
+}
@@ -0,0 +1,204 @@
+package services
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"kahoot-game/internal/logging"
+	"kahoot-game/internal/models"
+)
+
+const (
+	// 等待寫入資料庫的事件上限，超過時捨棄事件以免拖慢遊戲
+	roomLogQueueSize = 1024
+
+	// 記憶體模式下每個房間保留的事件數與保留事件的房間數
+	memoryRoomLogLimit = 1000
+	memoryRoomLogRooms = 200
+
+	// 時間軸查詢的預設與最大筆數
+	DefaultRoomLogPageSize = 100
+	MaxRoomLogPageSize     = 500
+)
+
+// RoomLogService 房間事件日誌服務
+// 有資料庫時寫入 room_logs，否則保留在記憶體中
+type RoomLogService struct {
+	db     *sql.DB
+	queue  chan *models.RoomLog
+	logger *slog.Logger
+
+	// 記憶體模式的事件存儲
+	memoryLogs   map[string][]models.RoomLog
+	memoryOrder  []string
+	memoryNextID int64
+	memoryMutex  sync.RWMutex
+}
+
+// NewRoomLogService 創建房間事件日誌服務
+func NewRoomLogService(db *sql.DB, logger *slog.Logger) *RoomLogService {
+	s := &RoomLogService{
+		db:         db,
+		logger:     logger,
+		memoryLogs: make(map[string][]models.RoomLog),
+	}
+
+	// 由單一寫入者依序寫入，確保時間軸順序與事件發生順序一致
+	if db != nil {
+		s.queue = make(chan *models.RoomLog, roomLogQueueSize)
+		go s.runWriter()
+	}
+
+	return s
+}
+
+// Record 記錄房間事件，不會阻塞遊戲流程；寫入失敗只記錄日誌
+func (s *RoomLogService) Record(roomID, eventType, playerName string, data map[string]interface{}) {
+	if s == nil {
+		return
+	}
+
+	entry := &models.RoomLog{
+		RoomID:     roomID,
+		EventType:  eventType,
+		PlayerName: playerName,
+		CreatedAt:  time.Now(),
+	}
+
+	if data != nil {
+		payload, err := json.Marshal(data)
+		if err != nil {
+			s.logger.Warn("房間事件序列化失敗", logging.KeyRoomID, roomID, "event", eventType, logging.Err(err))
+			return
+		}
+		entry.EventData = payload
+	}
+
+	if s.db == nil {
+		s.appendMemory(entry)
+		return
+	}
+
+	select {
+	case s.queue <- entry:
+	default:
+		s.logger.Warn("房間事件佇列已滿，捨棄事件", logging.KeyRoomID, roomID, "event", eventType)
+	}
+}
+
+// runWriter 依序將事件寫入資料庫
+func (s *RoomLogService) runWriter() {
+	query := `
+		INSERT INTO room_logs (room_id, event_type, player_name, event_data, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+	`
+
+	for entry := range s.queue {
+		playerName := sql.NullString{String: entry.PlayerName, Valid: entry.PlayerName != ""}
+		eventData := sql.NullString{String: string(entry.EventData), Valid: len(entry.EventData) > 0}
+
+		_, err := s.db.Exec(query, entry.RoomID, entry.EventType, playerName, eventData, entry.CreatedAt)
+		if err != nil {
+			s.logger.Error("寫入房間事件失敗", logging.KeyRoomID, entry.RoomID, "event", entry.EventType, logging.Err(err))
+		}
+	}
+}
+
+// appendMemory 記憶體模式：保存事件，超過上限時捨棄最舊的事件與房間
+func (s *RoomLogService) appendMemory(entry *models.RoomLog) {
+	s.memoryMutex.Lock()
+	defer s.memoryMutex.Unlock()
+
+	s.memoryNextID++
+	entry.ID = s.memoryNextID
+
+	logs, exists := s.memoryLogs[entry.RoomID]
+	if !exists {
+		s.memoryOrder = append(s.memoryOrder, entry.RoomID)
+		if len(s.memoryOrder) > memoryRoomLogRooms {
+			delete(s.memoryLogs, s.memoryOrder[0])
+			s.memoryOrder = s.memoryOrder[1:]
+		}
+	}
+
+	logs = append(logs, *entry)
+	if len(logs) > memoryRoomLogLimit {
+		logs = logs[len(logs)-memoryRoomLogLimit:]
+	}
+	s.memoryLogs[entry.RoomID] = logs
+}
+
+// ListEvents 依時間順序分頁查詢房間事件
+// afterID 為上一頁最後一筆事件的 ID，eventType 為空字串時不篩選類型
+func (s *RoomLogService) ListEvents(roomID string, afterID int64, eventType string, limit int) ([]models.RoomLog, error) {
+	if limit <= 0 {
+		limit = DefaultRoomLogPageSize
+	}
+	if limit > MaxRoomLogPageSize {
+		limit = MaxRoomLogPageSize
+	}
+
+	if s.db == nil {
+		return s.listMemory(roomID, afterID, eventType, limit), nil
+	}
+
+	query := `
+		SELECT id, room_id, event_type, COALESCE(player_name, ''), event_data, created_at
+		FROM room_logs
+		WHERE room_id = $1 AND id > $2 AND ($3 = '' OR event_type = $3)
+		ORDER BY id
+		LIMIT $4
+	`
+
+	rows, err := s.db.Query(query, roomID, afterID, eventType, limit)
+	if err != nil {
+		return nil, fmt.Errorf("查詢房間事件失敗: %w", err)
+	}
+	defer rows.Close()
+
+	events := []models.RoomLog{}
+	for rows.Next() {
+		var event models.RoomLog
+		var eventData []byte
+		err := rows.Scan(&event.ID, &event.RoomID, &event.EventType, &event.PlayerName, &eventData, &event.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("掃描房間事件失敗: %w", err)
+		}
+		if len(eventData) > 0 {
+			event.EventData = json.RawMessage(eventData)
+		}
+		events = append(events, event)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("讀取房間事件失敗: %w", err)
+	}
+
+	return events, nil
+}
+
+// listMemory 記憶體模式的事件查詢
+func (s *RoomLogService) listMemory(roomID string, afterID int64, eventType string, limit int) []models.RoomLog {
+	s.memoryMutex.RLock()
+	defer s.memoryMutex.RUnlock()
+
+	events := []models.RoomLog{}
+	for _, event := range s.memoryLogs[roomID] {
+		if event.ID <= afterID {
+			continue
+		}
+		if eventType != "" && event.EventType != eventType {
+			continue
+		}
+		events = append(events, event)
+		if len(events) >= limit {
+			break
+		}
+	}
+
+	return events
+}
@@ -1,150 +1,193 @@
 package services
 
 import (
-	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
+	"log/slog"
 	"math/rand"
-	"sync"
 	"time"
 
-	"kahoot-game/internal/database"
+	"kahoot-game/internal/config"
 	"kahoot-game/internal/models"
+)
+
+const (
+	// 房間設定未指定時的預設值
+	defaultMaxPlayersPerRoom = 20
+	defaultRoomIDLength      = 6
+	defaultQuestionTimeLimit = 30
+	defaultTotalQuestions    = 10
+
+	// 產生房間ID時遇到重複ID的最大重試次數
+	maxRoomIDAttempts = 20
+)
 
-	"github.com/go-redis/redis/v8"
+var (
+	// ErrRoomNotFound 房間不存在
+	ErrRoomNotFound = errors.New("房間不存在")
+
+	// ErrRoomConflict 房間同時被大量修改，重試後仍無法寫入
+	ErrRoomConflict = errors.New("房間資料更新衝突，請稍後再試")
+
+	// ErrPlayerBanned 玩家已被主持人禁止加入此房間
+	ErrPlayerBanned = errors.New("你已被主持人禁止加入此房間")
 )
 
 // RoomService 房間服務
 type RoomService struct {
-	redisClient *redis.Client
+	store       RoomStore
 	gameService *GameService
-	keys        *database.RedisKeys
-	
-	// 測試模式用的記憶體存儲
-	memoryRooms map[string]*models.Room
-	memoryMutex sync.RWMutex
+	roomLogs    *RoomLogService
+	settings    config.GameConfig
+	logger      *slog.Logger
+
+	// 房間刪除時的回呼（例如停止房間排程器）
+	roomDeletedHooks []func(roomID string)
 }
 
-// NewRoomService 創建房間服務
-func NewRoomService(redisClient *redis.Client, gameService *GameService) *RoomService {
+// NewRoomService 創建房間服務，房間與玩家資料存放在 store
+// settings 為房間人數上限、房間ID長度，以及建立房間未指定題數與作答秒數時的預設值，未設定（0）的項目使用預設值
+func NewRoomService(store RoomStore, gameService *GameService, roomLogs *RoomLogService, settings config.GameConfig, logger *slog.Logger) *RoomService {
+	if settings.MaxPlayersPerRoom <= 0 {
+		settings.MaxPlayersPerRoom = defaultMaxPlayersPerRoom
+	}
+	if settings.RoomIDLength <= 0 {
+		settings.RoomIDLength = defaultRoomIDLength
+	}
+	if settings.QuestionTimeLimit <= 0 {
+		settings.QuestionTimeLimit = defaultQuestionTimeLimit
+	}
+	if settings.DefaultTotalQuestions <= 0 {
+		settings.DefaultTotalQuestions = defaultTotalQuestions
+	}
+
 	return &RoomService{
-		redisClient: redisClient,
+		store:       store,
 		gameService: gameService,
-		keys:        database.NewRedisKeys(),
-		memoryRooms: make(map[string]*models.Room),
+		roomLogs:    roomLogs,
+		settings:    settings,
+		logger:      logger,
 	}
 }
 
+// MaxPlayersPerRoom 每個房間的玩家人數上限
+func (s *RoomService) MaxPlayersPerRoom() int {
+	return s.settings.MaxPlayersPerRoom
+}
+
+// StorageMode 目前的房間存放方式
+func (s *RoomService) StorageMode() string {
+	return s.store.Name()
+}
+
+// SharedStore 房間資料是否由多個節點共用（Redis 模式）
+func (s *RoomService) SharedStore() bool {
+	return s.store.Shared()
+}
+
 // CreateRoom 創建房間
-func (s *RoomService) CreateRoom(hostName string, totalQuestions, questionTimeLimit int) (*models.Room, error) {
-	ctx := context.Background()
+// selection 為房間的選題條件（可為 nil），之後每次開始遊戲都會依同樣條件重新選題
+// teams 為隊伍名稱（可為空），設定兩隊以上即為隊伍模式；passcode 為房間密碼（可為空），只保存雜湊
+// public 為 true 時房間會出現在公開房間列表與快速配對中；totalQuestions、questionTimeLimit 為 0 時使用設定的預設值
+func (s *RoomService) CreateRoom(hostName, gameMode string, totalQuestions, questionTimeLimit int, selection *models.QuestionSelection, teams []string, passcode string, public bool) (*models.Room, error) {
+	if totalQuestions <= 0 {
+		totalQuestions = s.settings.DefaultTotalQuestions
+	}
+	if questionTimeLimit <= 0 {
+		questionTimeLimit = s.settings.QuestionTimeLimit
+	}
 	
-	// 生成唯一房間ID
-	roomID := s.generateRoomID()
+	// 取得遊戲模式
+	mode, err := s.gameService.GetMode(gameMode)
+	if err != nil {
+		return nil, err
+	}
 	
-	// 生成「2種人」題目
-	questions := GetRandomQuestions(totalQuestions)
+	// 檢查隊伍設定
+	teams, err = NormalizeTeams(teams)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := mode.(TeamRanker); len(teams) > 0 && !ok {
+		return nil, fmt.Errorf("%w: %s", ErrTeamsUnsupported, mode.Name())
+	}
+	
+	// 房間密碼只保存雜湊
+	passcodeHash, err := hashPasscode(passcode)
+	if err != nil {
+		return nil, err
+	}
+	
+	// 依遊戲模式準備題目
+	var questions []models.Question
+	if selection.IsEmpty() {
+		selection = nil
+		questions, err = mode.LoadQuestions(totalQuestions)
+	} else if selector, ok := mode.(QuestionSelector); ok {
+		questions, err = selector.SelectQuestions(totalQuestions, selection)
+	} else {
+		return nil, fmt.Errorf("%w: %s", ErrSelectionUnsupported, mode.Name())
+	}
+	if err != nil {
+		return nil, fmt.Errorf("準備題目失敗: %w", err)
+	}
 	
 	// 創建房間
 	room := &models.Room{
-		ID:                roomID,
 		HostName:          hostName,
+		GameMode:          mode.ID(),
 		Status:            models.RoomStatusWaiting,
 		Players:           make(map[string]*models.Player),
 		CurrentQuestion:   0,
 		TotalQuestions:    totalQuestions,
 		QuestionTimeLimit: questionTimeLimit,
 		Questions:         questions,
+		QuestionSelection: selection,
+		Teams:             teams,
+		IsPublic:          public,
+		HasPasscode:       passcodeHash != "",
+		PasscodeHash:      passcodeHash,
 		CreatedAt:         time.Now(),
 	}
 	
-	// 存儲到 Redis（如果有 Redis 客戶端）或記憶體
-	if s.redisClient != nil {
-		roomData, err := json.Marshal(room)
-		if err != nil {
-			return nil, fmt.Errorf("序列化房間資料失敗: %w", err)
-		}
-		
-		err = s.redisClient.Set(ctx, s.keys.RoomKey(roomID), roomData, database.RoomExpiration).Err()
-		if err != nil {
-			return nil, fmt.Errorf("存儲房間資料失敗: %w", err)
-		}
-		
-		// 添加到活躍房間列表
-		err = s.redisClient.SAdd(ctx, database.ActiveRoomsKey, roomID).Err()
-		if err != nil {
-			return nil, fmt.Errorf("添加到活躍房間列表失敗: %w", err)
-		}
-	} else {
-		// 測試模式：存儲到記憶體
-		s.memoryMutex.Lock()
-		s.memoryRooms[roomID] = room
-		s.memoryMutex.Unlock()
+	// 生成唯一房間ID並存儲
+	if err := s.storeNewRoom(room); err != nil {
+		return nil, err
 	}
 	
+	s.roomLogs.Record(room.ID, models.RoomEventCreated, hostName, map[string]interface{}{
+		"gameMode":          room.GameMode,
+		"totalQuestions":    totalQuestions,
+		"questionTimeLimit": questionTimeLimit,
+		"questionSelection": selection,
+		"teams":             teams,
+		"hasPasscode":       room.HasPasscode,
+		"isPublic":          public,
+	})
+	
 	return room, nil
 }
 
 // GetRoom 獲取房間資訊
 func (s *RoomService) GetRoom(roomID string) (*models.Room, error) {
-	if s.redisClient != nil {
-		ctx := context.Background()
-		
-		roomData, err := s.redisClient.Get(ctx, s.keys.RoomKey(roomID)).Result()
-		if err == redis.Nil {
-			return nil, fmt.Errorf("房間不存在")
-		} else if err != nil {
-			return nil, fmt.Errorf("獲取房間資料失敗: %w", err)
-		}
-		
-		var room models.Room
-		err = json.Unmarshal([]byte(roomData), &room)
-		if err != nil {
-			return nil, fmt.Errorf("反序列化房間資料失敗: %w", err)
-		}
-		
-		return &room, nil
-	} else {
-		// 測試模式：從記憶體獲取
-		s.memoryMutex.RLock()
-		room, exists := s.memoryRooms[roomID]
-		s.memoryMutex.RUnlock()
-		
-		if !exists {
-			return nil, fmt.Errorf("房間不存在")
-		}
-		
-		return room, nil
-	}
+	return s.store.GetRoom(roomID)
+}
+
+// MutateRoom 以原子方式讀取、修改並寫回房間
+// Redis 模式使用 WATCH/MULTI 樂觀鎖，寫入前房間被其他連線修改時會重新讀取並重試，
+// 因此 fn 可能被呼叫多次：fn 只能修改傳入的房間，不可有其他副作用，也不可再呼叫 RoomService。
+// fn 回傳錯誤時放棄本次修改並原樣回傳該錯誤
+func (s *RoomService) MutateRoom(roomID string, fn func(room *models.Room) error) (*models.Room, error) {
+	return s.store.MutateRoom(roomID, fn)
 }
 
 // AddPlayer 添加玩家到房間
-func (s *RoomService) AddPlayer(roomID, playerID, playerName string) (*models.Player, error) {
-	ctx := context.Background()
-	
-	// 獲取房間
-	room, err := s.GetRoom(roomID)
-	if err != nil {
+// 房間設有密碼時需提供正確的 passcode，requester 為連線來源（用於計算密碼錯誤次數）
+func (s *RoomService) AddPlayer(roomID, playerID, playerName, passcode, requester string) (*models.Player, error) {
+	if err := s.CheckPasscode(roomID, passcode, requester); err != nil {
 		return nil, err
 	}
 	
-	// 檢查房間狀態
-	if room.Status != models.RoomStatusWaiting {
-		return nil, fmt.Errorf("遊戲已開始，無法加入")
-	}
-	
-	// 檢查房間人數限制
-	if len(room.Players) >= 20 { // 最大20人
-		return nil, fmt.Errorf("房間已滿")
-	}
-	
-	// 檢查玩家名稱是否重複
-	for _, player := range room.Players {
-		if player.Name == playerName {
-			return nil, fmt.Errorf("玩家名稱已存在")
-		}
-	}
-	
 	// 創建玩家
 	player := &models.Player{
 		ID:           playerID,
@@ -156,146 +199,162 @@ func (s *RoomService) AddPlayer(roomID, playerID, playerName string) (*models.Pl
 		LastActivity: time.Now(),
 	}
 	
-	// 添加玩家到房間
-	room.AddPlayer(player)
-	
-	// 更新房間資料
-	err = s.updateRoom(room)
+	room, err := s.MutateRoom(roomID, func(room *models.Room) error {
+		// 被踢出並禁止加入的玩家不能以同一名稱或工作階段再加入
+		if room.IsBanned(playerID, playerName) {
+			return ErrPlayerBanned
+		}
+		
+		// 檢查房間狀態
+		if room.Status != models.RoomStatusWaiting {
+			return fmt.Errorf("遊戲已開始，無法加入")
+		}
+		
+		// 檢查房間人數限制
+		if len(room.Players) >= s.settings.MaxPlayersPerRoom {
+			return fmt.Errorf("房間已滿")
+		}
+		
+		// 檢查玩家名稱是否重複
+		for _, existing := range room.Players {
+			if existing.Name == playerName {
+				return fmt.Errorf("玩家名稱已存在")
+			}
+		}
+		
+		// 添加玩家到房間
+		playerCopy := *player
+		room.AddPlayer(&playerCopy)
+		
+		// 隊伍模式：自動分到人數最少的隊伍，主持人之後可以再調整
+		if room.IsTeamMode() {
+			assignUnassignedPlayers(room)
+			player.Team = playerCopy.Team
+		}
+		return nil
+	})
 	if err != nil {
-		return nil, fmt.Errorf("更新房間資料失敗: %w", err)
+		return nil, err
 	}
 	
-	// 存儲玩家資料（如果有 Redis 客戶端）
-	if s.redisClient != nil {
-		playerData, _ := json.Marshal(player)
-		err = s.redisClient.Set(ctx, s.keys.PlayerKey(playerID), playerData, database.PlayerExpiration).Err()
-		if err != nil {
-			return nil, fmt.Errorf("存儲玩家資料失敗: %w", err)
-		}
+	// 存儲玩家資料
+	if err := s.store.SavePlayer(player); err != nil {
+		return nil, err
 	}
 	
+	s.roomLogs.Record(roomID, models.RoomEventPlayerJoined, playerName, map[string]interface{}{
+		"playerId":     playerID,
+		"totalPlayers": room.GetPlayerCount(),
+	})
+	
 	return player, nil
 }
 
+// BanPlayer 禁止玩家以同一名稱或工作階段再加入房間
+func (s *RoomService) BanPlayer(roomID, playerID, playerName string) (*models.Room, error) {
+	return s.MutateRoom(roomID, func(room *models.Room) error {
+		if room.IsBanned(playerID, playerName) {
+			return nil
+		}
+		room.Bans = append(room.Bans, models.RoomBan{
+			PlayerID:   playerID,
+			PlayerName: playerName,
+			BannedAt:   time.Now(),
+		})
+		return nil
+	})
+}
+
 // RemovePlayer 從房間移除玩家
-func (s *RoomService) RemovePlayer(roomID, playerID string) error {
-	ctx := context.Background()
-	
-	// 獲取房間
-	room, err := s.GetRoom(roomID)
+// onRemoved 會在同一次原子更新中執行（可為 nil），讓呼叫端一併調整遊戲狀態；
+// 房間因此沒有玩家時會刪除房間並回傳 nil
+func (s *RoomService) RemovePlayer(roomID, playerID string, onRemoved func(room *models.Room)) (*models.Room, error) {
+	playerName := ""
+	room, err := s.MutateRoom(roomID, func(room *models.Room) error {
+		if player, exists := room.GetPlayer(playerID); exists {
+			playerName = player.Name
+		}
+		room.RemovePlayer(playerID)
+		if onRemoved != nil {
+			onRemoved(room)
+		}
+		return nil
+	})
 	if err != nil {
-		return err
+		return nil, err
 	}
 	
-	// 移除玩家
-	room.RemovePlayer(playerID)
+	if playerName != "" {
+		s.roomLogs.Record(roomID, models.RoomEventPlayerLeft, playerName, map[string]interface{}{
+			"playerId":         playerID,
+			"remainingPlayers": room.GetPlayerCount(),
+		})
+	}
 	
 	// 如果房間沒有玩家了，刪除房間
 	if len(room.Players) == 0 {
-		return s.DeleteRoom(roomID)
+		return nil, s.DeleteRoom(roomID)
 	}
 	
-	// 更新房間資料
-	err = s.updateRoom(room)
-	if err != nil {
-		return fmt.Errorf("更新房間資料失敗: %w", err)
+	// 刪除玩家資料
+	if err := s.store.DeletePlayers(playerID); err != nil {
+		return nil, err
 	}
 	
-	// 刪除玩家資料（如果有 Redis 客戶端）
-	if s.redisClient != nil {
-		err = s.redisClient.Del(ctx, s.keys.PlayerKey(playerID)).Err()
-		if err != nil {
-			return fmt.Errorf("刪除玩家資料失敗: %w", err)
+	return room, nil
+}
+
+// SetPlayerConnected 更新玩家連線狀態（斷線等待重連或重連成功）
+func (s *RoomService) SetPlayerConnected(roomID, playerID string, connected bool) (*models.Room, error) {
+	return s.MutateRoom(roomID, func(room *models.Room) error {
+		if player, exists := room.GetPlayer(playerID); exists {
+			player.IsConnected = connected
+			player.LastActivity = time.Now()
 		}
-	}
-	
-	return nil
+		return nil
+	})
+}
+
+// OnRoomDeleted 註冊房間刪除時的回呼
+func (s *RoomService) OnRoomDeleted(hook func(roomID string)) {
+	s.roomDeletedHooks = append(s.roomDeletedHooks, hook)
 }
 
 // DeleteRoom 刪除房間
 func (s *RoomService) DeleteRoom(roomID string) error {
-	if s.redisClient != nil {
-		ctx := context.Background()
-		
-		// 從 Redis 刪除房間資料
-		err := s.redisClient.Del(ctx, s.keys.RoomKey(roomID)).Err()
-		if err != nil {
-			return fmt.Errorf("刪除房間資料失敗: %w", err)
-		}
-		
-		// 從活躍房間列表移除
-		err = s.redisClient.SRem(ctx, database.ActiveRoomsKey, roomID).Err()
-		if err != nil {
-			return fmt.Errorf("從活躍房間列表移除失敗: %w", err)
-		}
-	} else {
-		// 測試模式：從記憶體刪除房間
-		s.memoryMutex.Lock()
-		delete(s.memoryRooms, roomID)
-		s.memoryMutex.Unlock()
+	if err := s.store.DeleteRoom(roomID); err != nil {
+		return err
+	}
+	
+	// 通知房間已刪除
+	for _, hook := range s.roomDeletedHooks {
+		hook(roomID)
 	}
 	
 	return nil
 }
 
-// updateRoom 更新房間資料
-func (s *RoomService) updateRoom(room *models.Room) error {
-	if s.redisClient != nil {
-		ctx := context.Background()
-		
-		roomData, err := json.Marshal(room)
-		if err != nil {
-			return fmt.Errorf("序列化房間資料失敗: %w", err)
-		}
+// storeNewRoom 為房間生成唯一ID並存儲，ID 已被使用時換一個重試
+func (s *RoomService) storeNewRoom(room *models.Room) error {
+	for attempt := 0; attempt < maxRoomIDAttempts; attempt++ {
+		room.ID = generateRoomID(s.settings.RoomIDLength)
 		
-		err = s.redisClient.Set(ctx, s.keys.RoomKey(room.ID), roomData, database.RoomExpiration).Err()
-		if err != nil {
-			return fmt.Errorf("更新房間資料失敗: %w", err)
+		err := s.store.CreateRoom(room)
+		if errors.Is(err, ErrRoomExists) {
+			continue
 		}
-	} else {
-		// 測試模式：更新記憶體中的房間
-		s.memoryMutex.Lock()
-		s.memoryRooms[room.ID] = room
-		s.memoryMutex.Unlock()
+		return err
 	}
-	
-	return nil
+	return fmt.Errorf("無法產生未使用的房間ID")
 }
 
-// generateRoomID 生成房間ID
-func (s *RoomService) generateRoomID() string {
+// generateRoomID 生成指定長度的房間ID
+func generateRoomID(length int) string {
 	const charset = "ABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
-	const length = 6
 	
-	rand.Seed(time.Now().UnixNano())
-	
-	for {
-		roomID := make([]byte, length)
-		for i := range roomID {
-			roomID[i] = charset[rand.Intn(len(charset))]
-		}
-		
-		// 如果沒有 Redis 客戶端（測試模式），檢查記憶體中是否存在
-		if s.redisClient == nil {
-			s.memoryMutex.RLock()
-			_, exists := s.memoryRooms[string(roomID)]
-			s.memoryMutex.RUnlock()
-			
-			if !exists {
-				return string(roomID)
-			}
-		} else {
-			// 檢查ID是否已存在
-			ctx := context.Background()
-			exists, _ := s.redisClient.Exists(ctx, s.keys.RoomKey(string(roomID))).Result()
-			if exists == 0 {
-				return string(roomID)
-			}
-		}
+	roomID := make([]byte, length)
+	for i := range roomID {
+		roomID[i] = charset[rand.Intn(len(charset))]
 	}
+	return string(roomID)
 }
-
-// UpdateRoom 更新房間（公開方法）
-func (s *RoomService) UpdateRoom(room *models.Room) error {
-	return s.updateRoom(room)
-}
\ No newline at end of file
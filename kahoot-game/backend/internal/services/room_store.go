@@ -0,0 +1,69 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"kahoot-game/internal/models"
+)
+
+var (
+	// ErrRoomExists 房間ID已被使用
+	ErrRoomExists = errors.New("房間ID已存在")
+
+	// ErrRoomStoreUnavailable 房間存放位置暫時無法存取（例如 Redis 斷線）
+	ErrRoomStoreUnavailable = errors.New("房間資料暫時無法存取")
+)
+
+// RoomStore 房間與玩家資料的存放位置
+// 讀取與修改都以副本進行，呼叫端修改回傳的房間不會影響已存放的資料；
+// 存放位置無法連線時回傳的錯誤會包裝 ErrRoomStoreUnavailable
+type RoomStore interface {
+	// Name 目前使用的存放方式（StorageModeRedis 或 StorageModeMemory）
+	Name() string
+
+	// Shared 資料是否由多個節點共用；共用時房間訊息與計時器需透過 Redis 在節點間協調
+	Shared() bool
+
+	// CreateRoom 保存新房間並加入活躍房間列表，房間ID已存在時回傳 ErrRoomExists
+	CreateRoom(room *models.Room) error
+
+	// GetRoom 讀取房間，不存在時回傳 ErrRoomNotFound
+	GetRoom(roomID string) (*models.Room, error)
+
+	// MutateRoom 以原子方式讀取、修改並寫回房間，房間不存在時回傳 ErrRoomNotFound
+	// fn 可能因並行修改而被呼叫多次：fn 只能修改傳入的房間，不可有其他副作用；
+	// fn 回傳錯誤時放棄本次修改並原樣回傳該錯誤
+	MutateRoom(roomID string, fn func(room *models.Room) error) (*models.Room, error)
+
+	// DeleteRoom 刪除房間並從活躍房間列表移除
+	DeleteRoom(roomID string) error
+
+	// ListRooms 讀取所有活躍房間，並回傳順便從活躍房間列表移除的過期房間數
+	ListRooms() ([]*models.Room, int, error)
+
+	// SavePlayer 保存玩家資料（以玩家ID查詢所在房間）
+	SavePlayer(player *models.Player) error
+
+	// DeletePlayers 刪除玩家資料
+	DeletePlayers(playerIDs ...string) error
+
+	// CleanupPlayers 刪除 stale 判斷為過期的玩家資料，回傳刪除數量
+	CleanupPlayers(stale func(player *models.Player) (bool, error)) (int, error)
+}
+
+// cloneRoom 深層複製房間（與 Redis 相同經過 JSON 序列化）
+func cloneRoom(room *models.Room) (*models.Room, error) {
+	roomData, err := json.Marshal(room)
+	if err != nil {
+		return nil, fmt.Errorf("序列化房間資料失敗: %w", err)
+	}
+
+	var clone models.Room
+	if err := json.Unmarshal(roomData, &clone); err != nil {
+		return nil, fmt.Errorf("反序列化房間資料失敗: %w", err)
+	}
+
+	return &clone, nil
+}
@@ -0,0 +1,189 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"kahoot-game/internal/logging"
+	"kahoot-game/internal/models"
+)
+
+// 切換到記憶體後檢查 Redis 是否恢復的等待上限
+const fallbackProbeTimeout = time.Second
+
+// FallbackRoomStore Redis 無法存取時自動改用記憶體存放
+// 切換後新建立的房間只存在本節點；Redis 中原有的房間在切換期間視為不存在。
+// 每隔 retryInterval 檢查一次 Redis，恢復且記憶體中已沒有房間時才切回 Redis，避免進行中的房間消失
+type FallbackRoomStore struct {
+	primary       *RedisRoomStore
+	memory        *MemoryRoomStore
+	retryInterval time.Duration
+	logger        *slog.Logger
+
+	mutex      sync.Mutex
+	failedOver bool
+	lastProbe  time.Time
+	probing    bool
+}
+
+// NewFallbackRoomStore 創建可自動切換到記憶體的房間存放
+func NewFallbackRoomStore(primary *RedisRoomStore, retryInterval time.Duration, logger *slog.Logger) *FallbackRoomStore {
+	return &FallbackRoomStore{
+		primary:       primary,
+		memory:        NewMemoryRoomStore(),
+		retryInterval: retryInterval,
+		logger:        logger,
+	}
+}
+
+// Name 目前使用的存放方式
+func (s *FallbackRoomStore) Name() string {
+	return s.active().Name()
+}
+
+// Shared 切換到記憶體期間房間只存在本節點
+func (s *FallbackRoomStore) Shared() bool {
+	return s.active().Shared()
+}
+
+// CreateRoom 保存新房間
+func (s *FallbackRoomStore) CreateRoom(room *models.Room) error {
+	return s.do(func(store RoomStore) error {
+		return store.CreateRoom(room)
+	})
+}
+
+// GetRoom 讀取房間
+func (s *FallbackRoomStore) GetRoom(roomID string) (*models.Room, error) {
+	var room *models.Room
+	err := s.do(func(store RoomStore) (err error) {
+		room, err = store.GetRoom(roomID)
+		return err
+	})
+	return room, err
+}
+
+// MutateRoom 以原子方式修改房間
+func (s *FallbackRoomStore) MutateRoom(roomID string, fn func(room *models.Room) error) (*models.Room, error) {
+	var room *models.Room
+	err := s.do(func(store RoomStore) (err error) {
+		room, err = store.MutateRoom(roomID, fn)
+		return err
+	})
+	return room, err
+}
+
+// DeleteRoom 刪除房間
+func (s *FallbackRoomStore) DeleteRoom(roomID string) error {
+	return s.do(func(store RoomStore) error {
+		return store.DeleteRoom(roomID)
+	})
+}
+
+// ListRooms 讀取所有活躍房間
+func (s *FallbackRoomStore) ListRooms() ([]*models.Room, int, error) {
+	var rooms []*models.Room
+	var pruned int
+	err := s.do(func(store RoomStore) (err error) {
+		rooms, pruned, err = store.ListRooms()
+		return err
+	})
+	return rooms, pruned, err
+}
+
+// SavePlayer 保存玩家資料
+func (s *FallbackRoomStore) SavePlayer(player *models.Player) error {
+	return s.do(func(store RoomStore) error {
+		return store.SavePlayer(player)
+	})
+}
+
+// DeletePlayers 刪除玩家資料
+func (s *FallbackRoomStore) DeletePlayers(playerIDs ...string) error {
+	return s.do(func(store RoomStore) error {
+		return store.DeletePlayers(playerIDs...)
+	})
+}
+
+// CleanupPlayers 刪除過期的玩家資料
+func (s *FallbackRoomStore) CleanupPlayers(stale func(player *models.Player) (bool, error)) (int, error) {
+	var deleted int
+	err := s.do(func(store RoomStore) (err error) {
+		deleted, err = store.CleanupPlayers(stale)
+		return err
+	})
+	return deleted, err
+}
+
+// do 在目前的存放位置執行操作，Redis 無法存取時切換到記憶體並重新執行一次
+func (s *FallbackRoomStore) do(op func(store RoomStore) error) error {
+	store := s.current()
+
+	err := op(store)
+	if store != RoomStore(s.primary) || !errors.Is(err, ErrRoomStoreUnavailable) {
+		return err
+	}
+
+	s.failOver(err)
+	return op(s.memory)
+}
+
+// active 目前的存放位置（不檢查 Redis 是否恢復）
+func (s *FallbackRoomStore) active() RoomStore {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.failedOver {
+		return s.memory
+	}
+	return s.primary
+}
+
+// current 目前的存放位置，已切換到記憶體時順便檢查是否可以切回 Redis
+func (s *FallbackRoomStore) current() RoomStore {
+	s.mutex.Lock()
+	if !s.failedOver {
+		s.mutex.Unlock()
+		return s.primary
+	}
+	if s.probing || time.Since(s.lastProbe) < s.retryInterval || s.memory.RoomCount() > 0 {
+		s.mutex.Unlock()
+		return s.memory
+	}
+	s.probing = true
+	s.lastProbe = time.Now()
+	s.mutex.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), fallbackProbeTimeout)
+	err := s.primary.Ping(ctx)
+	cancel()
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.probing = false
+
+	// 檢查期間可能已有新房間建立在記憶體中
+	if err != nil || s.memory.RoomCount() > 0 {
+		return s.memory
+	}
+
+	s.failedOver = false
+	s.logger.Info("Redis 已恢復，房間資料改回存放在 Redis")
+	return s.primary
+}
+
+// failOver 切換到記憶體存放
+func (s *FallbackRoomStore) failOver(cause error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.failedOver {
+		return
+	}
+	s.failedOver = true
+	s.lastProbe = time.Now()
+	s.logger.Warn("Redis 無法存取，房間資料改存放在記憶體", "retryInterval", s.retryInterval, logging.Err(cause))
+}
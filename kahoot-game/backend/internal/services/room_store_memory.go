@@ -0,0 +1,166 @@
+package services
+
+import (
+	"sync"
+
+	"kahoot-game/internal/models"
+)
+
+// MemoryRoomStore 將房間與玩家資料存放在本機記憶體
+// 不需要任何外部服務，適合本機開發與單一實例部署；重新啟動後資料會消失
+type MemoryRoomStore struct {
+	rooms   map[string]*models.Room
+	players map[string]*models.Player
+	mutex   sync.RWMutex
+}
+
+// NewMemoryRoomStore 創建記憶體房間存放
+func NewMemoryRoomStore() *MemoryRoomStore {
+	return &MemoryRoomStore{
+		rooms:   make(map[string]*models.Room),
+		players: make(map[string]*models.Player),
+	}
+}
+
+// Name 存放方式
+func (s *MemoryRoomStore) Name() string {
+	return StorageModeMemory
+}
+
+// Shared 記憶體中的房間只有本節點看得到
+func (s *MemoryRoomStore) Shared() bool {
+	return false
+}
+
+// CreateRoom 保存新房間
+func (s *MemoryRoomStore) CreateRoom(room *models.Room) error {
+	clone, err := cloneRoom(room)
+	if err != nil {
+		return err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if _, exists := s.rooms[room.ID]; exists {
+		return ErrRoomExists
+	}
+	s.rooms[room.ID] = clone
+	return nil
+}
+
+// GetRoom 讀取房間副本
+func (s *MemoryRoomStore) GetRoom(roomID string) (*models.Room, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	room, exists := s.rooms[roomID]
+	if !exists {
+		return nil, ErrRoomNotFound
+	}
+
+	return cloneRoom(room)
+}
+
+// MutateRoom 在鎖內修改房間副本，成功後才替換
+func (s *MemoryRoomStore) MutateRoom(roomID string, fn func(room *models.Room) error) (*models.Room, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	stored, exists := s.rooms[roomID]
+	if !exists {
+		return nil, ErrRoomNotFound
+	}
+
+	room, err := cloneRoom(stored)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := fn(room); err != nil {
+		return nil, err
+	}
+
+	s.rooms[roomID] = room
+	return cloneRoom(room)
+}
+
+// DeleteRoom 刪除房間
+func (s *MemoryRoomStore) DeleteRoom(roomID string) error {
+	s.mutex.Lock()
+	delete(s.rooms, roomID)
+	s.mutex.Unlock()
+	return nil
+}
+
+// ListRooms 讀取所有房間副本（記憶體中沒有過期的房間）
+func (s *MemoryRoomStore) ListRooms() ([]*models.Room, int, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	rooms := make([]*models.Room, 0, len(s.rooms))
+	for _, room := range s.rooms {
+		clone, err := cloneRoom(room)
+		if err != nil {
+			return nil, 0, err
+		}
+		rooms = append(rooms, clone)
+	}
+	return rooms, 0, nil
+}
+
+// RoomCount 目前存放的房間數
+func (s *MemoryRoomStore) RoomCount() int {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return len(s.rooms)
+}
+
+// SavePlayer 保存玩家資料
+func (s *MemoryRoomStore) SavePlayer(player *models.Player) error {
+	playerCopy := *player
+
+	s.mutex.Lock()
+	s.players[player.ID] = &playerCopy
+	s.mutex.Unlock()
+	return nil
+}
+
+// DeletePlayers 刪除玩家資料
+func (s *MemoryRoomStore) DeletePlayers(playerIDs ...string) error {
+	s.mutex.Lock()
+	for _, playerID := range playerIDs {
+		delete(s.players, playerID)
+	}
+	s.mutex.Unlock()
+	return nil
+}
+
+// CleanupPlayers 刪除 stale 判斷為過期的玩家資料
+// 先複製玩家列表再逐一判斷，stale 可以再讀取房間而不會卡在鎖上
+func (s *MemoryRoomStore) CleanupPlayers(stale func(player *models.Player) (bool, error)) (int, error) {
+	s.mutex.RLock()
+	players := make([]models.Player, 0, len(s.players))
+	for _, player := range s.players {
+		players = append(players, *player)
+	}
+	s.mutex.RUnlock()
+
+	deleted := 0
+	for i := range players {
+		remove, err := stale(&players[i])
+		if err != nil {
+			return deleted, err
+		}
+		if !remove {
+			continue
+		}
+
+		s.mutex.Lock()
+		delete(s.players, players[i].ID)
+		s.mutex.Unlock()
+		deleted++
+	}
+
+	return deleted, nil
+}
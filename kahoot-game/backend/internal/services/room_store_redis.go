@@ -0,0 +1,321 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand"
+	"net"
+	"strings"
+	"time"
+
+	"kahoot-game/internal/database"
+	"kahoot-game/internal/logging"
+	"kahoot-game/internal/metrics"
+	"kahoot-game/internal/models"
+
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	// 房間更新發生樂觀鎖衝突時的最大重試次數
+	maxRoomMutationRetries = 30
+
+	// 衝突重試前的隨機等待上限，避免同時答題的玩家不斷互相衝突
+	roomMutationBackoff = 5 * time.Millisecond
+)
+
+// RedisRoomStore 將房間與玩家資料存放在 Redis，多個後端實例可共用
+type RedisRoomStore struct {
+	redisClient *redis.Client
+	keys        *database.RedisKeys
+	logger      *slog.Logger
+}
+
+// NewRedisRoomStore 創建 Redis 房間存放
+func NewRedisRoomStore(redisClient *redis.Client, logger *slog.Logger) *RedisRoomStore {
+	return &RedisRoomStore{
+		redisClient: redisClient,
+		keys:        database.NewRedisKeys(),
+		logger:      logger,
+	}
+}
+
+// Name 存放方式
+func (s *RedisRoomStore) Name() string {
+	return StorageModeRedis
+}
+
+// Shared Redis 中的房間由所有節點共用
+func (s *RedisRoomStore) Shared() bool {
+	return true
+}
+
+// Ping 檢查 Redis 是否可以連線
+func (s *RedisRoomStore) Ping(ctx context.Context) error {
+	if err := s.redisClient.Ping(ctx).Err(); err != nil {
+		return unavailable("Redis 連線失敗", err)
+	}
+	return nil
+}
+
+// CreateRoom 保存新房間並加入活躍房間列表
+func (s *RedisRoomStore) CreateRoom(room *models.Room) error {
+	ctx := context.Background()
+
+	roomData, err := json.Marshal(room)
+	if err != nil {
+		return fmt.Errorf("序列化房間資料失敗: %w", err)
+	}
+
+	created, err := s.redisClient.SetNX(ctx, s.keys.RoomKey(room.ID), roomData, database.RoomExpiration).Result()
+	if err != nil {
+		return unavailable("存儲房間資料失敗", err)
+	}
+	if !created {
+		return ErrRoomExists
+	}
+
+	// 添加到活躍房間列表
+	if err := s.redisClient.SAdd(ctx, database.ActiveRoomsKey, room.ID).Err(); err != nil {
+		return unavailable("添加到活躍房間列表失敗", err)
+	}
+
+	return nil
+}
+
+// GetRoom 讀取房間
+func (s *RedisRoomStore) GetRoom(roomID string) (*models.Room, error) {
+	defer metrics.ObserveRedis(metrics.OperationGetRoom, time.Now())
+
+	roomData, err := s.redisClient.Get(context.Background(), s.keys.RoomKey(roomID)).Bytes()
+	if err == redis.Nil {
+		return nil, ErrRoomNotFound
+	} else if err != nil {
+		return nil, unavailable("獲取房間資料失敗", err)
+	}
+
+	var room models.Room
+	if err := json.Unmarshal(roomData, &room); err != nil {
+		return nil, fmt.Errorf("反序列化房間資料失敗: %w", err)
+	}
+
+	return &room, nil
+}
+
+// MutateRoom 使用 WATCH/MULTI 樂觀鎖修改房間
+// 寫入前房間被其他連線修改時會重新讀取並重試
+func (s *RedisRoomStore) MutateRoom(roomID string, fn func(room *models.Room) error) (*models.Room, error) {
+	defer metrics.ObserveRedis(metrics.OperationUpdateRoom, time.Now())
+
+	ctx := context.Background()
+	key := s.keys.RoomKey(roomID)
+
+	var result *models.Room
+	txf := func(tx *redis.Tx) error {
+		roomData, err := tx.Get(ctx, key).Bytes()
+		if err == redis.Nil {
+			return ErrRoomNotFound
+		} else if err != nil {
+			return unavailable("獲取房間資料失敗", err)
+		}
+
+		var room models.Room
+		if err := json.Unmarshal(roomData, &room); err != nil {
+			return fmt.Errorf("反序列化房間資料失敗: %w", err)
+		}
+
+		if err := fn(&room); err != nil {
+			return err
+		}
+
+		newData, err := json.Marshal(&room)
+		if err != nil {
+			return fmt.Errorf("序列化房間資料失敗: %w", err)
+		}
+
+		// 只有在 WATCH 之後房間未被修改時才會寫入
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Set(ctx, key, newData, database.RoomExpiration)
+			return nil
+		})
+		if errors.Is(err, redis.TxFailedErr) {
+			return err
+		} else if err != nil {
+			return unavailable("存儲房間資料失敗", err)
+		}
+
+		result = &room
+		return nil
+	}
+
+	for attempt := 1; attempt <= maxRoomMutationRetries; attempt++ {
+		err := s.redisClient.Watch(ctx, txf, key)
+		if errors.Is(err, redis.TxFailedErr) {
+			s.logger.Debug("房間更新衝突，重試", logging.KeyRoomID, roomID, "attempt", attempt)
+			time.Sleep(time.Duration(rand.Int63n(int64(roomMutationBackoff))))
+			continue
+		}
+		if err != nil {
+			// WATCH 本身失敗時錯誤未經包裝，fn 回傳的錯誤則原樣回傳
+			if !errors.Is(err, ErrRoomStoreUnavailable) && isRedisConnectionError(err) {
+				return nil, unavailable("更新房間資料失敗", err)
+			}
+			return nil, err
+		}
+		return result, nil
+	}
+
+	return nil, ErrRoomConflict
+}
+
+// DeleteRoom 刪除房間並從活躍房間列表移除
+func (s *RedisRoomStore) DeleteRoom(roomID string) error {
+	ctx := context.Background()
+
+	if err := s.redisClient.Del(ctx, s.keys.RoomKey(roomID)).Err(); err != nil {
+		return unavailable("刪除房間資料失敗", err)
+	}
+
+	if err := s.redisClient.SRem(ctx, database.ActiveRoomsKey, roomID).Err(); err != nil {
+		return unavailable("從活躍房間列表移除失敗", err)
+	}
+
+	return nil
+}
+
+// ListRooms 讀取所有活躍房間
+// 已過期的房間會順便從活躍房間列表移除，並回傳移除的數量
+func (s *RedisRoomStore) ListRooms() ([]*models.Room, int, error) {
+	ctx := context.Background()
+
+	roomIDs, err := s.redisClient.SMembers(ctx, database.ActiveRoomsKey).Result()
+	if err != nil {
+		return nil, 0, unavailable("獲取活躍房間列表失敗", err)
+	}
+	if len(roomIDs) == 0 {
+		return nil, 0, nil
+	}
+
+	keys := make([]string, len(roomIDs))
+	for i, roomID := range roomIDs {
+		keys[i] = s.keys.RoomKey(roomID)
+	}
+
+	values, err := s.redisClient.MGet(ctx, keys...).Result()
+	if err != nil && err != redis.Nil {
+		return nil, 0, unavailable("獲取房間資料失敗", err)
+	}
+
+	rooms := make([]*models.Room, 0, len(values))
+	var expired []interface{}
+	for i, value := range values {
+		roomData, ok := value.(string)
+		if !ok {
+			expired = append(expired, roomIDs[i])
+			continue
+		}
+
+		var room models.Room
+		if err := json.Unmarshal([]byte(roomData), &room); err != nil {
+			s.logger.Warn("房間資料無法解析", logging.KeyRoomID, roomIDs[i], logging.Err(err))
+			continue
+		}
+		rooms = append(rooms, &room)
+	}
+
+	if len(expired) > 0 {
+		if err := s.redisClient.SRem(ctx, database.ActiveRoomsKey, expired...).Err(); err != nil {
+			s.logger.Warn("移除過期的活躍房間失敗", logging.Err(err))
+			return rooms, 0, nil
+		}
+	}
+
+	return rooms, len(expired), nil
+}
+
+// SavePlayer 保存玩家資料
+func (s *RedisRoomStore) SavePlayer(player *models.Player) error {
+	playerData, err := json.Marshal(player)
+	if err != nil {
+		return fmt.Errorf("序列化玩家資料失敗: %w", err)
+	}
+
+	err = s.redisClient.Set(context.Background(), s.keys.PlayerKey(player.ID), playerData, database.PlayerExpiration).Err()
+	if err != nil {
+		return unavailable("存儲玩家資料失敗", err)
+	}
+	return nil
+}
+
+// DeletePlayers 刪除玩家資料
+func (s *RedisRoomStore) DeletePlayers(playerIDs ...string) error {
+	if len(playerIDs) == 0 {
+		return nil
+	}
+
+	keys := make([]string, len(playerIDs))
+	for i, playerID := range playerIDs {
+		keys[i] = s.keys.PlayerKey(playerID)
+	}
+
+	if err := s.redisClient.Del(context.Background(), keys...).Err(); err != nil {
+		return unavailable("刪除玩家資料失敗", err)
+	}
+	return nil
+}
+
+// CleanupPlayers 掃描所有玩家資料，刪除 stale 判斷為過期的項目
+func (s *RedisRoomStore) CleanupPlayers(stale func(player *models.Player) (bool, error)) (int, error) {
+	ctx := context.Background()
+	deleted := 0
+
+	iter := s.redisClient.Scan(ctx, 0, database.PlayerPrefix+"*", 100).Iterator()
+	for iter.Next(ctx) {
+		key := iter.Val()
+
+		playerData, err := s.redisClient.Get(ctx, key).Bytes()
+		if err != nil {
+			continue
+		}
+
+		var player models.Player
+		if err := json.Unmarshal(playerData, &player); err != nil {
+			s.logger.Warn("玩家資料無法解析", "key", key, logging.Err(err))
+			continue
+		}
+		// 以鍵值為準，避免資料內的 ID 與鍵值不一致
+		player.ID = strings.TrimPrefix(key, database.PlayerPrefix)
+
+		remove, err := stale(&player)
+		if err != nil {
+			return deleted, err
+		}
+		if !remove {
+			continue
+		}
+
+		if err := s.redisClient.Del(ctx, key).Err(); err != nil {
+			return deleted, unavailable("刪除玩家資料失敗", err)
+		}
+		deleted++
+	}
+	if err := iter.Err(); err != nil {
+		return deleted, unavailable("掃描玩家資料失敗", err)
+	}
+
+	return deleted, nil
+}
+
+// unavailable 包裝 Redis 指令錯誤，讓呼叫端可以用 ErrRoomStoreUnavailable 判斷
+func unavailable(action string, err error) error {
+	return fmt.Errorf("%s: %w: %w", action, ErrRoomStoreUnavailable, err)
+}
+
+// isRedisConnectionError 是否為連線層級的錯誤
+func isRedisConnectionError(err error) bool {
+	var netErr net.Error
+	return errors.Is(err, redis.ErrClosed) || errors.Is(err, context.DeadlineExceeded) || errors.As(err, &netErr)
+}
@@ -0,0 +1,308 @@
+package services
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"sort"
+	"testing"
+	"time"
+
+	"kahoot-game/internal/database"
+	"kahoot-game/internal/models"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+)
+
+// 測試用的 Redis 恢復檢查間隔
+const testRetryInterval = 50 * time.Millisecond
+
+// newTestRedisRoomStore 使用 miniredis 的 Redis 房間存放
+func newTestRedisRoomStore(t *testing.T) (*RedisRoomStore, *miniredis.Miniredis) {
+	t.Helper()
+
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr(), MaxRetries: -1})
+	t.Cleanup(func() { client.Close() })
+
+	return NewRedisRoomStore(client, slog.New(slog.NewTextHandler(io.Discard, nil))), mr
+}
+
+func newStoreTestRoom(roomID string) *models.Room {
+	return &models.Room{
+		ID:       roomID,
+		HostName: "主持人",
+		Status:   models.RoomStatusWaiting,
+		Players:  make(map[string]*models.Player),
+	}
+}
+
+func roomIDs(rooms []*models.Room) []string {
+	ids := make([]string, len(rooms))
+	for i, room := range rooms {
+		ids[i] = room.ID
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func TestRoomStore(t *testing.T) {
+	stores := []struct {
+		name     string
+		newStore func(t *testing.T) RoomStore
+	}{
+		{name: StorageModeMemory, newStore: func(t *testing.T) RoomStore { return NewMemoryRoomStore() }},
+		{name: StorageModeRedis, newStore: func(t *testing.T) RoomStore {
+			store, _ := newTestRedisRoomStore(t)
+			return store
+		}},
+		{name: "fallback", newStore: func(t *testing.T) RoomStore {
+			store, _ := newTestRedisRoomStore(t)
+			return NewFallbackRoomStore(store, testRetryInterval, store.logger)
+		}},
+	}
+
+	errBoom := errors.New("boom")
+
+	tests := []struct {
+		name string
+		run  func(t *testing.T, store RoomStore)
+	}{
+		{
+			name: "建立與讀取房間",
+			run: func(t *testing.T, store RoomStore) {
+				if err := store.CreateRoom(newStoreTestRoom("AAAAAA")); err != nil {
+					t.Fatalf("CreateRoom() error = %v", err)
+				}
+				room, err := store.GetRoom("AAAAAA")
+				if err != nil {
+					t.Fatalf("GetRoom() error = %v", err)
+				}
+				if room.HostName != "主持人" {
+					t.Errorf("HostName = %q", room.HostName)
+				}
+			},
+		},
+		{
+			name: "房間ID重複",
+			run: func(t *testing.T, store RoomStore) {
+				store.CreateRoom(newStoreTestRoom("AAAAAA"))
+				if err := store.CreateRoom(newStoreTestRoom("AAAAAA")); !errors.Is(err, ErrRoomExists) {
+					t.Errorf("CreateRoom() error = %v, 預期 %v", err, ErrRoomExists)
+				}
+			},
+		},
+		{
+			name: "房間不存在",
+			run: func(t *testing.T, store RoomStore) {
+				if _, err := store.GetRoom("NOROOM"); !errors.Is(err, ErrRoomNotFound) {
+					t.Errorf("GetRoom() error = %v, 預期 %v", err, ErrRoomNotFound)
+				}
+				_, err := store.MutateRoom("NOROOM", func(room *models.Room) error { return nil })
+				if !errors.Is(err, ErrRoomNotFound) {
+					t.Errorf("MutateRoom() error = %v, 預期 %v", err, ErrRoomNotFound)
+				}
+			},
+		},
+		{
+			name: "修改讀取的副本不影響存放的房間",
+			run: func(t *testing.T, store RoomStore) {
+				store.CreateRoom(newStoreTestRoom("AAAAAA"))
+				room, _ := store.GetRoom("AAAAAA")
+				room.HostName = "改過的"
+
+				stored, _ := store.GetRoom("AAAAAA")
+				if stored.HostName != "主持人" {
+					t.Errorf("HostName = %q, 預期未被修改", stored.HostName)
+				}
+			},
+		},
+		{
+			name: "修改房間",
+			run: func(t *testing.T, store RoomStore) {
+				store.CreateRoom(newStoreTestRoom("AAAAAA"))
+				updated, err := store.MutateRoom("AAAAAA", func(room *models.Room) error {
+					room.Status = models.RoomStatusStarting
+					return nil
+				})
+				if err != nil {
+					t.Fatalf("MutateRoom() error = %v", err)
+				}
+				stored, _ := store.GetRoom("AAAAAA")
+				if updated.Status != models.RoomStatusStarting || stored.Status != models.RoomStatusStarting {
+					t.Errorf("Status = %s / %s, 預期 %s", updated.Status, stored.Status, models.RoomStatusStarting)
+				}
+			},
+		},
+		{
+			name: "修改失敗時不寫入",
+			run: func(t *testing.T, store RoomStore) {
+				store.CreateRoom(newStoreTestRoom("AAAAAA"))
+				_, err := store.MutateRoom("AAAAAA", func(room *models.Room) error {
+					room.Status = models.RoomStatusStarting
+					return errBoom
+				})
+				if !errors.Is(err, errBoom) {
+					t.Errorf("MutateRoom() error = %v, 預期 %v", err, errBoom)
+				}
+				stored, _ := store.GetRoom("AAAAAA")
+				if stored.Status != models.RoomStatusWaiting {
+					t.Errorf("Status = %s, 預期 %s", stored.Status, models.RoomStatusWaiting)
+				}
+			},
+		},
+		{
+			name: "列出與刪除房間",
+			run: func(t *testing.T, store RoomStore) {
+				store.CreateRoom(newStoreTestRoom("AAAAAA"))
+				store.CreateRoom(newStoreTestRoom("BBBBBB"))
+				if err := store.DeleteRoom("AAAAAA"); err != nil {
+					t.Fatalf("DeleteRoom() error = %v", err)
+				}
+
+				rooms, _, err := store.ListRooms()
+				if err != nil {
+					t.Fatalf("ListRooms() error = %v", err)
+				}
+				if ids := roomIDs(rooms); len(ids) != 1 || ids[0] != "BBBBBB" {
+					t.Errorf("ListRooms() = %v, 預期 [BBBBBB]", ids)
+				}
+				if _, err := store.GetRoom("AAAAAA"); !errors.Is(err, ErrRoomNotFound) {
+					t.Errorf("刪除後 GetRoom() error = %v, 預期 %v", err, ErrRoomNotFound)
+				}
+			},
+		},
+		{
+			name: "清除過期玩家",
+			run: func(t *testing.T, store RoomStore) {
+				for _, playerID := range []string{"p1", "p2", "p3"} {
+					if err := store.SavePlayer(&models.Player{ID: playerID, RoomID: "AAAAAA"}); err != nil {
+						t.Fatalf("SavePlayer() error = %v", err)
+					}
+				}
+				store.DeletePlayers("p3")
+
+				var seen []string
+				deleted, err := store.CleanupPlayers(func(player *models.Player) (bool, error) {
+					seen = append(seen, player.ID)
+					return player.ID == "p1", nil
+				})
+				if err != nil {
+					t.Fatalf("CleanupPlayers() error = %v", err)
+				}
+				sort.Strings(seen)
+				if deleted != 1 || len(seen) != 2 || seen[0] != "p1" || seen[1] != "p2" {
+					t.Errorf("CleanupPlayers() 刪除 %d 筆，檢查 %v，預期刪除 1 筆，檢查 [p1 p2]", deleted, seen)
+				}
+
+				seen = nil
+				store.CleanupPlayers(func(player *models.Player) (bool, error) {
+					seen = append(seen, player.ID)
+					return false, nil
+				})
+				if len(seen) != 1 || seen[0] != "p2" {
+					t.Errorf("清除後剩下 %v, 預期 [p2]", seen)
+				}
+			},
+		},
+	}
+
+	for _, st := range stores {
+		t.Run(st.name, func(t *testing.T) {
+			for _, tt := range tests {
+				t.Run(tt.name, func(t *testing.T) {
+					tt.run(t, st.newStore(t))
+				})
+			}
+		})
+	}
+}
+
+func TestRedisRoomStoreListRoomsPrunesExpired(t *testing.T) {
+	store, mr := newTestRedisRoomStore(t)
+	store.CreateRoom(newStoreTestRoom("AAAAAA"))
+	store.CreateRoom(newStoreTestRoom("BBBBBB"))
+
+	// 房間資料過期後仍留在活躍房間列表中
+	mr.Del(database.Keys.RoomKey("AAAAAA"))
+
+	rooms, pruned, err := store.ListRooms()
+	if err != nil {
+		t.Fatalf("ListRooms() error = %v", err)
+	}
+	if ids := roomIDs(rooms); pruned != 1 || len(ids) != 1 || ids[0] != "BBBBBB" {
+		t.Errorf("ListRooms() = %v, 移除 %d 筆，預期 [BBBBBB]、移除 1 筆", ids, pruned)
+	}
+	if members, _ := mr.Members(database.ActiveRoomsKey); len(members) != 1 {
+		t.Errorf("活躍房間列表 = %v, 預期只剩 BBBBBB", members)
+	}
+}
+
+func TestFallbackRoomStore(t *testing.T) {
+	tests := []struct {
+		name          string
+		redisRestored bool // Redis 是否恢復
+		keepMemory    bool // 記憶體中是否仍有房間
+		waitRetry     bool // 是否已超過檢查間隔
+		wantMode      string
+	}{
+		{name: "Redis 恢復且記憶體沒有房間時切回", redisRestored: true, waitRetry: true, wantMode: StorageModeRedis},
+		{name: "未到檢查時間時留在記憶體", redisRestored: true, wantMode: StorageModeMemory},
+		{name: "記憶體仍有房間時留在記憶體", redisRestored: true, keepMemory: true, waitRetry: true, wantMode: StorageModeMemory},
+		{name: "Redis 尚未恢復時留在記憶體", waitRetry: true, wantMode: StorageModeMemory},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			primary, mr := newTestRedisRoomStore(t)
+			store := NewFallbackRoomStore(primary, testRetryInterval, primary.logger)
+
+			if err := store.CreateRoom(newStoreTestRoom("REDIS1")); err != nil {
+				t.Fatalf("CreateRoom() error = %v", err)
+			}
+			if store.Name() != StorageModeRedis || !store.Shared() {
+				t.Fatalf("Redis 正常時存放方式 = %s", store.Name())
+			}
+
+			// Redis 斷線：新房間改存放在記憶體，Redis 中的房間暫時看不到
+			mr.Close()
+			if err := store.CreateRoom(newStoreTestRoom("LOCAL1")); err != nil {
+				t.Fatalf("Redis 斷線時 CreateRoom() error = %v", err)
+			}
+			if store.Name() != StorageModeMemory || store.Shared() {
+				t.Fatalf("Redis 斷線後存放方式 = %s", store.Name())
+			}
+			if _, err := store.GetRoom("LOCAL1"); err != nil {
+				t.Fatalf("讀取記憶體中的房間失敗: %v", err)
+			}
+			if _, err := store.GetRoom("REDIS1"); !errors.Is(err, ErrRoomNotFound) {
+				t.Fatalf("切換期間讀取 Redis 中的房間 error = %v, 預期 %v", err, ErrRoomNotFound)
+			}
+
+			if tt.redisRestored {
+				if err := mr.Restart(); err != nil {
+					t.Fatalf("重新啟動 miniredis 失敗: %v", err)
+				}
+			}
+			if !tt.keepMemory {
+				store.DeleteRoom("LOCAL1")
+			}
+			if tt.waitRetry {
+				time.Sleep(testRetryInterval)
+			}
+
+			// 任何操作都會觸發檢查
+			_, err := store.GetRoom("REDIS1")
+			if store.Name() != tt.wantMode {
+				t.Fatalf("存放方式 = %s, 預期 %s", store.Name(), tt.wantMode)
+			}
+			if tt.wantMode == StorageModeRedis && err != nil {
+				t.Errorf("切回 Redis 後讀取原有房間失敗: %v", err)
+			}
+			if tt.wantMode == StorageModeMemory && !errors.Is(err, ErrRoomNotFound) {
+				t.Errorf("留在記憶體時讀取 Redis 中的房間 error = %v, 預期 %v", err, ErrRoomNotFound)
+			}
+		})
+	}
+}
@@ -0,0 +1,247 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+
+	"kahoot-game/internal/models"
+)
+
+const (
+	// 隊伍數量限制
+	minTeams = 2
+	maxTeams = 8
+
+	// 隊伍名稱長度上限
+	maxTeamNameLength = 30
+)
+
+// ErrInvalidTeams 隊伍設定不合理
+var ErrInvalidTeams = errors.New("隊伍設定錯誤")
+
+// NormalizeTeams 整理並檢查隊伍名稱，沒有設定隊伍時回傳 nil
+func NormalizeTeams(teams []string) ([]string, error) {
+	if len(teams) == 0 {
+		return nil, nil
+	}
+
+	if len(teams) < minTeams || len(teams) > maxTeams {
+		return nil, fmt.Errorf("%w: 隊伍數量必須在 %d 到 %d 隊之間", ErrInvalidTeams, minTeams, maxTeams)
+	}
+
+	normalized := make([]string, 0, len(teams))
+	seen := make(map[string]bool, len(teams))
+	for _, team := range teams {
+		team = strings.TrimSpace(team)
+		if team == "" {
+			return nil, fmt.Errorf("%w: 隊伍名稱不能為空", ErrInvalidTeams)
+		}
+		if len([]rune(team)) > maxTeamNameLength {
+			return nil, fmt.Errorf("%w: 隊伍名稱不可超過 %d 個字", ErrInvalidTeams, maxTeamNameLength)
+		}
+		if seen[team] {
+			return nil, fmt.Errorf("%w: 隊伍名稱 %s 重複", ErrInvalidTeams, team)
+		}
+		seen[team] = true
+		normalized = append(normalized, team)
+	}
+
+	return normalized, nil
+}
+
+// smallestTeam 人數最少的隊伍，人數相同時取排在前面的隊伍
+func smallestTeam(room *models.Room) string {
+	best := ""
+	bestCount := 0
+	for _, roster := range room.GetTeamRosters() {
+		if best == "" || len(roster.Players) < bestCount {
+			best = roster.Team
+			bestCount = len(roster.Players)
+		}
+	}
+	return best
+}
+
+// assignUnassignedPlayers 將尚未分隊的玩家分到人數最少的隊伍
+func assignUnassignedPlayers(room *models.Room) {
+	if !room.IsTeamMode() {
+		return
+	}
+
+	// 依玩家ID處理，確保結果固定
+	playerIDs := make([]string, 0, len(room.Players))
+	for playerID, player := range room.Players {
+		if !room.HasTeam(player.Team) {
+			playerIDs = append(playerIDs, playerID)
+		}
+	}
+	sort.Strings(playerIDs)
+
+	for _, playerID := range playerIDs {
+		room.Players[playerID].Team = smallestTeam(room)
+	}
+}
+
+// balanceTeams 隨機重新分隊，各隊人數最多相差一人
+func balanceTeams(room *models.Room) {
+	players := room.GetPlayerList()
+	rand.Shuffle(len(players), func(i, j int) {
+		players[i], players[j] = players[j], players[i]
+	})
+
+	for i, player := range players {
+		player.Team = room.Teams[i%len(room.Teams)]
+	}
+}
+
+// selectNextTeamHost 隊伍模式選擇下一個主角
+// 輪到主角次數最少的隊伍優先，次數相同時從當前主角的下一隊開始，讓主角在各隊間輪流；
+// 同一隊的隊員依序輪流當主角
+func (s *GameService) selectNextTeamHost(room *models.Room, currentHost string) string {
+	rosters := room.GetTeamRosters()
+
+	start := rand.Intn(len(rosters))
+	if current, exists := room.Players[currentHost]; exists {
+		for i, roster := range rosters {
+			if roster.Team == current.Team {
+				start = i + 1
+				break
+			}
+		}
+	}
+
+	if room.TeamHostTurns == nil {
+		room.TeamHostTurns = make(map[string]int)
+	}
+
+	var next *models.TeamRoster
+	for i := range rosters {
+		roster := &rosters[(start+i)%len(rosters)]
+		if len(roster.Players) == 0 {
+			continue
+		}
+		if next == nil || room.TeamHostTurns[roster.Team] < room.TeamHostTurns[next.Team] {
+			next = roster
+		}
+	}
+
+	if next == nil {
+		return ""
+	}
+
+	turns := room.TeamHostTurns[next.Team]
+	room.TeamHostTurns[next.Team] = turns + 1
+	return next.Players[turns%len(next.Players)].ID
+}
+
+// calculateTeamScores 將隊員本題得分加總到隊伍分數並排名
+func (s *GameService) calculateTeamScores(room *models.Room, scores []models.ScoreInfo) []models.TeamScore {
+	if room.TeamScores == nil {
+		room.TeamScores = make(map[string]int)
+	}
+
+	gained := make(map[string]int, len(room.Teams))
+	for _, score := range scores {
+		gained[score.Team] += score.ScoreGained
+	}
+
+	teamScores := make([]models.TeamScore, 0, len(room.Teams))
+	for _, roster := range room.GetTeamRosters() {
+		room.TeamScores[roster.Team] += gained[roster.Team]
+		teamScores = append(teamScores, models.TeamScore{
+			Team:        roster.Team,
+			Score:       room.TeamScores[roster.Team],
+			ScoreGained: gained[roster.Team],
+			Members:     len(roster.Players),
+		})
+	}
+
+	sort.SliceStable(teamScores, func(i, j int) bool {
+		return teamScores[i].Score > teamScores[j].Score
+	})
+	logger := s.roundLogger(room)
+	for i := range teamScores {
+		teamScores[i].Rank = i + 1
+		logger.Debug("隊伍排名", "rank", i+1, "team", teamScores[i].Team, "score", teamScores[i].Score, "scoreGained", teamScores[i].ScoreGained)
+	}
+
+	return teamScores
+}
+
+// GetFinalTeamRanking 隊伍最終排名，猜測統計來自仍在房間中的隊員
+func (s *GameService) GetFinalTeamRanking(room *models.Room, playerStats []models.PlayerGameStats) []models.TeamGameStats {
+	statsByPlayer := make(map[string]models.PlayerGameStats, len(playerStats))
+	for _, stats := range playerStats {
+		statsByPlayer[stats.PlayerID] = stats
+	}
+
+	result := make([]models.TeamGameStats, 0, len(room.Teams))
+	for _, roster := range room.GetTeamRosters() {
+		teamStats := models.TeamGameStats{
+			Team:       roster.Team,
+			TotalScore: room.TeamScores[roster.Team],
+			Members:    make([]string, 0, len(roster.Players)),
+		}
+
+		for _, player := range roster.Players {
+			teamStats.Members = append(teamStats.Members, player.Name)
+			teamStats.Guesses += statsByPlayer[player.ID].AsGuesser
+			teamStats.CorrectGuesses += statsByPlayer[player.ID].CorrectGuesses
+		}
+		if teamStats.Guesses > 0 {
+			teamStats.GuessAccuracy = float64(teamStats.CorrectGuesses) / float64(teamStats.Guesses) * 100
+		}
+
+		result = append(result, teamStats)
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].TotalScore > result[j].TotalScore
+	})
+	for i := range result {
+		result[i].Rank = i + 1
+	}
+
+	return result
+}
+
+// AssignTeam 主持人在大廳將玩家分到指定隊伍
+func (s *RoomService) AssignTeam(roomID, playerID, team string) (*models.Room, error) {
+	return s.MutateRoom(roomID, func(room *models.Room) error {
+		if !room.IsTeamMode() {
+			return fmt.Errorf("%w: 房間不是隊伍模式", ErrInvalidTeams)
+		}
+		if room.Status != models.RoomStatusWaiting {
+			return fmt.Errorf("%w: 遊戲開始後無法調整隊伍", ErrInvalidTeams)
+		}
+		if !room.HasTeam(team) {
+			return fmt.Errorf("%w: 隊伍 %s 不存在", ErrInvalidTeams, team)
+		}
+
+		player, exists := room.GetPlayer(playerID)
+		if !exists {
+			return fmt.Errorf("%w: 玩家不存在", ErrInvalidTeams)
+		}
+
+		player.Team = team
+		return nil
+	})
+}
+
+// BalanceTeams 主持人在大廳重新平均分隊
+func (s *RoomService) BalanceTeams(roomID string) (*models.Room, error) {
+	return s.MutateRoom(roomID, func(room *models.Room) error {
+		if !room.IsTeamMode() {
+			return fmt.Errorf("%w: 房間不是隊伍模式", ErrInvalidTeams)
+		}
+		if room.Status != models.RoomStatusWaiting {
+			return fmt.Errorf("%w: 遊戲開始後無法調整隊伍", ErrInvalidTeams)
+		}
+
+		balanceTeams(room)
+		return nil
+	})
+}
@@ -0,0 +1,228 @@
+package services
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"reflect"
+	"testing"
+
+	"kahoot-game/internal/models"
+)
+
+// newTeamRoom 建立隊伍模式房間，members 為各隊隊員的玩家ID
+func newTeamRoom(teams []string, members map[string][]string) *models.Room {
+	room := &models.Room{
+		ID:      "TEAM01",
+		Teams:   teams,
+		Players: make(map[string]*models.Player),
+	}
+	for team, playerIDs := range members {
+		for _, playerID := range playerIDs {
+			room.Players[playerID] = &models.Player{ID: playerID, Name: playerID, Team: team}
+		}
+	}
+	return room
+}
+
+func newTestGameService() *GameService {
+	return NewGameService(nil, nil, nil, slog.New(slog.NewTextHandler(io.Discard, nil)))
+}
+
+func TestNormalizeTeams(t *testing.T) {
+	tests := []struct {
+		name    string
+		teams   []string
+		want    []string
+		wantErr bool
+	}{
+		{name: "沒有隊伍", teams: nil, want: nil},
+		{name: "去除前後空白", teams: []string{" 紅隊 ", "藍隊"}, want: []string{"紅隊", "藍隊"}},
+		{name: "只有一隊", teams: []string{"紅隊"}, wantErr: true},
+		{name: "超過八隊", teams: []string{"1", "2", "3", "4", "5", "6", "7", "8", "9"}, wantErr: true},
+		{name: "名稱空白", teams: []string{"紅隊", "  "}, wantErr: true},
+		{name: "名稱重複", teams: []string{"紅隊", " 紅隊"}, wantErr: true},
+		{name: "名稱太長", teams: []string{"紅隊", "一二三四五六七八九十一二三四五六七八九十一二三四五六七八九十一"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NormalizeTeams(tt.teams)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidTeams) {
+					t.Fatalf("NormalizeTeams() error = %v, 預期 %v", err, ErrInvalidTeams)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NormalizeTeams() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("NormalizeTeams() = %v, 預期 %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCalculateTeamScores(t *testing.T) {
+	teams := []string{"紅隊", "藍隊", "綠隊"}
+	members := map[string][]string{
+		"紅隊": {"r1", "r2"},
+		"藍隊": {"b1"},
+	}
+
+	tests := []struct {
+		name   string
+		prior  map[string]int // 本題前的隊伍累計分數
+		scores []models.ScoreInfo
+		want   []models.TeamScore
+	}{
+		{
+			name: "隊員得分加總並依分數排名",
+			scores: []models.ScoreInfo{
+				{PlayerID: "r1", Team: "紅隊", ScoreGained: 100},
+				{PlayerID: "r2", Team: "紅隊", ScoreGained: 50},
+				{PlayerID: "b1", Team: "藍隊", ScoreGained: 200},
+			},
+			want: []models.TeamScore{
+				{Team: "藍隊", Score: 200, ScoreGained: 200, Members: 1, Rank: 1},
+				{Team: "紅隊", Score: 150, ScoreGained: 150, Members: 2, Rank: 2},
+				{Team: "綠隊", Score: 0, ScoreGained: 0, Members: 0, Rank: 3},
+			},
+		},
+		{
+			name:  "累計先前的隊伍分數",
+			prior: map[string]int{"紅隊": 300, "藍隊": 100, "綠隊": 50},
+			scores: []models.ScoreInfo{
+				{PlayerID: "b1", Team: "藍隊", ScoreGained: 150},
+			},
+			want: []models.TeamScore{
+				{Team: "紅隊", Score: 300, ScoreGained: 0, Members: 2, Rank: 1},
+				{Team: "藍隊", Score: 250, ScoreGained: 150, Members: 1, Rank: 2},
+				{Team: "綠隊", Score: 50, ScoreGained: 0, Members: 0, Rank: 3},
+			},
+		},
+		{
+			name:  "同分時維持隊伍順序",
+			prior: map[string]int{"紅隊": 100, "藍隊": 200, "綠隊": 200},
+			scores: []models.ScoreInfo{
+				{PlayerID: "r1", Team: "紅隊", ScoreGained: 100},
+			},
+			want: []models.TeamScore{
+				{Team: "紅隊", Score: 200, ScoreGained: 100, Members: 2, Rank: 1},
+				{Team: "藍隊", Score: 200, ScoreGained: 0, Members: 1, Rank: 2},
+				{Team: "綠隊", Score: 200, ScoreGained: 0, Members: 0, Rank: 3},
+			},
+		},
+		{
+			name: "忽略不屬於房間隊伍的得分",
+			scores: []models.ScoreInfo{
+				{PlayerID: "x1", Team: "黃隊", ScoreGained: 500},
+				{PlayerID: "b1", Team: "藍隊", ScoreGained: 10},
+			},
+			want: []models.TeamScore{
+				{Team: "藍隊", Score: 10, ScoreGained: 10, Members: 1, Rank: 1},
+				{Team: "紅隊", Score: 0, ScoreGained: 0, Members: 2, Rank: 2},
+				{Team: "綠隊", Score: 0, ScoreGained: 0, Members: 0, Rank: 3},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			room := newTeamRoom(teams, members)
+			room.TeamScores = tt.prior
+
+			got := newTestGameService().calculateTeamScores(room, tt.scores)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("calculateTeamScores() =\n%+v\n預期\n%+v", got, tt.want)
+			}
+			for _, teamScore := range got {
+				if room.TeamScores[teamScore.Team] != teamScore.Score {
+					t.Errorf("房間中 %s 的累計分數 = %d, 預期 %d", teamScore.Team, room.TeamScores[teamScore.Team], teamScore.Score)
+				}
+			}
+		})
+	}
+}
+
+func TestGetFinalTeamRanking(t *testing.T) {
+	room := newTeamRoom([]string{"紅隊", "藍隊"}, map[string][]string{
+		"紅隊": {"r1", "r2"},
+		"藍隊": {"b1"},
+	})
+	room.TeamScores = map[string]int{"紅隊": 400, "藍隊": 900}
+
+	playerStats := []models.PlayerGameStats{
+		{PlayerID: "r1", AsGuesser: 4, CorrectGuesses: 3},
+		{PlayerID: "r2", AsGuesser: 4, CorrectGuesses: 1},
+		// b1 沒有猜過，已離開的玩家不計入隊伍
+		{PlayerID: "gone", AsGuesser: 5, CorrectGuesses: 5},
+	}
+
+	want := []models.TeamGameStats{
+		{Team: "藍隊", TotalScore: 900, Rank: 1, Members: []string{"b1"}},
+		{Team: "紅隊", TotalScore: 400, Rank: 2, Members: []string{"r1", "r2"}, Guesses: 8, CorrectGuesses: 4, GuessAccuracy: 50},
+	}
+
+	got := newTestGameService().GetFinalTeamRanking(room, playerStats)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("GetFinalTeamRanking() =\n%+v\n預期\n%+v", got, want)
+	}
+}
+
+func TestSelectNextTeamHost(t *testing.T) {
+	tests := []struct {
+		name    string
+		teams   []string
+		members map[string][]string
+		first   string   // 第一題的主角
+		want    []string // 接下來各題的主角
+	}{
+		{
+			name:    "主角在兩隊間輪流",
+			teams:   []string{"紅隊", "藍隊"},
+			members: map[string][]string{"紅隊": {"r1", "r2"}, "藍隊": {"b1", "b2"}},
+			first:   "r1",
+			want:    []string{"b1", "r1", "b2", "r2", "b1"},
+		},
+		{
+			name:    "人數較少的隊伍隊員重複當主角",
+			teams:   []string{"紅隊", "藍隊"},
+			members: map[string][]string{"紅隊": {"r1", "r2"}, "藍隊": {"b1"}},
+			first:   "r1",
+			want:    []string{"b1", "r1", "b1", "r2", "b1", "r1"},
+		},
+		{
+			name:    "略過沒有隊員的隊伍",
+			teams:   []string{"紅隊", "藍隊", "綠隊"},
+			members: map[string][]string{"紅隊": {"r1"}, "綠隊": {"g1"}},
+			first:   "r1",
+			want:    []string{"g1", "r1", "g1"},
+		},
+		{
+			name:    "三隊依序輪流",
+			teams:   []string{"紅隊", "藍隊", "綠隊"},
+			members: map[string][]string{"紅隊": {"r1"}, "藍隊": {"b1"}, "綠隊": {"g1"}},
+			first:   "b1",
+			want:    []string{"g1", "r1", "b1", "g1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestGameService()
+			room := newTeamRoom(tt.teams, tt.members)
+
+			host := tt.first
+			got := make([]string, 0, len(tt.want))
+			for range tt.want {
+				host = s.selectNextTeamHost(room, host)
+				got = append(got, host)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("主角順序 = %v, 預期 %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,308 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"math/rand"
+	"sort"
+	"time"
+
+	"kahoot-game/internal/database"
+	"kahoot-game/internal/logging"
+	"kahoot-game/internal/models"
+)
+
+const (
+	// triviaBaseScore 答對的基礎分
+	triviaBaseScore = 100
+
+	// triviaMaxSpeedBonus 速度獎勵上限，越快作答獎勵越高
+	triviaMaxSpeedBonus = 100
+)
+
+// TriviaMode 「你問我答」遊戲模式
+// 四選一、有客觀正確答案，答對越快分數越高
+type TriviaMode struct {
+	gameService *GameService
+	db          *sql.DB
+}
+
+// NewTriviaMode 創建「你問我答」遊戲模式，db 為 nil 時使用內建題庫
+func NewTriviaMode(gameService *GameService, db *sql.DB) *TriviaMode {
+	return &TriviaMode{
+		gameService: gameService,
+		db:          db,
+	}
+}
+
+// ID 模式代碼
+func (m *TriviaMode) ID() string {
+	return models.GameModeTrivia
+}
+
+// Name 模式顯示名稱
+func (m *TriviaMode) Name() string {
+	return "你問我答"
+}
+
+// MinPlayers 最少玩家數
+func (m *TriviaMode) MinPlayers() int {
+	return 1
+}
+
+// LoadQuestions 從資料庫題庫選題，資料庫不可用時使用內建題庫
+func (m *TriviaMode) LoadQuestions(count int) ([]models.Question, error) {
+	if m.db != nil {
+		questions, err := m.loadQuestionsFromDB(count)
+		if err == nil && len(questions) > 0 {
+			return questions, nil
+		}
+		m.gameService.logger.Warn("從資料庫載入「你問我答」題目失敗，改用內建題庫", logging.Err(err))
+	}
+
+	questions := getBuiltinTriviaQuestions(count)
+	if len(questions) == 0 {
+		return nil, fmt.Errorf("無法載入遊戲題目")
+	}
+	return questions, nil
+}
+
+// loadQuestionsFromDB 從 questions 表隨機選題
+func (m *TriviaMode) loadQuestionsFromDB(count int) ([]models.Question, error) {
+	query := `
+		SELECT id, question_text, option_a, option_b, option_c, option_d,
+			   correct_answer, COALESCE(explanation, ''), COALESCE(category, ''),
+			   difficulty, times_used, is_active, created_at
+		FROM questions
+		WHERE is_active = true
+		ORDER BY RANDOM()
+		LIMIT $1
+	`
+
+	rows, err := m.db.Query(query, count)
+	if err != nil {
+		return nil, fmt.Errorf("查詢題目失敗: %w", err)
+	}
+	defer rows.Close()
+
+	var questions []models.Question
+	for rows.Next() {
+		var q models.Question
+		err := rows.Scan(
+			&q.ID, &q.QuestionText, &q.OptionA, &q.OptionB, &q.OptionC, &q.OptionD,
+			&q.CorrectAnswer, &q.Explanation, &q.Category,
+			&q.Difficulty, &q.TimesUsed, &q.IsActive, &q.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("掃描題目資料失敗: %w", err)
+		}
+		questions = append(questions, q)
+	}
+
+	return questions, rows.Err()
+}
+
+// StartGame 開始遊戲
+func (m *TriviaMode) StartGame(room *models.Room) error {
+	if room.GetPlayerCount() < m.MinPlayers() {
+		return fmt.Errorf("至少需要%d個玩家才能開始遊戲", m.MinPlayers())
+	}
+
+	questions, err := m.LoadQuestions(room.TotalQuestions)
+	if err != nil {
+		return err
+	}
+
+	// 題庫不足時以實際題數為準
+	room.Questions = questions
+	if room.TotalQuestions > len(questions) {
+		room.TotalQuestions = len(questions)
+	}
+
+	// 重置遊戲狀態
+	room.CurrentQuestion = 1
+	room.CurrentHost = ""
+	room.NextHostOverride = ""
+	room.Answers = make(map[string]*models.Answer)
+	room.GameHistory = nil
+	for _, player := range room.Players {
+		player.Score = 0
+	}
+	room.Status = models.RoomStatusQuestionDisplay
+
+	return nil
+}
+
+// QuestionPayload 題目內容，不包含正確答案
+func (m *TriviaMode) QuestionPayload(room *models.Room) map[string]interface{} {
+	currentQuestion := room.Questions[room.CurrentQuestion-1]
+
+	return map[string]interface{}{
+		"questionId":   currentQuestion.ID,
+		"questionText": currentQuestion.QuestionText,
+		"optionA":      currentQuestion.OptionA,
+		"optionB":      currentQuestion.OptionB,
+		"optionC":      currentQuestion.OptionC,
+		"optionD":      currentQuestion.OptionD,
+		"category":     currentQuestion.Category,
+		"difficulty":   currentQuestion.Difficulty,
+		"question":     currentQuestion.QuestionText,
+	}
+}
+
+// SubmitAnswer 提交答案並判斷對錯
+func (m *TriviaMode) SubmitAnswer(room *models.Room, playerID, answer string, timeUsed float64) (*models.Answer, error) {
+	currentQuestion := room.Questions[room.CurrentQuestion-1]
+
+	switch answer {
+	case "A", "B", "C", "D":
+	default:
+		return nil, fmt.Errorf("無效的答案選項")
+	}
+
+	if _, exists := room.GetPlayer(playerID); !exists {
+		return nil, fmt.Errorf("玩家不存在")
+	}
+
+	return &models.Answer{
+		PlayerID:     playerID,
+		QuestionID:   currentQuestion.ID,
+		Answer:       answer,
+		IsCorrect:    answer == currentQuestion.CorrectAnswer,
+		ResponseTime: timeUsed,
+		SubmittedAt:  time.Now(),
+	}, nil
+}
+
+// AllAnswered 所有玩家都作答才算完成
+func (m *TriviaMode) AllAnswered(room *models.Room) bool {
+	return len(room.Answers) >= room.GetPlayerCount()
+}
+
+// ValidateRound 有客觀答案，只要有人作答就能計分
+func (m *TriviaMode) ValidateRound(room *models.Room) *RoundError {
+	return nil
+}
+
+// ScoreRound 答對得基礎分加速度獎勵
+func (m *TriviaMode) ScoreRound(room *models.Room) *models.QuestionResult {
+	currentQuestion := room.Questions[room.CurrentQuestion-1]
+	scores := make([]models.ScoreInfo, 0, len(room.Players))
+
+	for playerID, player := range room.Players {
+		scoreGained := 0
+		if answer, hasAnswered := room.Answers[playerID]; hasAnswered {
+			answer.IsCorrect = answer.Answer == currentQuestion.CorrectAnswer
+			if answer.IsCorrect {
+				scoreGained = triviaBaseScore + triviaSpeedBonus(answer.ResponseTime, room.QuestionTimeLimit)
+			}
+			answer.ScoreGained = scoreGained
+		}
+
+		player.Score += scoreGained
+		scores = append(scores, models.ScoreInfo{
+			PlayerID:    playerID,
+			PlayerName:  player.Name,
+			Score:       player.Score,
+			ScoreGained: scoreGained,
+		})
+	}
+
+	rankScores(scores)
+
+	// 題目歷史中的 HostAnswer 記錄正確答案
+	m.gameService.RecordQuestionHistory(room, currentQuestion.CorrectAnswer)
+
+	m.gameService.roundLogger(room).Info("「你問我答」本題計分完成",
+		"correctAnswer", currentQuestion.CorrectAnswer, "answers", len(room.Answers))
+
+	return &models.QuestionResult{
+		QuestionID:    currentQuestion.ID,
+		CorrectAnswer: currentQuestion.CorrectAnswer,
+		Explanation:   currentQuestion.Explanation,
+		PlayerAnswers: copyAnswers(room.Answers),
+		Scores:        scores,
+	}
+}
+
+// NextQuestion 進入下一題
+func (m *TriviaMode) NextQuestion(room *models.Room) {
+	room.CurrentQuestion++
+
+	if room.CurrentQuestion > room.TotalQuestions {
+		room.Status = models.RoomStatusFinished
+	} else {
+		room.Status = models.RoomStatusQuestionDisplay
+	}
+}
+
+// PlayerLeft 沒有主角，玩家離開不影響本題
+func (m *TriviaMode) PlayerLeft(room *models.Room, playerID string) bool {
+	return false
+}
+
+// FinalRanking 最終排名
+func (m *TriviaMode) FinalRanking(room *models.Room) []models.PlayerGameStats {
+	return m.gameService.GetFinalRanking(room)
+}
+
+// triviaSpeedBonus 依剩餘時間比例計算速度獎勵
+func triviaSpeedBonus(responseTime float64, timeLimit int) int {
+	if timeLimit <= 0 {
+		return 0
+	}
+
+	remaining := 1 - responseTime/float64(timeLimit)
+	if remaining < 0 {
+		remaining = 0
+	}
+	if remaining > 1 {
+		remaining = 1
+	}
+
+	return int(remaining * triviaMaxSpeedBonus)
+}
+
+// getBuiltinTriviaQuestions 從內建題庫隨機選題
+func getBuiltinTriviaQuestions(count int) []models.Question {
+	seeds := database.TriviaSeedQuestions()
+	questions := make([]models.Question, len(seeds))
+
+	for i, seed := range seeds {
+		questions[i] = models.Question{
+			ID:            i + 1,
+			QuestionText:  seed.Text,
+			OptionA:       seed.OptionA,
+			OptionB:       seed.OptionB,
+			OptionC:       seed.OptionC,
+			OptionD:       seed.OptionD,
+			CorrectAnswer: seed.CorrectAnswer,
+			Explanation:   seed.Explanation,
+			Category:      seed.Category,
+			Difficulty:    seed.Difficulty,
+			IsActive:      true,
+			CreatedAt:     time.Now(),
+		}
+	}
+
+	rand.Shuffle(len(questions), func(i, j int) {
+		questions[i], questions[j] = questions[j], questions[i]
+	})
+
+	if count < len(questions) {
+		questions = questions[:count]
+	}
+
+	return questions
+}
+
+// rankScores 依總分降序排序並設置排名
+func rankScores(scores []models.ScoreInfo) {
+	sort.SliceStable(scores, func(i, j int) bool {
+		return scores[i].Score > scores[j].Score
+	})
+
+	for i := range scores {
+		scores[i].Rank = i + 1
+	}
+}
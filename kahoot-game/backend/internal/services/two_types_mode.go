@@ -0,0 +1,179 @@
+package services
+
+import (
+	"fmt"
+
+	"kahoot-game/internal/models"
+)
+
+// TwoTypesMode 「2種人」遊戲模式
+// 每題由一位主角作答，其他玩家猜主角的選擇
+type TwoTypesMode struct {
+	gameService *GameService
+}
+
+// NewTwoTypesMode 創建「2種人」遊戲模式
+func NewTwoTypesMode(gameService *GameService) *TwoTypesMode {
+	return &TwoTypesMode{
+		gameService: gameService,
+	}
+}
+
+// ID 模式代碼
+func (m *TwoTypesMode) ID() string {
+	return models.GameModeTwoTypes
+}
+
+// Name 模式顯示名稱
+func (m *TwoTypesMode) Name() string {
+	return "2種人"
+}
+
+// MinPlayers 最少玩家數
+func (m *TwoTypesMode) MinPlayers() int {
+	return 2
+}
+
+// LoadQuestions 從「2種人」題庫隨機選題
+func (m *TwoTypesMode) LoadQuestions(count int) ([]models.Question, error) {
+	return m.SelectQuestions(count, nil)
+}
+
+// SelectQuestions 依房間選題條件從「2種人」題庫選題
+func (m *TwoTypesMode) SelectQuestions(count int, selection *models.QuestionSelection) ([]models.Question, error) {
+	questions, err := m.gameService.LoadTwoTypesQuestions(count, selection)
+	if err != nil {
+		return nil, err
+	}
+	if len(questions) == 0 {
+		return nil, fmt.Errorf("無法載入遊戲題目")
+	}
+	return questions, nil
+}
+
+// StartGame 開始遊戲
+func (m *TwoTypesMode) StartGame(room *models.Room) error {
+	return m.gameService.StartTwoTypesGame(room)
+}
+
+// QuestionPayload 題目內容，包含本題主角
+func (m *TwoTypesMode) QuestionPayload(room *models.Room) map[string]interface{} {
+	currentQuestion := room.Questions[room.CurrentQuestion-1]
+
+	return map[string]interface{}{
+		"questionId":   currentQuestion.ID,
+		"questionText": currentQuestion.QuestionText,
+		"optionA":      currentQuestion.OptionA,
+		"optionB":      currentQuestion.OptionB,
+		"hostPlayer":   room.CurrentHost,
+		"question":     currentQuestion.QuestionText, // 前端可能使用這個字段
+	}
+}
+
+// SubmitAnswer 提交答案
+func (m *TwoTypesMode) SubmitAnswer(room *models.Room, playerID, answer string, timeUsed float64) (*models.Answer, error) {
+	return m.gameService.SubmitTwoTypesAnswer(room, playerID, answer, timeUsed)
+}
+
+// AllAnswered 所有玩家（含主角）都作答才算完成
+func (m *TwoTypesMode) AllAnswered(room *models.Room) bool {
+	return len(room.Answers) >= room.GetPlayerCount()
+}
+
+// ValidateRound 主角沒作答時本題無效
+func (m *TwoTypesMode) ValidateRound(room *models.Room) *RoundError {
+	if _, hostAnswered := room.Answers[room.CurrentHost]; !hostAnswered {
+		return &RoundError{
+			Reason:  "host_no_answer",
+			Message: "主角未在時間內答題，本題無效",
+		}
+	}
+	return nil
+}
+
+// ScoreRound 計分並記錄題目歷史
+func (m *TwoTypesMode) ScoreRound(room *models.Room) *models.QuestionResult {
+	scores := m.gameService.CalculateTwoTypesScores(room, room.Answers)
+	hostAnswer := getHostAnswer(room)
+
+	m.gameService.RecordQuestionHistory(room, hostAnswer)
+
+	result := &models.QuestionResult{
+		QuestionID:    room.Questions[room.CurrentQuestion-1].ID,
+		CorrectAnswer: hostAnswer,
+		PlayerAnswers: copyAnswers(room.Answers),
+		Scores:        scores,
+	}
+
+	// 隊伍模式：隊員得分加總為隊伍分數
+	if room.IsTeamMode() {
+		result.TeamScores = m.gameService.calculateTeamScores(room, scores)
+	}
+
+	return result
+}
+
+// NextQuestion 換下一位主角並進入下一題
+func (m *TwoTypesMode) NextQuestion(room *models.Room) {
+	m.gameService.NextTwoTypesQuestion(room)
+}
+
+// PlayerLeft 主角或下一題預定的主角離開時重新選擇主角
+func (m *TwoTypesMode) PlayerLeft(room *models.Room, playerID string) bool {
+	if room.GetPlayerCount() == 0 {
+		room.NextHostOverride = ""
+		return false
+	}
+
+	hostChanged := false
+
+	// 如果當前主角不存在或就是離開者，選擇新的主角
+	currentHostMissing := room.CurrentHost == "" || room.Players[room.CurrentHost] == nil
+	if currentHostMissing {
+		if newHost := m.gameService.SelectNextHost(room, playerID); newHost != "" {
+			room.CurrentHost = newHost
+			room.NextHostOverride = newHost
+			hostChanged = true
+		}
+	}
+
+	// 如果下一題預設主角是離開者，重新選擇
+	if room.NextHostOverride == playerID {
+		if newOverride := m.gameService.SelectNextHost(room, playerID); newOverride != "" {
+			room.NextHostOverride = newOverride
+			room.CurrentHost = newOverride
+			hostChanged = true
+		} else {
+			room.NextHostOverride = ""
+		}
+	}
+
+	return hostChanged
+}
+
+// FinalRanking 最終排名
+func (m *TwoTypesMode) FinalRanking(room *models.Room) []models.PlayerGameStats {
+	return m.gameService.GetFinalRanking(room)
+}
+
+// FinalTeamRanking 隊伍最終排名
+func (m *TwoTypesMode) FinalTeamRanking(room *models.Room, playerStats []models.PlayerGameStats) []models.TeamGameStats {
+	return m.gameService.GetFinalTeamRanking(room, playerStats)
+}
+
+// getHostAnswer 獲取主角答案
+func getHostAnswer(room *models.Room) string {
+	if answer, exists := room.Answers[room.CurrentHost]; exists {
+		return answer.Answer
+	}
+	return ""
+}
+
+// copyAnswers 複製答案表，避免結果訊息與房間狀態共用指標
+func copyAnswers(answers map[string]*models.Answer) map[string]models.Answer {
+	result := make(map[string]models.Answer, len(answers))
+	for playerID, answer := range answers {
+		result[playerID] = *answer
+	}
+	return result
+}
@@ -90,6 +90,8 @@ func ConvertToGameQuestions(twoTypesQuestions []TwoTypesQuestion) []models.Quest
 			OptionA:      q.OptionA,
 			OptionB:      q.OptionB,
 			Category:     q.Category,
+			Difficulty:   1,
+			PackID:       BuiltinQuestionPack,
 			TimesUsed:    0,
 			IsActive:     true,
 			CreatedAt:    time.Now(),
@@ -99,7 +101,7 @@ func ConvertToGameQuestions(twoTypesQuestions []TwoTypesQuestion) []models.Quest
 	return questions
 }
 
-// GetRandomQuestions 隨機選取指定數量的題目
+// GetRandomQuestions 從內建題庫隨機選取指定數量的題目（題庫無法使用時的備援）
 func GetRandomQuestions(count int) []models.Question {
 	allQuestions := ConvertToGameQuestions(GetTwoTypesQuestions())
 	
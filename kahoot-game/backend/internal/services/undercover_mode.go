@@ -0,0 +1,450 @@
+package services
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"kahoot-game/internal/logging"
+	"kahoot-game/internal/models"
+)
+
+const (
+	// undercoverVoteScore 投票投中臥底的得分
+	undercoverVoteScore = 50
+
+	// undercoverWinScore 獲勝陣營每位玩家的得分
+	undercoverWinScore = 200
+
+	// undercoverMaxDescriptionLength 描述的最大字數
+	undercoverMaxDescriptionLength = 50
+
+	// undercoverDoubleAgentThreshold 玩家數達到此值時安排兩名臥底
+	undercoverDoubleAgentThreshold = 7
+)
+
+// UndercoverMode 「誰是臥底」遊戲模式
+// 平民與臥底私下拿到相近但不同的詞語，每輪先描述再投票淘汰一人，
+// 每個描述階段與投票階段各佔用房間的一「題」
+type UndercoverMode struct {
+	gameService *GameService
+}
+
+// NewUndercoverMode 創建「誰是臥底」遊戲模式
+func NewUndercoverMode(gameService *GameService) *UndercoverMode {
+	return &UndercoverMode{
+		gameService: gameService,
+	}
+}
+
+// ID 模式代碼
+func (m *UndercoverMode) ID() string {
+	return models.GameModeUndercover
+}
+
+// Name 模式顯示名稱
+func (m *UndercoverMode) Name() string {
+	return "誰是臥底"
+}
+
+// MinPlayers 最少玩家數
+func (m *UndercoverMode) MinPlayers() int {
+	return 3
+}
+
+// LoadQuestions 產生描述與投票交替的階段題目，詞語另外存在房間狀態中
+func (m *UndercoverMode) LoadQuestions(count int) ([]models.Question, error) {
+	if count <= 0 {
+		return nil, fmt.Errorf("無法載入遊戲題目")
+	}
+
+	questions := make([]models.Question, count)
+	for i := range questions {
+		phase := models.UndercoverPhaseDescribe
+		text := "用一句話描述你拿到的詞語"
+		if i%2 == 1 {
+			phase = models.UndercoverPhaseVote
+			text = "投票找出臥底"
+		}
+
+		questions[i] = models.Question{
+			ID:           i + 1,
+			QuestionText: text,
+			Category:     phase,
+			IsActive:     true,
+			CreatedAt:    time.Now(),
+		}
+	}
+
+	return questions, nil
+}
+
+// StartGame 分配身份與詞語並進入第一輪描述
+func (m *UndercoverMode) StartGame(room *models.Room) error {
+	playerCount := room.GetPlayerCount()
+	if playerCount < m.MinPlayers() {
+		return fmt.Errorf("至少需要%d個玩家才能開始遊戲", m.MinPlayers())
+	}
+
+	pair := GetRandomUndercoverWordPair()
+
+	undercoverCount := 1
+	if playerCount >= undercoverDoubleAgentThreshold {
+		undercoverCount = 2
+	}
+
+	playerIDs := make([]string, 0, playerCount)
+	for playerID := range room.Players {
+		playerIDs = append(playerIDs, playerID)
+	}
+	rand.Shuffle(len(playerIDs), func(i, j int) {
+		playerIDs[i], playerIDs[j] = playerIDs[j], playerIDs[i]
+	})
+
+	roles := make(map[string]string, playerCount)
+	for i, playerID := range playerIDs {
+		if i < undercoverCount {
+			roles[playerID] = models.UndercoverRoleUndercover
+		} else {
+			roles[playerID] = models.UndercoverRoleCivilian
+		}
+	}
+
+	// 每輪最多淘汰一人，最多 playerCount-2 輪就會分出勝負
+	maxRounds := playerCount - 2
+	questions, err := m.LoadQuestions(maxRounds * 2)
+	if err != nil {
+		return err
+	}
+
+	room.Questions = questions
+	room.TotalQuestions = len(questions)
+	room.Undercover = &models.UndercoverState{
+		Category:       pair.Category,
+		CivilianWord:   pair.CivilianWord,
+		UndercoverWord: pair.UndercoverWord,
+		Roles:          roles,
+		Eliminated:     make(map[string]bool),
+		Phase:          models.UndercoverPhaseDescribe,
+		Round:          1,
+	}
+
+	// 重置遊戲狀態
+	room.CurrentQuestion = 1
+	room.CurrentHost = ""
+	room.NextHostOverride = ""
+	room.Answers = make(map[string]*models.Answer)
+	room.GameHistory = nil
+	for _, player := range room.Players {
+		player.Score = 0
+	}
+	room.Status = models.RoomStatusQuestionDisplay
+
+	m.gameService.logger.Info("「誰是臥底」開始",
+		logging.KeyRoomID, room.ID, "players", playerCount, "undercovers", undercoverCount, "maxRounds", maxRounds)
+
+	return nil
+}
+
+// QuestionPayload 當前階段資訊，不包含任何詞語或身份
+func (m *UndercoverMode) QuestionPayload(room *models.Room) map[string]interface{} {
+	currentQuestion := room.Questions[room.CurrentQuestion-1]
+	state := room.Undercover
+
+	alivePlayers := m.alivePlayerIDs(room)
+	rand.Shuffle(len(alivePlayers), func(i, j int) {
+		alivePlayers[i], alivePlayers[j] = alivePlayers[j], alivePlayers[i]
+	})
+
+	eliminated := make([]string, 0, len(state.Eliminated))
+	for playerID := range state.Eliminated {
+		eliminated = append(eliminated, playerID)
+	}
+
+	return map[string]interface{}{
+		"questionId":   currentQuestion.ID,
+		"questionText": currentQuestion.QuestionText,
+		"question":     currentQuestion.QuestionText,
+		"phase":        state.Phase,
+		"round":        state.Round,
+		"alivePlayers": alivePlayers, // 描述階段依此順序發言
+		"eliminated":   eliminated,
+		"wordCategory": state.Category,
+	}
+}
+
+// PrivatePayloads 描述階段開始時，私下告訴每位玩家自己的詞語
+func (m *UndercoverMode) PrivatePayloads(room *models.Room) map[string]map[string]interface{} {
+	state := room.Undercover
+	if state == nil || state.Phase != models.UndercoverPhaseDescribe {
+		return nil
+	}
+
+	payloads := make(map[string]map[string]interface{}, len(state.Roles))
+	for playerID := range state.Roles {
+		if _, inRoom := room.Players[playerID]; !inRoom {
+			continue
+		}
+
+		payloads[playerID] = map[string]interface{}{
+			"gameMode":   m.ID(),
+			"word":       state.WordFor(playerID),
+			"round":      state.Round,
+			"eliminated": state.Eliminated[playerID],
+		}
+	}
+
+	return payloads
+}
+
+// SubmitAnswer 描述階段提交描述，投票階段提交被投票的玩家ID
+func (m *UndercoverMode) SubmitAnswer(room *models.Room, playerID, answer string, timeUsed float64) (*models.Answer, error) {
+	state := room.Undercover
+	if state == nil {
+		return nil, fmt.Errorf("遊戲尚未開始")
+	}
+
+	if !state.IsAlive(playerID) {
+		return nil, fmt.Errorf("你已被淘汰，無法作答")
+	}
+
+	answer = strings.TrimSpace(answer)
+
+	switch state.Phase {
+	case models.UndercoverPhaseDescribe:
+		if answer == "" {
+			return nil, fmt.Errorf("描述不能為空")
+		}
+		if utf8.RuneCountInString(answer) > undercoverMaxDescriptionLength {
+			return nil, fmt.Errorf("描述不能超過%d個字", undercoverMaxDescriptionLength)
+		}
+		if strings.Contains(answer, state.WordFor(playerID)) {
+			return nil, fmt.Errorf("描述不能直接說出詞語")
+		}
+
+	case models.UndercoverPhaseVote:
+		if answer == playerID {
+			return nil, fmt.Errorf("不能投給自己")
+		}
+		if !state.IsAlive(answer) {
+			return nil, fmt.Errorf("只能投給場上的玩家")
+		}
+
+	default:
+		return nil, fmt.Errorf("未知的遊戲階段")
+	}
+
+	return &models.Answer{
+		PlayerID:     playerID,
+		QuestionID:   room.Questions[room.CurrentQuestion-1].ID,
+		Answer:       answer,
+		ResponseTime: timeUsed,
+		SubmittedAt:  time.Now(),
+	}, nil
+}
+
+// AllAnswered 場上所有存活玩家都作答才算完成
+func (m *UndercoverMode) AllAnswered(room *models.Room) bool {
+	alivePlayers := m.alivePlayerIDs(room)
+	for _, playerID := range alivePlayers {
+		if _, answered := room.Answers[playerID]; !answered {
+			return false
+		}
+	}
+	return len(alivePlayers) > 0
+}
+
+// ValidateRound 描述可以略過，投票只要有人投就能結算
+func (m *UndercoverMode) ValidateRound(room *models.Room) *RoundError {
+	if room.Undercover == nil {
+		return &RoundError{
+			Reason:  "game_not_started",
+			Message: "遊戲尚未開始",
+		}
+	}
+	return nil
+}
+
+// ScoreRound 描述階段公布所有描述，投票階段淘汰得票最高者並判定勝負
+func (m *UndercoverMode) ScoreRound(room *models.Room) *models.QuestionResult {
+	state := room.Undercover
+	currentQuestion := room.Questions[room.CurrentQuestion-1]
+	scoreGained := make(map[string]int, len(room.Players))
+
+	details := map[string]interface{}{
+		"phase": state.Phase,
+		"round": state.Round,
+	}
+
+	if state.Phase == models.UndercoverPhaseDescribe {
+		descriptions := make(map[string]string, len(room.Answers))
+		for playerID, answer := range room.Answers {
+			descriptions[playerID] = answer.Answer
+		}
+		details["descriptions"] = descriptions
+	} else {
+		eliminated, votes, tie := m.tallyVotes(room)
+		details["votes"] = votes
+		details["tie"] = tie
+
+		// 投中臥底的玩家得分
+		for playerID, answer := range room.Answers {
+			answer.IsCorrect = state.Roles[answer.Answer] == models.UndercoverRoleUndercover
+			if answer.IsCorrect {
+				answer.ScoreGained = undercoverVoteScore
+				scoreGained[playerID] += undercoverVoteScore
+			}
+		}
+
+		if eliminated != "" {
+			state.Eliminated[eliminated] = true
+			details["eliminated"] = eliminated
+			details["eliminatedRole"] = state.Roles[eliminated]
+			m.gameService.roundLogger(room).Info("「誰是臥底」淘汰玩家",
+				"round", state.Round, logging.KeyPlayerID, eliminated, "role", state.Roles[eliminated])
+		}
+
+		state.Winner = m.checkWinner(room)
+		if state.Winner == "" && room.CurrentQuestion >= room.TotalQuestions {
+			// 撐過所有輪次，臥底獲勝
+			state.Winner = models.UndercoverRoleUndercover
+		}
+
+		m.gameService.RecordQuestionHistory(room, eliminated)
+	}
+
+	if state.Winner != "" {
+		for playerID, role := range state.Roles {
+			if role == state.Winner {
+				scoreGained[playerID] += undercoverWinScore
+			}
+		}
+
+		details["winner"] = state.Winner
+		details["roles"] = state.Roles
+		details["civilianWord"] = state.CivilianWord
+		details["undercoverWord"] = state.UndercoverWord
+		m.gameService.roundLogger(room).Info("「誰是臥底」分出勝負", "winner", state.Winner)
+	}
+
+	scores := make([]models.ScoreInfo, 0, len(room.Players))
+	for playerID, player := range room.Players {
+		player.Score += scoreGained[playerID]
+		scores = append(scores, models.ScoreInfo{
+			PlayerID:    playerID,
+			PlayerName:  player.Name,
+			Score:       player.Score,
+			ScoreGained: scoreGained[playerID],
+		})
+	}
+	rankScores(scores)
+
+	return &models.QuestionResult{
+		QuestionID:    currentQuestion.ID,
+		PlayerAnswers: copyAnswers(room.Answers),
+		Scores:        scores,
+		Details:       details,
+	}
+}
+
+// NextQuestion 描述 → 投票 → 下一輪描述，分出勝負即結束
+func (m *UndercoverMode) NextQuestion(room *models.Room) {
+	state := room.Undercover
+	room.CurrentQuestion++
+
+	if state == nil || state.Winner != "" || room.CurrentQuestion > room.TotalQuestions {
+		room.Status = models.RoomStatusFinished
+		return
+	}
+
+	if state.Phase == models.UndercoverPhaseVote {
+		state.Phase = models.UndercoverPhaseDescribe
+		state.Round++
+	} else {
+		state.Phase = models.UndercoverPhaseVote
+	}
+	room.Status = models.RoomStatusQuestionDisplay
+}
+
+// PlayerLeft 離開的玩家視同淘汰，並重新判定勝負
+func (m *UndercoverMode) PlayerLeft(room *models.Room, playerID string) bool {
+	state := room.Undercover
+	if state == nil || state.Winner != "" {
+		return false
+	}
+
+	if _, hasRole := state.Roles[playerID]; hasRole {
+		state.Eliminated[playerID] = true
+		state.Winner = m.checkWinner(room)
+	}
+
+	return false
+}
+
+// FinalRanking 最終排名
+func (m *UndercoverMode) FinalRanking(room *models.Room) []models.PlayerGameStats {
+	return m.gameService.GetFinalRanking(room)
+}
+
+// alivePlayerIDs 場上存活且仍在房間內的玩家
+func (m *UndercoverMode) alivePlayerIDs(room *models.Room) []string {
+	if room.Undercover == nil {
+		return nil
+	}
+
+	alive := make([]string, 0, len(room.Players))
+	for playerID := range room.Players {
+		if room.Undercover.IsAlive(playerID) {
+			alive = append(alive, playerID)
+		}
+	}
+	return alive
+}
+
+// tallyVotes 計票，得票最高且唯一者被淘汰，平手時無人淘汰
+func (m *UndercoverMode) tallyVotes(room *models.Room) (eliminated string, votes map[string]int, tie bool) {
+	votes = make(map[string]int)
+	for _, answer := range room.Answers {
+		votes[answer.Answer]++
+	}
+
+	maxVotes := 0
+	for playerID, count := range votes {
+		switch {
+		case count > maxVotes:
+			maxVotes = count
+			eliminated = playerID
+			tie = false
+		case count == maxVotes:
+			tie = true
+		}
+	}
+
+	if tie {
+		eliminated = ""
+	}
+	return eliminated, votes, tie
+}
+
+// checkWinner 臥底全部出局則平民獲勝，臥底人數不少於平民則臥底獲勝
+func (m *UndercoverMode) checkWinner(room *models.Room) string {
+	civilians, undercovers := 0, 0
+	for _, playerID := range m.alivePlayerIDs(room) {
+		if room.Undercover.Roles[playerID] == models.UndercoverRoleUndercover {
+			undercovers++
+		} else {
+			civilians++
+		}
+	}
+
+	switch {
+	case undercovers == 0:
+		return models.UndercoverRoleCivilian
+	case undercovers >= civilians:
+		return models.UndercoverRoleUndercover
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,282 @@
+package services
+
+import (
+	"testing"
+
+	"kahoot-game/internal/models"
+)
+
+// newUndercoverRoom 建立投票階段的「誰是臥底」房間，undercovers 以外的玩家都是平民
+func newUndercoverRoom(players []string, undercovers ...string) *models.Room {
+	room := &models.Room{
+		ID:      "SPY001",
+		Players: make(map[string]*models.Player),
+		Answers: make(map[string]*models.Answer),
+		Undercover: &models.UndercoverState{
+			CivilianWord:   "蘋果",
+			UndercoverWord: "水梨",
+			Roles:          make(map[string]string),
+			Eliminated:     make(map[string]bool),
+			Phase:          models.UndercoverPhaseVote,
+			Round:          1,
+		},
+	}
+	for _, playerID := range players {
+		room.Players[playerID] = &models.Player{ID: playerID, Name: playerID}
+		room.Undercover.Roles[playerID] = models.UndercoverRoleCivilian
+	}
+	for _, playerID := range undercovers {
+		room.Undercover.Roles[playerID] = models.UndercoverRoleUndercover
+	}
+	return room
+}
+
+// vote 記錄玩家的投票
+func vote(room *models.Room, voter, target string) {
+	room.Answers[voter] = &models.Answer{PlayerID: voter, Answer: target}
+}
+
+func TestUndercoverTallyVotes(t *testing.T) {
+	tests := []struct {
+		name           string
+		votes          map[string]string // 投票者 → 被投票的玩家
+		wantEliminated string
+		wantTie        bool
+	}{
+		{
+			name:           "得票最高者被淘汰",
+			votes:          map[string]string{"c1": "u1", "c2": "u1", "c3": "c1", "u1": "c1", "c4": "u1"},
+			wantEliminated: "u1",
+		},
+		{
+			name:    "平手時無人淘汰",
+			votes:   map[string]string{"c1": "u1", "c2": "u1", "u1": "c1", "c3": "c1"},
+			wantTie: true,
+		},
+		{
+			name:    "最高票平手時無人淘汰，即使有較低票者",
+			votes:   map[string]string{"c1": "u1", "c2": "c3", "u1": "c1", "c3": "c1", "c4": "u1"},
+			wantTie: true,
+		},
+		{
+			name:           "只有一人投票",
+			votes:          map[string]string{"c1": "u1"},
+			wantEliminated: "u1",
+		},
+		{
+			name:  "沒有人投票",
+			votes: map[string]string{},
+		},
+	}
+
+	m := NewUndercoverMode(newTestGameService())
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			room := newUndercoverRoom([]string{"c1", "c2", "c3", "c4", "u1"}, "u1")
+			for voter, target := range tt.votes {
+				vote(room, voter, target)
+			}
+
+			eliminated, votes, tie := m.tallyVotes(room)
+			if eliminated != tt.wantEliminated || tie != tt.wantTie {
+				t.Errorf("tallyVotes() = (%q, tie=%v), 預期 (%q, tie=%v)", eliminated, tie, tt.wantEliminated, tt.wantTie)
+			}
+			total := 0
+			for _, count := range votes {
+				total += count
+			}
+			if total != len(tt.votes) {
+				t.Errorf("總票數 = %d, 預期 %d", total, len(tt.votes))
+			}
+		})
+	}
+}
+
+func TestUndercoverCheckWinner(t *testing.T) {
+	tests := []struct {
+		name        string
+		players     []string
+		undercovers []string
+		eliminated  []string
+		want        string
+	}{
+		{
+			name:        "臥底少於平民時繼續遊戲",
+			players:     []string{"c1", "c2", "c3", "u1"},
+			undercovers: []string{"u1"},
+			want:        "",
+		},
+		{
+			name:        "臥底全部出局時平民獲勝",
+			players:     []string{"c1", "c2", "c3", "u1"},
+			undercovers: []string{"u1"},
+			eliminated:  []string{"u1"},
+			want:        models.UndercoverRoleCivilian,
+		},
+		{
+			name:        "臥底與平民人數相同時臥底獲勝",
+			players:     []string{"c1", "c2", "c3", "u1"},
+			undercovers: []string{"u1"},
+			eliminated:  []string{"c1", "c2"},
+			want:        models.UndercoverRoleUndercover,
+		},
+		{
+			name:        "兩名臥底只淘汰一名時繼續遊戲",
+			players:     []string{"c1", "c2", "c3", "c4", "c5", "u1", "u2"},
+			undercovers: []string{"u1", "u2"},
+			eliminated:  []string{"u1"},
+			want:        "",
+		},
+		{
+			name:        "兩名臥底都出局時平民獲勝",
+			players:     []string{"c1", "c2", "c3", "c4", "c5", "u1", "u2"},
+			undercovers: []string{"u1", "u2"},
+			eliminated:  []string{"u1", "c1", "u2"},
+			want:        models.UndercoverRoleCivilian,
+		},
+		{
+			name:        "兩名臥底撐到人數與平民相同時臥底獲勝",
+			players:     []string{"c1", "c2", "c3", "c4", "c5", "u1", "u2"},
+			undercovers: []string{"u1", "u2"},
+			eliminated:  []string{"c1", "c2", "c3"},
+			want:        models.UndercoverRoleUndercover,
+		},
+	}
+
+	m := NewUndercoverMode(newTestGameService())
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			room := newUndercoverRoom(tt.players, tt.undercovers...)
+			for _, playerID := range tt.eliminated {
+				room.Undercover.Eliminated[playerID] = true
+			}
+
+			if got := m.checkWinner(room); got != tt.want {
+				t.Errorf("checkWinner() = %q, 預期 %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUndercoverStartGameRoles(t *testing.T) {
+	tests := []struct {
+		players         int
+		wantUndercovers int
+	}{
+		{players: 3, wantUndercovers: 1},
+		{players: 6, wantUndercovers: 1},
+		{players: 7, wantUndercovers: 2},
+		{players: 10, wantUndercovers: 2},
+	}
+
+	m := NewUndercoverMode(newTestGameService())
+	for _, tt := range tests {
+		room := &models.Room{ID: "SPY001", Players: make(map[string]*models.Player)}
+		for i := 0; i < tt.players; i++ {
+			playerID := string(rune('a' + i))
+			room.Players[playerID] = &models.Player{ID: playerID, Name: playerID}
+		}
+
+		if err := m.StartGame(room); err != nil {
+			t.Fatalf("%d 位玩家時 StartGame() error = %v", tt.players, err)
+		}
+
+		undercovers := 0
+		for _, role := range room.Undercover.Roles {
+			if role == models.UndercoverRoleUndercover {
+				undercovers++
+			}
+		}
+		if undercovers != tt.wantUndercovers || len(room.Undercover.Roles) != tt.players {
+			t.Errorf("%d 位玩家時有 %d 名臥底（共 %d 個身份），預期 %d 名臥底",
+				tt.players, undercovers, len(room.Undercover.Roles), tt.wantUndercovers)
+		}
+		// 每輪描述加投票各一題，最多 players-2 輪
+		if want := (tt.players - 2) * 2; room.TotalQuestions != want {
+			t.Errorf("%d 位玩家時共 %d 題，預期 %d 題", tt.players, room.TotalQuestions, want)
+		}
+	}
+
+	room := &models.Room{ID: "SPY001", Players: map[string]*models.Player{"a": {ID: "a"}, "b": {ID: "b"}}}
+	if err := m.StartGame(room); err == nil {
+		t.Error("2 位玩家時 StartGame() 預期失敗")
+	}
+}
+
+func TestUndercoverPlayerLeftMidVote(t *testing.T) {
+	tests := []struct {
+		name        string
+		players     []string
+		undercovers []string
+		voted       []string // 離開前已投票的玩家
+		leaving     string
+		wantWinner  string
+	}{
+		{
+			name:        "未投票的平民離開後其餘玩家已全部投票",
+			players:     []string{"c1", "c2", "c3", "u1"},
+			undercovers: []string{"u1"},
+			voted:       []string{"c1", "c2", "u1"},
+			leaving:     "c3",
+			wantWinner:  "",
+		},
+		{
+			name:        "臥底離開時平民獲勝",
+			players:     []string{"c1", "c2", "c3", "u1"},
+			undercovers: []string{"u1"},
+			voted:       []string{"c1", "c2", "c3"},
+			leaving:     "u1",
+			wantWinner:  models.UndercoverRoleCivilian,
+		},
+		{
+			name:        "平民離開後人數與臥底相同時臥底獲勝",
+			players:     []string{"c1", "c2", "u1"},
+			undercovers: []string{"u1"},
+			voted:       []string{"c2", "u1"},
+			leaving:     "c1",
+			wantWinner:  models.UndercoverRoleUndercover,
+		},
+	}
+
+	m := NewUndercoverMode(newTestGameService())
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			room := newUndercoverRoom(tt.players, tt.undercovers...)
+			for _, voter := range tt.voted {
+				target := tt.undercovers[0]
+				if voter == target {
+					target = tt.leaving
+				}
+				vote(room, voter, target)
+			}
+			if m.AllAnswered(room) {
+				t.Fatal("離開前不應所有人都已投票")
+			}
+
+			// 與 Hub 移除玩家的流程一致：先移除玩家與答案，再通知遊戲模式
+			delete(room.Players, tt.leaving)
+			delete(room.Answers, tt.leaving)
+			if m.PlayerLeft(room, tt.leaving) {
+				t.Error("PlayerLeft() 不應更換主角")
+			}
+
+			if room.Undercover.IsAlive(tt.leaving) {
+				t.Errorf("離開的玩家 %s 應視同淘汰", tt.leaving)
+			}
+			if room.Undercover.Winner != tt.wantWinner {
+				t.Errorf("Winner = %q, 預期 %q", room.Undercover.Winner, tt.wantWinner)
+			}
+			if !m.AllAnswered(room) {
+				t.Error("離開的玩家不應讓投票卡住")
+			}
+		})
+	}
+
+	// 已分出勝負後離開不改變結果
+	room := newUndercoverRoom([]string{"c1", "c2", "u1"}, "u1")
+	room.Undercover.Winner = models.UndercoverRoleCivilian
+	m.PlayerLeft(room, "u1")
+	if room.Undercover.Eliminated["u1"] || room.Undercover.Winner != models.UndercoverRoleCivilian {
+		t.Errorf("分出勝負後離開改變了結果: %+v", room.Undercover)
+	}
+}
@@ -0,0 +1,53 @@
+package services
+
+import (
+	"math/rand"
+)
+
+// UndercoverWordPair 「誰是臥底」詞語組
+type UndercoverWordPair struct {
+	Category       string `json:"category"`
+	CivilianWord   string `json:"civilianWord"`
+	UndercoverWord string `json:"undercoverWord"`
+}
+
+// GetUndercoverWordPairs 獲取「誰是臥底」詞庫
+func GetUndercoverWordPairs() []UndercoverWordPair {
+	return []UndercoverWordPair{
+		// 飲食類
+		{Category: "food", CivilianWord: "珍珠奶茶", UndercoverWord: "椰果奶茶"},
+		{Category: "food", CivilianWord: "小籠包", UndercoverWord: "水煎包"},
+		{Category: "food", CivilianWord: "牛肉麵", UndercoverWord: "擔仔麵"},
+		{Category: "food", CivilianWord: "鳳梨酥", UndercoverWord: "太陽餅"},
+		{Category: "food", CivilianWord: "臭豆腐", UndercoverWord: "麻辣鴨血"},
+		{Category: "food", CivilianWord: "雞排", UndercoverWord: "鹽酥雞"},
+		{Category: "food", CivilianWord: "咖啡", UndercoverWord: "奶茶"},
+
+		// 生活類
+		{Category: "life", CivilianWord: "牙刷", UndercoverWord: "牙線"},
+		{Category: "life", CivilianWord: "捷運", UndercoverWord: "公車"},
+		{Category: "life", CivilianWord: "便利商店", UndercoverWord: "超市"},
+		{Category: "life", CivilianWord: "雨傘", UndercoverWord: "雨衣"},
+		{Category: "life", CivilianWord: "枕頭", UndercoverWord: "抱枕"},
+		{Category: "life", CivilianWord: "電梯", UndercoverWord: "手扶梯"},
+
+		// 科技類
+		{Category: "tech", CivilianWord: "筆電", UndercoverWord: "平板"},
+		{Category: "tech", CivilianWord: "耳機", UndercoverWord: "喇叭"},
+		{Category: "tech", CivilianWord: "LINE", UndercoverWord: "Messenger"},
+		{Category: "tech", CivilianWord: "YouTube", UndercoverWord: "Netflix"},
+
+		// 娛樂類
+		{Category: "entertainment", CivilianWord: "KTV", UndercoverWord: "演唱會"},
+		{Category: "entertainment", CivilianWord: "夜市", UndercoverWord: "市集"},
+		{Category: "entertainment", CivilianWord: "露營", UndercoverWord: "野餐"},
+		{Category: "entertainment", CivilianWord: "桌遊", UndercoverWord: "密室逃脫"},
+		{Category: "entertainment", CivilianWord: "游泳", UndercoverWord: "潛水"},
+	}
+}
+
+// GetRandomUndercoverWordPair 隨機選取一組詞語
+func GetRandomUndercoverWordPair() UndercoverWordPair {
+	pairs := GetUndercoverWordPairs()
+	return pairs[rand.Intn(len(pairs))]
+}
@@ -2,16 +2,21 @@ package websocket
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
+	"net"
 	"net/http"
+	"sync"
 	"time"
 
+	"kahoot-game/internal/logging"
+	"kahoot-game/internal/metrics"
 	"kahoot-game/internal/models"
 	"kahoot-game/internal/services"
 
-	"github.com/gorilla/websocket"
 	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
 )
 
 const (
@@ -24,18 +29,16 @@ const (
 	// 發送 ping 訊息的間隔時間，必須小於 pongWait
 	pingPeriod = (pongWait * 9) / 10
 
-	// 訊息的最大大小
-	maxMessageSize = 512
-)
+	// 未設定時的訊息大小上限
+	defaultMaxMessageSize = 512
 
-var upgrader = websocket.Upgrader{
-	ReadBufferSize:  1024,
-	WriteBufferSize: 1024,
-	CheckOrigin: func(r *http.Request) bool {
-		// 在生產環境中應該檢查 origin
-		return true
-	},
-}
+	// 被主持人踢出時的 WebSocket 關閉代碼
+	closeCodeKicked = 4001 // 踢出房間
+	closeCodeBanned = 4003 // 踢出並禁止再加入
+
+	// 房間被清理器關閉時的 WebSocket 關閉代碼
+	closeCodeRoomClosed = 4004
+)
 
 // Client WebSocket 客戶端結構
 type Client struct {
@@ -48,6 +51,15 @@ type Client struct {
 	// 發送訊息的通道
 	send chan []byte
 
+	// 單則訊息的大小上限
+	maxMessageSize int64
+
+	// 連線來源 IP（房間密碼錯誤次數依來源計算）
+	remoteIP string
+
+	// 帶有 clientId 欄位的日誌記錄器
+	baseLogger *slog.Logger
+
 	// Hub 引用
 	hub *Hub
 
@@ -55,6 +67,29 @@ type Client struct {
 	PlayerName string
 	RoomID     string
 	IsHost     bool
+
+	// 觀眾只接收房間廣播，不是房間玩家
+	IsSpectator bool
+
+	// 斷線重連用的 resume token
+	resumeToken string
+
+	// 已被重連的新連線接手
+	replaced bool
+
+	// 主持人憑證（建立房間或以憑證加入時取得）
+	hostToken string
+
+	// 被主持人踢出（banned 代表同時被禁止再加入），在 Hub 鎖內設定
+	kicked bool
+	banned bool
+
+	// 房間已被清理器關閉，在 Hub 鎖內設定
+	roomClosed bool
+
+	// 關閉時通知 writePump 送出剩餘訊息與 close frame 後關閉連線
+	done      chan struct{}
+	closeOnce sync.Once
 }
 
 // Message WebSocket 訊息結構
@@ -63,26 +98,50 @@ type Message struct {
 	Data interface{} `json:"data"`
 }
 
-// NewClient 創建新的客戶端
-func NewClient(conn *websocket.Conn, hub *Hub) *Client {
+// NewClient 創建新的客戶端，超過 maxMessageSize 位元組的訊息會使連線關閉
+func NewClient(conn *websocket.Conn, hub *Hub, maxMessageSize int64, logger *slog.Logger) *Client {
+	id := uuid.New().String()
 	return &Client{
-		conn: conn,
-		ID:   uuid.New().String(),
-		send: make(chan []byte, 256),
-		hub:  hub,
+		conn:           conn,
+		ID:             id,
+		send:           make(chan []byte, 256),
+		done:           make(chan struct{}),
+		maxMessageSize: maxMessageSize,
+		hub:            hub,
+		baseLogger:     logger.With(logging.KeyClientID, id),
+	}
+}
+
+// logger 帶有 clientId 與目前房間 roomId 欄位的日誌記錄器
+func (c *Client) logger() *slog.Logger {
+	logger := c.baseLogger
+	if logger == nil {
+		logger = slog.Default().With(logging.KeyClientID, c.ID)
+	}
+	if c.RoomID == "" {
+		return logger
 	}
+	return logger.With(logging.KeyRoomID, c.RoomID)
+}
+
+// disconnect 由伺服器端關閉連線（被踢出或房間關閉），readPump 結束時會向 Hub 註銷此連線
+// 不可直接送往 hub.unregister：readPump 仍在處理訊息，send 通道關閉後的回應會 panic
+func (c *Client) disconnect() {
+	c.closeOnce.Do(func() {
+		close(c.done)
+	})
 }
 
 // readPump 處理從客戶端讀取訊息
 func (c *Client) readPump() {
 	defer func() {
-		log.Printf("🔄 readPump 結束，發送註銷請求: %s", c.ID)
+		c.logger().Debug("readPump 結束，發送註銷請求")
 		c.hub.unregister <- c
 		c.conn.Close()
-		log.Printf("❌ readPump 清理完成: %s", c.ID)
+		c.logger().Debug("readPump 清理完成")
 	}()
 
-	c.conn.SetReadLimit(maxMessageSize)
+	c.conn.SetReadLimit(c.maxMessageSize)
 	c.conn.SetReadDeadline(time.Now().Add(pongWait))
 	c.conn.SetPongHandler(func(string) error {
 		c.conn.SetReadDeadline(time.Now().Add(pongWait))
@@ -93,9 +152,9 @@ func (c *Client) readPump() {
 		_, messageBytes, err := c.conn.ReadMessage()
 		if err != nil {
 			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
-				log.Printf("WebSocket 錯誤: %v", err)
+				c.logger().Warn("WebSocket 連線異常關閉", logging.Err(err))
 			} else {
-				log.Printf("WebSocket 正常關閉: %s, 錯誤: %v", c.ID, err)
+				c.logger().Debug("WebSocket 連線關閉", logging.Err(err))
 			}
 			break
 		}
@@ -103,7 +162,7 @@ func (c *Client) readPump() {
 		// 解析訊息
 		var msg Message
 		if err := json.Unmarshal(messageBytes, &msg); err != nil {
-			log.Printf("訊息解析錯誤: %v", err)
+			c.logger().Warn("訊息解析錯誤", logging.Err(err))
 			c.sendError("INVALID_MESSAGE", "訊息格式錯誤")
 			continue
 		}
@@ -126,7 +185,7 @@ func (c *Client) writePump() {
 		case message, ok := <-c.send:
 			c.conn.SetWriteDeadline(time.Now().Add(writeWait))
 			if !ok {
-				c.conn.WriteMessage(websocket.CloseMessage, []byte{})
+				c.conn.WriteMessage(websocket.CloseMessage, c.closeMessage())
 				return
 			}
 
@@ -155,6 +214,21 @@ func (c *Client) writePump() {
 				}
 			}
 
+		case <-c.done:
+			// 先送出已排入佇列的通知（例如 KICKED、ROOM_CLOSED）再關閉連線
+			c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			for n := len(c.send); n > 0; n-- {
+				message, ok := <-c.send
+				if !ok {
+					break
+				}
+				if err := c.conn.WriteMessage(websocket.TextMessage, message); err != nil {
+					return
+				}
+			}
+			c.conn.WriteMessage(websocket.CloseMessage, c.closeMessage())
+			return
+
 		case <-ticker.C:
 			c.conn.SetWriteDeadline(time.Now().Add(writeWait))
 			if err := c.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
@@ -164,26 +238,69 @@ func (c *Client) writePump() {
 	}
 }
 
+// closeMessage 關閉連線時送出的 close frame，被踢出時附上原因讓前端辨識
+func (c *Client) closeMessage() []byte {
+	switch {
+	case c.banned:
+		return websocket.FormatCloseMessage(closeCodeBanned, "banned_by_host")
+	case c.kicked:
+		return websocket.FormatCloseMessage(closeCodeKicked, "kicked_by_host")
+	case c.roomClosed:
+		return websocket.FormatCloseMessage(closeCodeRoomClosed, "room_closed")
+	}
+	return []byte{}
+}
+
 // handleMessage 處理客戶端訊息
 func (c *Client) handleMessage(msg *Message) {
-	log.Printf("📨 收到訊息 type=%s from=%s room=%s", msg.Type, c.ID, c.RoomID)
+	c.logger().Debug("收到訊息", "type", msg.Type)
+	metrics.MessageHandled(msg.Type)
+
+	// 觀眾只能觀看
+	if c.IsSpectator && msg.Type != "LEAVE_ROOM" && msg.Type != "PING" {
+		c.sendError("SPECTATOR_READ_ONLY", "觀眾只能觀看，無法操作遊戲")
+		return
+	}
+
 	switch msg.Type {
 	case "CREATE_ROOM":
 		c.handleCreateRoom(msg.Data)
 	case "JOIN_ROOM":
 		c.handleJoinRoom(msg.Data)
+	case "QUICK_JOIN":
+		c.handleQuickJoin(msg.Data)
 	case "JOIN_AS_HOST":
 		c.handleJoinAsHost(msg.Data)
+	case "JOIN_AS_SPECTATOR":
+		c.handleJoinAsSpectator(msg.Data)
+	case "ASSIGN_TEAM":
+		c.handleAssignTeam(msg.Data)
+	case "BALANCE_TEAMS":
+		c.handleBalanceTeams()
 	case "START_GAME":
 		c.handleStartGame(msg.Data)
+	case "PAUSE_GAME":
+		c.handlePauseGame()
+	case "RESUME_GAME":
+		c.handleResumeGame()
+	case "SKIP_QUESTION":
+		c.handleSkipQuestion()
+	case "EXTEND_TIME":
+		c.handleExtendTime(msg.Data)
+	case "END_GAME":
+		c.handleEndGame()
+	case "KICK_PLAYER":
+		c.handleKickPlayer(msg.Data)
 	case "SUBMIT_ANSWER":
 		c.handleSubmitAnswer(msg.Data)
 	case "LEAVE_ROOM":
 		c.handleLeaveRoom(msg.Data)
+	case "RESUME_SESSION":
+		c.handleResumeSession(msg.Data)
 	case "PING":
 		c.handlePing()
 	default:
-		log.Printf("未知訊息類型: %s", msg.Type)
+		c.logger().Warn("未知訊息類型", "type", msg.Type)
 		c.sendError("UNKNOWN_MESSAGE_TYPE", "未知的訊息類型")
 	}
 }
@@ -197,18 +314,67 @@ func (c *Client) handleCreateRoom(data interface{}) {
 	}
 
 	hostName, _ := dataMap["hostName"].(string)
-	totalQuestions := int(dataMap["totalQuestions"].(float64))
-	questionTimeLimit := int(dataMap["questionTimeLimit"].(float64))
+	gameMode, _ := dataMap["gameMode"].(string)
+	// 未指定題數與作答秒數時使用設定的預設值
+	totalQuestions, _ := dataMap["totalQuestions"].(float64)
+	questionTimeLimit, _ := dataMap["questionTimeLimit"].(float64)
 
 	if hostName == "" {
 		c.sendError("INVALID_HOST_NAME", "主持人名稱不能為空")
 		return
 	}
 
+	// 選題條件（分類、題目包、權重與排除清單）
+	var selection models.QuestionSelection
+	if err := decodeData(data, &selection); err != nil {
+		c.sendError("INVALID_QUESTION_SELECTION", "選題條件格式錯誤")
+		return
+	}
+
+	// 隊伍名稱（隊伍模式）
+	var teamConfig struct {
+		Teams []string `json:"teams"`
+	}
+	if err := decodeData(data, &teamConfig); err != nil {
+		c.sendError("INVALID_TEAMS", "隊伍設定格式錯誤")
+		return
+	}
+	passcode, _ := dataMap["passcode"].(string)
+	public, _ := dataMap["public"].(bool)
+
 	// 呼叫房間服務創建房間
-	room, err := c.hub.roomService.CreateRoom(hostName, totalQuestions, questionTimeLimit)
+	room, err := c.hub.roomService.CreateRoom(hostName, gameMode, int(totalQuestions), int(questionTimeLimit), &selection, teamConfig.Teams, passcode, public)
+	if errors.Is(err, services.ErrUnknownGameMode) {
+		c.sendError("INVALID_GAME_MODE", err.Error())
+		return
+	}
+	if errors.Is(err, services.ErrNotEnoughQuestions) {
+		c.sendError("NOT_ENOUGH_QUESTIONS", err.Error())
+		return
+	}
+	if errors.Is(err, services.ErrInvalidQuestionSelection) || errors.Is(err, services.ErrSelectionUnsupported) {
+		c.sendError("INVALID_QUESTION_SELECTION", err.Error())
+		return
+	}
+	if errors.Is(err, services.ErrInvalidTeams) || errors.Is(err, services.ErrTeamsUnsupported) {
+		c.sendError("INVALID_TEAMS", err.Error())
+		return
+	}
+	if errors.Is(err, services.ErrInvalidPasscode) {
+		c.sendError("INVALID_PASSCODE", err.Error())
+		return
+	}
 	if err != nil {
-		log.Printf("創建房間錯誤: %v", err)
+		c.logger().Error("創建房間失敗", logging.Err(err))
+		c.sendError("CREATE_ROOM_FAILED", "創建房間失敗")
+		return
+	}
+
+	// 簽發主持人憑證，之後以此憑證加入房間、控制遊戲與刪除房間
+	hostToken, err := c.hub.hostTokens.Issue(room.ID, hostName)
+	if err != nil {
+		c.logger().Error("簽發主持人憑證失敗", logging.KeyRoomID, room.ID, logging.Err(err))
+		c.hub.roomService.DeleteRoom(room.ID)
 		c.sendError("CREATE_ROOM_FAILED", "創建房間失敗")
 		return
 	}
@@ -217,10 +383,14 @@ func (c *Client) handleCreateRoom(data interface{}) {
 	c.PlayerName = hostName
 	c.RoomID = room.ID
 	c.IsHost = true
+	c.hostToken = hostToken
 
 	// 將客戶端加入房間
 	c.hub.AddClientToRoom(c, room.ID)
 
+	// 建立斷線重連工作階段
+	resumeToken := c.hub.createSession(c)
+
 	// 生成房間 URL（根據配置調整）
 	roomUrl := c.hub.BuildJoinURL(room.ID)
 	
@@ -230,15 +400,23 @@ func (c *Client) handleCreateRoom(data interface{}) {
 		Data: map[string]interface{}{
 			"roomId":            room.ID,
 			"hostName":          hostName,
-			"totalQuestions":    totalQuestions,
-			"questionTimeLimit": questionTimeLimit,
+			"gameMode":          room.GameMode,
+			"totalQuestions":    room.TotalQuestions,
+			"questionTimeLimit": room.QuestionTimeLimit,
+			"maxPlayers":        c.hub.roomService.MaxPlayersPerRoom(),
+			"questionSelection": room.QuestionSelection,
+			"teams":             room.Teams,
+			"hasPasscode":       room.HasPasscode,
+			"isPublic":          room.IsPublic,
 			"roomUrl":           roomUrl,
 			"joinCode":          room.ID, // 用於 QR Code 生成
+			"resumeToken":       resumeToken,
+			"hostToken":         hostToken,
 		},
 	}
 
 	c.sendMessage(&response)
-	log.Printf("🏠 房間 %s 創建成功，主持人: %s", room.ID, hostName)
+	c.baseLogger.Info("房間創建成功", logging.KeyRoomID, room.ID, "hostName", hostName)
 }
 
 // handleJoinRoom 處理加入房間
@@ -251,6 +429,7 @@ func (c *Client) handleJoinRoom(data interface{}) {
 
 	roomID, _ := dataMap["roomId"].(string)
 	playerName, _ := dataMap["playerName"].(string)
+	passcode, _ := dataMap["passcode"].(string)
 
 	if roomID == "" || playerName == "" {
 		c.sendError("INVALID_ROOM_DATA", "房間ID和玩家名稱不能為空")
@@ -258,13 +437,85 @@ func (c *Client) handleJoinRoom(data interface{}) {
 	}
 
 	// 呼叫房間服務加入房間
-	player, err := c.hub.roomService.AddPlayer(roomID, c.ID, playerName)
+	player, err := c.hub.roomService.AddPlayer(roomID, c.ID, playerName, passcode, c.remoteIP)
+	if errors.Is(err, services.ErrPlayerBanned) {
+		c.sendError("PLAYER_BANNED", err.Error())
+		return
+	}
+	if c.sendPasscodeError(err) {
+		return
+	}
 	if err != nil {
-		log.Printf("加入房間錯誤: %v", err)
+		c.baseLogger.Info("加入房間失敗", logging.KeyRoomID, roomID, logging.Err(err))
 		c.sendError("JOIN_ROOM_FAILED", err.Error())
 		return
 	}
 
+	c.completeJoin(roomID, player, nil)
+}
+
+// handleQuickJoin 處理快速配對：加入玩家最多且仍有空位的公開房間，沒有房間時建立新房間
+func (c *Client) handleQuickJoin(data interface{}) {
+	var req struct {
+		PlayerName string `json:"playerName"`
+		GameMode   string `json:"gameMode"`
+	}
+	if err := decodeData(data, &req); err != nil || req.PlayerName == "" {
+		c.sendError("INVALID_ROOM_DATA", "玩家名稱不能為空")
+		return
+	}
+
+	if c.RoomID != "" {
+		c.sendError("ALREADY_IN_ROOM", "已經在房間中")
+		return
+	}
+
+	rooms, err := c.hub.roomService.ListPublicRooms(req.GameMode)
+	if err != nil {
+		c.logger().Error("獲取公開房間列表失敗", logging.Err(err))
+		c.sendError("QUICK_JOIN_FAILED", "快速配對失敗")
+		return
+	}
+
+	// 依序嘗試加入，房間剛好額滿、已開始或名稱重複時換下一間
+	for _, info := range rooms {
+		player, err := c.hub.roomService.AddPlayer(info.ID, c.ID, req.PlayerName, "", c.remoteIP)
+		if err != nil {
+			c.baseLogger.Debug("快速配對略過房間", logging.KeyRoomID, info.ID, logging.Err(err))
+			continue
+		}
+
+		c.completeJoin(info.ID, player, map[string]interface{}{"quickJoin": true, "createdRoom": false})
+		return
+	}
+
+	room, err := c.hub.roomService.CreateQuickJoinRoom(req.GameMode)
+	if errors.Is(err, services.ErrUnknownGameMode) {
+		c.sendError("INVALID_GAME_MODE", err.Error())
+		return
+	}
+	if err != nil {
+		c.logger().Error("快速配對建立房間失敗", logging.Err(err))
+		c.sendError("QUICK_JOIN_FAILED", "快速配對失敗")
+		return
+	}
+
+	player, err := c.hub.roomService.AddPlayer(room.ID, c.ID, req.PlayerName, "", c.remoteIP)
+	if err != nil {
+		c.baseLogger.Warn("快速配對加入房間失敗", logging.KeyRoomID, room.ID, logging.Err(err))
+		c.sendError("QUICK_JOIN_FAILED", err.Error())
+		return
+	}
+
+	c.baseLogger.Info("快速配對建立房間", logging.KeyRoomID, room.ID)
+	c.completeJoin(room.ID, player, map[string]interface{}{"quickJoin": true, "createdRoom": true})
+}
+
+// completeJoin 玩家加入房間後設定連線、建立工作階段並通知房間
+// extra 會附加在回給本人的 PLAYER_JOINED 中（可為 nil）
+func (c *Client) completeJoin(roomID string, player *models.Player, extra map[string]interface{}) {
+	playerName := player.Name
+
 	// 設定客戶端資訊
 	c.PlayerName = playerName
 	c.RoomID = roomID
@@ -273,21 +524,29 @@ func (c *Client) handleJoinRoom(data interface{}) {
 	// 將客戶端加入房間
 	c.hub.AddClientToRoom(c, roomID)
 
+	// 建立斷線重連工作階段
+	resumeToken := c.hub.createSession(c)
+
 	// 獲取房間資訊
 	room, _ := c.hub.roomService.GetRoom(roomID)
 
-	// 發送加入成功訊息給該玩家
-	joinResponse := Message{
-		Type: "PLAYER_JOINED",
-		Data: map[string]interface{}{
-			"playerId":     player.ID,
-			"playerName":   player.Name,
-			"roomId":       roomID,
-			"totalPlayers": room.GetPlayerCount(),
-			"players":      room.GetPlayerList(),
-		},
+	// 發送加入成功訊息給該玩家（resume token 只回給本人）
+	joinData := map[string]interface{}{
+		"playerId":     player.ID,
+		"playerName":   player.Name,
+		"team":         player.Team,
+		"roomId":       roomID,
+		"totalPlayers": room.GetPlayerCount(),
+		"players":      room.GetPlayerList(),
+		"resumeToken":  resumeToken,
 	}
-	c.sendMessage(&joinResponse)
+	for key, value := range extra {
+		joinData[key] = value
+	}
+	c.sendMessage(&Message{
+		Type: "PLAYER_JOINED",
+		Data: joinData,
+	})
 
 	// 廣播給房間內其他玩家
 	broadcastMsg := Message{
@@ -295,6 +554,7 @@ func (c *Client) handleJoinRoom(data interface{}) {
 		Data: map[string]interface{}{
 			"playerId":     player.ID,
 			"playerName":   player.Name,
+			"team":         player.Team,
 			"totalPlayers": room.GetPlayerCount(),
 			"players":      room.GetPlayerList(),
 		},
@@ -304,7 +564,7 @@ func (c *Client) handleJoinRoom(data interface{}) {
 		c.hub.BroadcastToRoom(roomID, msgBytes)
 	}
 
-	log.Printf("👤 玩家 %s 加入房間 %s", playerName, roomID)
+	c.logger().Info("玩家加入房間", "playerName", playerName)
 }
 
 // handleJoinAsHost 處理主持人加入房間（房間已通過 HTTP API 創建）
@@ -317,16 +577,24 @@ func (c *Client) handleJoinAsHost(data interface{}) {
 
 	roomID, _ := dataMap["roomId"].(string)
 	hostName, _ := dataMap["hostName"].(string)
+	hostToken, _ := dataMap["hostToken"].(string)
 
 	if roomID == "" || hostName == "" {
 		c.sendError("INVALID_ROOM_DATA", "房間ID和主持人名稱不能為空")
 		return
 	}
 
+	// 只有持有建立房間時簽發的憑證才能以主持人身分加入
+	if _, err := c.hub.hostTokens.Verify(hostToken, roomID); err != nil {
+		c.logger().Info("主持人憑證驗證失敗", logging.Err(err))
+		c.sendError("INVALID_HOST_TOKEN", "主持人憑證無效或已過期")
+		return
+	}
+
 	// 驗證房間是否存在
 	room, err := c.hub.roomService.GetRoom(roomID)
 	if err != nil {
-		log.Printf("房間不存在: %v", err)
+		c.baseLogger.Info("房間不存在", logging.KeyRoomID, roomID, logging.Err(err))
 		c.sendError("ROOM_NOT_FOUND", "房間不存在")
 		return
 	}
@@ -335,10 +603,14 @@ func (c *Client) handleJoinAsHost(data interface{}) {
 	c.PlayerName = hostName
 	c.RoomID = roomID
 	c.IsHost = true
+	c.hostToken = hostToken
 
 	// 將客戶端加入房間
 	c.hub.AddClientToRoom(c, roomID)
 
+	// 建立斷線重連工作階段
+	resumeToken := c.hub.createSession(c)
+
 	// 發送加入成功訊息
 	roomUrl := c.hub.BuildJoinURL(roomID)
 
@@ -351,67 +623,192 @@ func (c *Client) handleJoinAsHost(data interface{}) {
 			"roomUrl":     roomUrl,
 			"totalPlayers": room.GetPlayerCount(),
 			"players":      room.GetPlayerList(),
+			"resumeToken":  resumeToken,
 		},
 	}
 	c.sendMessage(&joinResponse)
 
-	log.Printf("🎯 主持人 %s 通過 WebSocket 加入房間 %s", hostName, roomID)
+	c.logger().Info("主持人通過 WebSocket 加入房間", "hostName", hostName)
 }
 
-// handleStartGame 處理開始遊戲
-func (c *Client) handleStartGame(data interface{}) {
-	if !c.IsHost {
-		c.sendError("PERMISSION_DENIED", "只有主持人可以開始遊戲")
+// handleJoinAsSpectator 處理觀眾加入房間
+// 觀眾不會加入玩家列表、不佔玩家名額，遊戲進行中也可以加入，收到的訊息與玩家相同
+func (c *Client) handleJoinAsSpectator(data interface{}) {
+	dataMap, ok := data.(map[string]interface{})
+	if !ok {
+		c.sendError("INVALID_DATA", "加入房間資料格式錯誤")
 		return
 	}
 
-	// 獲取房間信息
-	room, err := c.hub.roomService.GetRoom(c.RoomID)
+	roomID, _ := dataMap["roomId"].(string)
+	spectatorName, _ := dataMap["spectatorName"].(string)
+	passcode, _ := dataMap["passcode"].(string)
+
+	if roomID == "" {
+		c.sendError("INVALID_ROOM_DATA", "房間ID不能為空")
+		return
+	}
+
+	if c.RoomID != "" {
+		c.sendError("ALREADY_IN_ROOM", "已經在房間中")
+		return
+	}
+
+	room, err := c.hub.roomService.GetRoom(roomID)
 	if err != nil {
-		log.Printf("獲取房間錯誤: %v", err)
+		c.baseLogger.Info("房間不存在", logging.KeyRoomID, roomID, logging.Err(err))
 		c.sendError("ROOM_NOT_FOUND", "房間不存在")
 		return
 	}
 
-	log.Printf("🔍 開始遊戲前檢查: 房間狀態=%s, 玩家數量=%d", room.Status, room.GetPlayerCount())
+	// 設有密碼的房間，觀眾同樣需要輸入密碼
+	if err := c.hub.roomService.CheckPasscode(roomID, passcode, c.remoteIP); err != nil {
+		if !c.sendPasscodeError(err) {
+			c.sendRequestError(err, "JOIN_SPECTATOR_FAILED")
+		}
+		return
+	}
+
+	if spectatorName == "" {
+		spectatorName = "觀眾"
+	}
+
+	// 設定客戶端資訊
+	c.PlayerName = spectatorName
+	c.IsHost = false
+	c.IsSpectator = true
+
+	// 將客戶端加入房間（只訂閱廣播）
+	c.hub.AddClientToRoom(c, roomID)
+
+	// 回傳房間現況，讓中途加入的觀眾也能顯示目前題目
+	snapshot := c.hub.roomSnapshot(room, "")
+	delete(snapshot, "hasAnswered")
+	snapshot["roomId"] = roomID
+	snapshot["spectatorId"] = c.ID
+	snapshot["spectatorName"] = spectatorName
 
-	// 檢查玩家數量
-	if room.GetPlayerCount() < 2 {
-		c.sendError("INSUFFICIENT_PLAYERS", "至少需要2個玩家才能開始遊戲")
+	c.sendMessage(&Message{
+		Type: "SPECTATOR_JOINED",
+		Data: snapshot,
+	})
+
+	c.logger().Info("觀眾加入房間", "spectatorName", spectatorName)
+}
+
+// handleAssignTeam 處理主持人將玩家分到指定隊伍
+func (c *Client) handleAssignTeam(data interface{}) {
+	if !c.requireHost("調整隊伍") {
 		return
 	}
 
-	// 如果房間已經結束，重置房間狀態以允許重新開始
-	if room.Status == models.RoomStatusFinished {
-		log.Printf("🔄 房間已結束，重置狀態以重新開始遊戲")
-		room.Status = models.RoomStatusWaiting
-		room.CurrentQuestion = 0
-		room.Answers = make(map[string]*models.Answer)
-		
-		// 重置所有玩家分數
-		for _, player := range room.Players {
-			player.Score = 0
-		}
-		
-		// 更新房間狀態
-		err = c.hub.roomService.UpdateRoom(room)
-		if err != nil {
-			log.Printf("重置房間狀態錯誤: %v", err)
-		}
+	var req struct {
+		PlayerID string `json:"playerId"`
+		Team     string `json:"team"`
+	}
+	if err := decodeData(data, &req); err != nil || req.PlayerID == "" || req.Team == "" {
+		c.sendError("INVALID_DATA", "分隊資料格式錯誤")
+		return
 	}
 
-	// 開始「2種人」遊戲
-	err = c.hub.gameService.StartTwoTypesGame(room)
+	room, err := c.hub.roomService.AssignTeam(c.RoomID, req.PlayerID, req.Team)
 	if err != nil {
-		log.Printf("開始遊戲錯誤: %v", err)
-		c.sendError("START_GAME_FAILED", err.Error())
+		c.logger().Info("分隊失敗", logging.Err(err))
+		c.sendError("INVALID_TEAMS", err.Error())
+		return
+	}
+
+	c.broadcastTeams(room)
+}
+
+// handleBalanceTeams 處理主持人要求重新平均分隊
+func (c *Client) handleBalanceTeams() {
+	if !c.requireHost("調整隊伍") {
 		return
 	}
 
-	// 更新房間狀態
-	err = c.hub.roomService.UpdateRoom(room)
+	room, err := c.hub.roomService.BalanceTeams(c.RoomID)
 	if err != nil {
-		log.Printf("更新房間狀態錯誤: %v", err)
+		c.logger().Info("自動分隊失敗", logging.Err(err))
+		c.sendError("INVALID_TEAMS", err.Error())
+		return
+	}
+
+	c.broadcastTeams(room)
+}
+
+// broadcastTeams 廣播目前的隊伍名單
+func (c *Client) broadcastTeams(room *models.Room) {
+	msg := Message{
+		Type: "TEAMS_UPDATED",
+		Data: map[string]interface{}{
+			"teams":   room.GetTeamRosters(),
+			"players": room.GetPlayerList(),
+		},
+	}
+
+	if msgBytes, err := json.Marshal(msg); err == nil {
+		c.hub.BroadcastToRoom(room.ID, msgBytes)
+	}
+
+	c.logger().Info("房間隊伍已更新")
+}
+
+// handleStartGame 處理開始遊戲
+func (c *Client) handleStartGame(data interface{}) {
+	// 快速配對的房間沒有主持人，房間中的玩家都可以開始遊戲
+	if !c.inQuickJoinRoom() && !c.requireHost("開始遊戲") {
+		return
+	}
+
+	// 檢查與開局在同一次原子更新中完成，避免重複開始
+	room, err := c.hub.roomService.MutateRoom(c.RoomID, func(room *models.Room) error {
+		mode, err := c.hub.gameService.ModeForRoom(room)
+		if err != nil {
+			return &requestError{code: "INVALID_GAME_MODE", message: err.Error()}
+		}
+
+		c.logger().Debug("開始遊戲前檢查", "status", room.Status, logging.KeyGameMode, mode.ID(), "players", room.GetPlayerCount())
+
+		// 遊戲進行中不可重複開始，避免重複啟動回合
+		if room.IsInProgress() {
+			return &requestError{code: "GAME_IN_PROGRESS", message: "遊戲已在進行中"}
+		}
+
+		// 檢查玩家數量
+		if room.GetPlayerCount() < mode.MinPlayers() {
+			return &requestError{code: "INSUFFICIENT_PLAYERS", message: fmt.Sprintf("至少需要%d個玩家才能開始遊戲", mode.MinPlayers())}
+		}
+
+		// 如果房間已經結束，重置房間狀態以允許重新開始
+		if room.Status == models.RoomStatusFinished {
+			c.logger().Debug("房間已結束，重置狀態以重新開始遊戲")
+			room.Status = models.RoomStatusWaiting
+			room.CurrentQuestion = 0
+			room.Answers = make(map[string]*models.Answer)
+
+			// 重置所有玩家分數
+			for _, player := range room.Players {
+				player.Score = 0
+			}
+		}
+
+		// 依遊戲模式開始遊戲
+		if err := mode.StartGame(room); err != nil {
+			c.logger().Debug("開始遊戲失敗", logging.Err(err))
+			return &requestError{code: "START_GAME_FAILED", message: err.Error()}
+		}
+
+		now := time.Now()
+		room.StartedAt = &now
+		room.FinishedAt = nil
+		room.SetPaused(false, now)
+
+		return nil
+	})
+	if err != nil {
+		c.sendRequestError(err, "START_GAME_FAILED")
+		return
 	}
 
 	// 廣播遊戲開始訊息
@@ -419,346 +816,226 @@ func (c *Client) handleStartGame(data interface{}) {
 		Type: "GAME_STARTED",
 		Data: map[string]interface{}{
 			"roomId":     room.ID,
+			"gameMode":   room.GameMode,
 			"firstHost":  room.CurrentHost,
 			"totalQuestions": room.TotalQuestions,
 		},
 	}
 
-	log.Printf("🎮 準備廣播 GAME_STARTED: 房間ID=%s, 客戶端房間ID=%s", room.ID, c.RoomID)
 	
 	if msgBytes, err := json.Marshal(gameStartMsg); err == nil {
-		log.Printf("🎮 廣播 GAME_STARTED 到房間 %s", c.RoomID)
 		c.hub.BroadcastToRoom(c.RoomID, msgBytes)
 	} else {
-		log.Printf("❌ 廣播 GAME_STARTED 失敗: %v", err)
+		c.logger().Error("廣播 GAME_STARTED 失敗", logging.Err(err))
 	}
 
-	// 發送第一題
-	c.sendFirstQuestion()
+	c.hub.roomLogs.Record(room.ID, models.RoomEventGameStarted, c.PlayerName, map[string]interface{}{
+		"gameMode":       room.GameMode,
+		"totalPlayers":   room.GetPlayerCount(),
+		"totalQuestions": room.TotalQuestions,
+		"firstHost":      room.CurrentHost,
+	})
 
-	log.Printf("🎮 房間 %s 開始遊戲，第一個主角: %s", c.RoomID, room.CurrentHost)
+	// 由房間排程器發送第一題並開始倒數
+	c.hub.dispatchScheduler(room.ID, schedulerCommand{kind: commandStartGame})
+
+	c.logger().Info("遊戲開始", logging.KeyGameMode, room.GameMode, "firstHost", room.CurrentHost)
 }
 
-// sendFirstQuestion 發送第一題
-func (c *Client) sendFirstQuestion() {
-	room, err := c.hub.roomService.GetRoom(c.RoomID)
-	if err != nil {
-		log.Printf("獲取房間錯誤: %v", err)
-		return
+// inQuickJoinRoom 此連線是否為快速配對房間中的玩家
+func (c *Client) inQuickJoinRoom() bool {
+	if c.RoomID == "" || c.IsHost || c.IsSpectator {
+		return false
 	}
 
-	log.Printf("🔍 檢查房間題目: 房間ID=%s, 題目數量=%d, 總題目設定=%d", c.RoomID, len(room.Questions), room.TotalQuestions)
-	
-	if len(room.Questions) == 0 {
-		log.Printf("❌ 房間 %s 沒有題目，嘗試重新載入題目", c.RoomID)
-		
-		// 嘗試重新載入題目
-		room.Questions = services.GetRandomQuestions(room.TotalQuestions)
-		log.Printf("🔄 重新載入後題目數量: %d", len(room.Questions))
-		
-		if len(room.Questions) == 0 {
-			log.Printf("❌ 重新載入題目失敗，無法開始遊戲")
-			c.sendError("NO_QUESTIONS", "無法載入遊戲題目")
-			return
-		}
-		
-		// 更新房間
-		err = c.hub.roomService.UpdateRoom(room)
-		if err != nil {
-			log.Printf("更新房間錯誤: %v", err)
-		}
+	room, err := c.hub.roomService.GetRoom(c.RoomID)
+	if err != nil || !room.QuickJoin {
+		return false
 	}
 
-	currentQuestion := room.Questions[room.CurrentQuestion-1]
+	_, isPlayer := room.GetPlayer(c.ID)
+	return isPlayer
+}
 
-	// 發送新題目訊息
-	newQuestionMsg := Message{
-		Type: "NEW_QUESTION",
-		Data: map[string]interface{}{
-			"questionId":      currentQuestion.ID,
-			"questionText":    currentQuestion.QuestionText,
-			"optionA":         currentQuestion.OptionA,
-			"optionB":         currentQuestion.OptionB,
-			"questionIndex":   room.CurrentQuestion - 1, // 前端使用 0-based index
-			"currentQuestion": room.CurrentQuestion,
-			"totalQuestions":  room.TotalQuestions,
-			"hostPlayer":      room.CurrentHost,
-			"timeLimit":       room.QuestionTimeLimit,
-			"question":        currentQuestion.QuestionText, // 前端可能使用這個字段
-		},
+// requireHost 檢查此連線是否為持有有效憑證的主持人
+func (c *Client) requireHost(action string) bool {
+	if !c.IsHost {
+		c.sendError("PERMISSION_DENIED", fmt.Sprintf("只有主持人可以%s", action))
+		return false
 	}
 
-	if msgBytes, err := json.Marshal(newQuestionMsg); err == nil {
-		c.hub.BroadcastToRoom(c.RoomID, msgBytes)
+	if _, err := c.hub.hostTokens.Verify(c.hostToken, c.RoomID); err != nil {
+		c.logger().Info("主持人憑證驗證失敗", logging.Err(err))
+		c.sendError("INVALID_HOST_TOKEN", "主持人憑證無效或已過期，請重新加入房間")
+		return false
 	}
 
-	log.Printf("📝 房間 %s 發送第 %d 題，主角: %s", c.RoomID, room.CurrentQuestion, room.CurrentHost)
-	
-	// 只有主持人啟動計時器，避免重複
-	if c.IsHost {
-		go c.startQuestionTimer(room.QuestionTimeLimit)
-	}
+	return true
 }
 
-// startQuestionTimer 開始答題倒數計時
-func (c *Client) startQuestionTimer(timeLimit int) {
+// gameControlRoom 檢查主持人權限與遊戲狀態，回傳可以接受控制指令的房間
+func (c *Client) gameControlRoom(action string) (*models.Room, bool) {
+	if !c.requireHost(action) {
+		return nil, false
+	}
+
 	room, err := c.hub.roomService.GetRoom(c.RoomID)
 	if err != nil {
-		return
+		c.sendRequestError(err, "ROOM_NOT_FOUND")
+		return nil, false
 	}
-	
-	log.Printf("⏰ 計時器啟動: 觸發者=%s, 是否主持人=%t, 房間=%s, 題目=%d", c.PlayerName, c.IsHost, c.RoomID, room.CurrentQuestion)
-	
-	// 設置計時器標識，防止重複啟動
-	timerKey := fmt.Sprintf("timer_%s_%d", c.RoomID, room.CurrentQuestion)
-	
-	for i := timeLimit; i >= 0; i-- {
-		// 檢查房間狀態，如果已經不在答題狀態就停止計時
-		room, err = c.hub.roomService.GetRoom(c.RoomID)
-		if err != nil || room.Status != models.RoomStatusQuestionDisplay {
-			log.Printf("⏹️ 計時器停止: 房間狀態改變或錯誤")
-			return
-		}
-		
-		// 檢查是否有新題目開始（避免舊計時器繼續）
-		currentTimerKey := fmt.Sprintf("timer_%s_%d", c.RoomID, room.CurrentQuestion)
-		if currentTimerKey != timerKey {
-			log.Printf("⏹️ 計時器停止: 新題目已開始")
-			return
-		}
-		
-		// 廣播倒數時間
-		timerMsg := Message{
-			Type: "TIMER_UPDATE",
-			Data: map[string]interface{}{
-				"timeLeft": i,
-				"questionIndex": room.CurrentQuestion,
-			},
-		}
-		
-		if msgBytes, err := json.Marshal(timerMsg); err == nil {
-			c.hub.BroadcastToRoom(c.RoomID, msgBytes)
-		}
-		
-		log.Printf("⏱️ 房間 %s 第 %d 題倒數: %d 秒", c.RoomID, room.CurrentQuestion, i)
-		
-		// 如果時間到了，處理答題結束
-		if i == 0 {
-			c.handleQuestionTimeout()
-			return
-		}
-		
-		time.Sleep(1 * time.Second)
+
+	if !room.IsInProgress() {
+		c.sendError("GAME_NOT_IN_PROGRESS", "遊戲未在進行中")
+		return nil, false
 	}
+
+	return room, true
 }
 
-// handleQuestionTimeout 處理答題時間結束
-func (c *Client) handleQuestionTimeout() {
-	room, err := c.hub.roomService.GetRoom(c.RoomID)
-	if err != nil {
+// handlePauseGame 處理主持人暫停遊戲
+func (c *Client) handlePauseGame() {
+	room, ok := c.gameControlRoom("暫停遊戲")
+	if !ok {
 		return
 	}
-	
-	// 廣播時間結束
-	timeoutMsg := Message{
-		Type: "QUESTION_TIMEOUT",
-		Data: map[string]interface{}{
-			"message": "答題時間結束",
-		},
+
+	if room.IsPaused {
+		c.sendError("GAME_ALREADY_PAUSED", "遊戲已經暫停")
+		return
 	}
-	
-	if msgBytes, err := json.Marshal(timeoutMsg); err == nil {
-		c.hub.BroadcastToRoom(c.RoomID, msgBytes)
+
+	c.hub.dispatchScheduler(room.ID, schedulerCommand{kind: commandPause, questionNum: room.CurrentQuestion})
+	c.logger().Info("主持人暫停遊戲", logging.KeyQuestionNum, room.CurrentQuestion)
+}
+
+// handleResumeGame 處理主持人繼續遊戲
+func (c *Client) handleResumeGame() {
+	room, ok := c.gameControlRoom("繼續遊戲")
+	if !ok {
+		return
 	}
-	
-	log.Printf("⏰ 房間 %s 第 %d 題答題時間結束", c.RoomID, room.CurrentQuestion)
-	
-	// 檢查倒數結束時的答題情況
-	totalPlayers := room.GetPlayerCount()
-	answeredPlayers := 0
-	hostAnswered := false
-	
-	if room.Answers != nil {
-		answeredPlayers = len(room.Answers)
-		// 檢查主角是否已答題
-		if _, exists := room.Answers[room.CurrentHost]; exists {
-			hostAnswered = true
-		}
+
+	if !room.IsPaused {
+		c.sendError("GAME_NOT_PAUSED", "遊戲沒有暫停")
+		return
 	}
-	
-	log.Printf("⏰ 時間結束統計: 總玩家=%d, 已答題=%d, 主角已答題=%t", totalPlayers, answeredPlayers, hostAnswered)
-	
-	if answeredPlayers > 0 && hostAnswered {
-		// 主角已答題，可以進行正常計分
-		log.Printf("📊 主角已答題，開始計算分數")
-		c.calculateAndShowResults(room)
-	} else if answeredPlayers > 0 && !hostAnswered {
-		// 有人答題但主角沒答題，這題無效
-		log.Printf("⚠️ 主角未答題，本題無效，3秒後進入下一題")
-		
-		// 廣播主角未答題訊息
-		invalidMsg := Message{
-			Type: "QUESTION_INVALID",
-			Data: map[string]interface{}{
-				"message": "主角未在時間內答題，本題無效",
-				"reason":  "host_no_answer",
-			},
-		}
-		
-		if msgBytes, err := json.Marshal(invalidMsg); err == nil {
-			c.hub.BroadcastToRoom(c.RoomID, msgBytes)
-		}
-		
-		go func() {
-			time.Sleep(3 * time.Second)
-			// 清除答案記錄
-			room.Answers = make(map[string]*models.Answer)
-			c.hub.roomService.UpdateRoom(room)
-			c.handleNextQuestion()
-		}()
-	} else {
-		// 沒人答題，直接進入下一題
-		log.Printf("📊 沒有玩家答題，3秒後進入下一題")
-		
-		// 廣播沒人答題訊息
-		noAnswerMsg := Message{
-			Type: "QUESTION_SKIPPED",
-			Data: map[string]interface{}{
-				"message": "時間到，沒有玩家答題",
-				"reason":  "no_answers",
-			},
-		}
-		
-		if msgBytes, err := json.Marshal(noAnswerMsg); err == nil {
-			c.hub.BroadcastToRoom(c.RoomID, msgBytes)
-		}
-		
-		go func() {
-			time.Sleep(3 * time.Second)
-			c.handleNextQuestion()
-		}()
+
+	c.hub.dispatchScheduler(room.ID, schedulerCommand{kind: commandResume, questionNum: room.CurrentQuestion})
+	c.logger().Info("主持人繼續遊戲", logging.KeyQuestionNum, room.CurrentQuestion)
+}
+
+// handleSkipQuestion 處理主持人略過當前題目
+func (c *Client) handleSkipQuestion() {
+	room, ok := c.gameControlRoom("略過題目")
+	if !ok {
+		return
 	}
+
+	if room.IsPaused {
+		c.sendError("GAME_PAUSED", "遊戲暫停中，請先繼續遊戲")
+		return
+	}
+
+	c.hub.dispatchScheduler(room.ID, schedulerCommand{kind: commandHostSkip, questionNum: room.CurrentQuestion})
+	c.logger().Info("主持人略過題目", logging.KeyQuestionNum, room.CurrentQuestion)
 }
 
-// handleNextQuestion 處理下一題邏輯
-func (c *Client) handleNextQuestion() {
-	room, err := c.hub.roomService.GetRoom(c.RoomID)
-	if err != nil {
-		log.Printf("獲取房間錯誤: %v", err)
+// handleExtendTime 處理主持人延長本題答題時間（預設 10 秒）
+func (c *Client) handleExtendTime(data interface{}) {
+	room, ok := c.gameControlRoom("延長答題時間")
+	if !ok {
 		return
 	}
-	
-	log.Printf("🔄 準備進入下一題: 當前題目=%d, 總題目=%d, 題庫大小=%d", room.CurrentQuestion, room.TotalQuestions, len(room.Questions))
-	
-	// 進入下一題
-	c.hub.gameService.NextTwoTypesQuestion(room)
-	
-	log.Printf("🔄 進入下一題後: 當前題目=%d, 總題目=%d, 房間狀態=%s", room.CurrentQuestion, room.TotalQuestions, room.Status)
-	
-	// 更新房間狀態
-	err = c.hub.roomService.UpdateRoom(room)
-	if err != nil {
-		log.Printf("更新房間狀態錯誤: %v", err)
+
+	if room.Status != models.RoomStatusQuestionDisplay {
+		c.sendError("INVALID_STATE", "當前不在答題階段")
+		return
 	}
-	
-	// 檢查遊戲是否結束
-	if room.Status == models.RoomStatusFinished {
-		// 遊戲結束，發送最終結果 (包含詳細統計)
-		finalStats := c.hub.gameService.GetFinalRanking(room)
-		
-		gameEndMsg := Message{
-			Type: "GAME_FINISHED",
-			Data: map[string]interface{}{
-				"finalStats":     finalStats,
-				"message":        "遊戲結束！",
-				"totalQuestions": room.TotalQuestions,
-			},
-		}
-		
-		if msgBytes, err := json.Marshal(gameEndMsg); err == nil {
-			c.hub.BroadcastToRoom(c.RoomID, msgBytes)
-		}
-		
-		log.Printf("🏁 房間 %s 遊戲結束，發送詳細統計給所有玩家", c.RoomID)
-	} else {
-		// 檢查是否還有題目可以發送
-		if room.CurrentQuestion <= len(room.Questions) {
-			log.Printf("📝 發送第 %d 題", room.CurrentQuestion)
-			c.sendNextQuestion()
-		} else {
-			log.Printf("❌ 沒有更多題目了，強制結束遊戲")
-			// 強制結束遊戲
-			room.Status = models.RoomStatusFinished
-			c.hub.roomService.UpdateRoom(room)
-			
-			finalResults := c.hub.gameService.GetFinalRanking(room)
-			gameEndMsg := Message{
-				Type: "GAME_FINISHED",
-				Data: map[string]interface{}{
-					"finalRanking": finalResults,
-					"message":      "遊戲結束！",
-				},
-			}
-			
-			if msgBytes, err := json.Marshal(gameEndMsg); err == nil {
-				c.hub.BroadcastToRoom(c.RoomID, msgBytes)
-			}
+
+	seconds := defaultExtendSeconds
+	if dataMap, ok := data.(map[string]interface{}); ok {
+		if value, exists := dataMap["seconds"].(float64); exists {
+			seconds = int(value)
 		}
 	}
+	if seconds < 1 || seconds > maxExtendSeconds {
+		c.sendError("INVALID_DATA", fmt.Sprintf("延長秒數必須在 1 到 %d 秒之間", maxExtendSeconds))
+		return
+	}
+
+	c.hub.dispatchScheduler(room.ID, schedulerCommand{kind: commandExtendTime, questionNum: room.CurrentQuestion, seconds: seconds})
+	c.logger().Info("主持人延長答題時間", logging.KeyQuestionNum, room.CurrentQuestion, "seconds", seconds)
 }
 
-// sendNextQuestion 發送下一題
-func (c *Client) sendNextQuestion() {
+// handleEndGame 處理主持人提前結束遊戲
+func (c *Client) handleEndGame() {
+	room, ok := c.gameControlRoom("結束遊戲")
+	if !ok {
+		return
+	}
+
+	c.hub.dispatchScheduler(room.ID, schedulerCommand{kind: commandEndGame, questionNum: room.CurrentQuestion})
+	c.logger().Info("主持人結束遊戲", logging.KeyQuestionNum, room.CurrentQuestion)
+}
+
+// handleKickPlayer 處理主持人踢出玩家，ban 為 true 時同時禁止該玩家再加入
+func (c *Client) handleKickPlayer(data interface{}) {
+	if !c.requireHost("踢出玩家") {
+		return
+	}
+
+	var req struct {
+		PlayerID string `json:"playerId"`
+		Ban      bool   `json:"ban"`
+	}
+	if err := decodeData(data, &req); err != nil || req.PlayerID == "" {
+		c.sendError("INVALID_DATA", "踢出玩家資料格式錯誤")
+		return
+	}
+
 	room, err := c.hub.roomService.GetRoom(c.RoomID)
 	if err != nil {
-		log.Printf("獲取房間錯誤: %v", err)
+		c.sendRequestError(err, "ROOM_NOT_FOUND")
 		return
 	}
 
-	if room.CurrentQuestion > len(room.Questions) {
-		log.Printf("沒有更多題目了，當前題目: %d, 總題目: %d", room.CurrentQuestion, len(room.Questions))
+	player, exists := room.GetPlayer(req.PlayerID)
+	if !exists {
+		c.sendError("PLAYER_NOT_FOUND", "玩家不在房間中")
 		return
 	}
 
-	log.Printf("🔍 發送題目檢查: 當前題目編號=%d, 題目總數=%d", room.CurrentQuestion, len(room.Questions))
-	
-	if room.CurrentQuestion < 1 || room.CurrentQuestion > len(room.Questions) {
-		log.Printf("❌ 題目編號超出範圍: %d (總共 %d 題)", room.CurrentQuestion, len(room.Questions))
-		return
+	if req.Ban {
+		if _, err := c.hub.roomService.BanPlayer(room.ID, player.ID, player.Name); err != nil {
+			c.logger().Error("禁止玩家加入失敗", logging.KeyPlayerID, player.ID, logging.Err(err))
+			c.sendRequestError(err, "KICK_FAILED")
+			return
+		}
 	}
 
-	currentQuestion := room.Questions[room.CurrentQuestion-1]
-	log.Printf("📝 準備發送第 %d 題: %s", room.CurrentQuestion, currentQuestion.QuestionText)
+	// 通知並關閉被踢出玩家的連線，再依一般離開流程移除玩家（包含重新選擇主角）
+	c.hub.kickPlayer(room.ID, player.ID, req.Ban)
+	c.hub.handlePlayerLeave(c.hub.offlineClient(player.ID, player.Name, room.ID, false))
 
-	// 確保房間狀態正確
-	room.Status = models.RoomStatusQuestionDisplay
-	
-	// 發送新題目訊息
-	newQuestionMsg := Message{
-		Type: "NEW_QUESTION",
+	kickedMsg := Message{
+		Type: "PLAYER_KICKED",
 		Data: map[string]interface{}{
-			"questionId":      currentQuestion.ID,
-			"questionText":    currentQuestion.QuestionText,
-			"optionA":         currentQuestion.OptionA,
-			"optionB":         currentQuestion.OptionB,
-			"questionIndex":   room.CurrentQuestion - 1, // 前端使用 0-based index
-			"currentQuestion": room.CurrentQuestion,
-			"totalQuestions":  room.TotalQuestions,
-			"hostPlayer":      room.CurrentHost,
-			"timeLimit":       room.QuestionTimeLimit,
-			"question":        currentQuestion.QuestionText, // 前端可能使用這個字段
+			"playerId":   player.ID,
+			"playerName": player.Name,
+			"banned":     req.Ban,
 		},
 	}
-
-	if msgBytes, err := json.Marshal(newQuestionMsg); err == nil {
-		c.hub.BroadcastToRoom(c.RoomID, msgBytes)
+	if msgBytes, err := json.Marshal(kickedMsg); err == nil {
+		c.hub.BroadcastToRoom(room.ID, msgBytes)
 	}
 
-	log.Printf("📝 房間 %s 發送第 %d 題，主角: %s", c.RoomID, room.CurrentQuestion, room.CurrentHost)
-	
-	// 啟動計時器（移除主持人限制，因為任何客戶端都可能觸發下一題）
-	log.Printf("⏰ 啟動第 %d 題計時器 (觸發者: %s)", room.CurrentQuestion, c.PlayerName)
-	go c.startQuestionTimer(room.QuestionTimeLimit)
+	c.hub.roomLogs.Record(room.ID, models.RoomEventPlayerKicked, player.Name, map[string]interface{}{
+		"playerId": player.ID,
+		"banned":   req.Ban,
+	})
+
+	c.logger().Info("主持人踢出玩家", logging.KeyPlayerID, player.ID, "playerName", player.Name, "banned", req.Ban)
 }
 
 // handleSubmitAnswer 處理提交答案
@@ -775,56 +1052,63 @@ func (c *Client) handleSubmitAnswer(data interface{}) {
 		return
 	}
 
-	timeUsed, _ := dataMap["timeUsed"].(float64)
+	// 驗證並寫入答案，同時判斷是否所有玩家都已答題
+	// 答題時間以伺服器記錄的題目開始時間計算，不採用客戶端回報的 timeUsed
+	allAnswered := false
+	var timeUsed float64
+	room, err := c.hub.roomService.MutateRoom(c.RoomID, func(room *models.Room) error {
+		// 檢查遊戲狀態
+		if room.Status != models.RoomStatusQuestionDisplay {
+			return &requestError{code: "INVALID_STATE", message: "當前不在答題階段"}
+		}
+		if room.IsPaused {
+			return &requestError{code: "GAME_PAUSED", message: "遊戲暫停中，暫時無法作答"}
+		}
 
-	// 獲取房間信息
-	room, err := c.hub.roomService.GetRoom(c.RoomID)
-	if err != nil {
-		log.Printf("獲取房間錯誤: %v", err)
-		c.sendError("ROOM_NOT_FOUND", "房間不存在")
-		return
-	}
+		mode, err := c.hub.gameService.ModeForRoom(room)
+		if err != nil {
+			return &requestError{code: "INVALID_GAME_MODE", message: err.Error()}
+		}
 
-	// 檢查遊戲狀態
-	if room.Status != models.RoomStatusQuestionDisplay {
-		c.sendError("INVALID_STATE", "當前不在答題階段")
-		return
-	}
+		// 依遊戲模式驗證並建立答案
+		timeUsed = room.QuestionElapsed(time.Now())
+		answerRecord, err := mode.SubmitAnswer(room, c.ID, answer, timeUsed)
+		if err != nil {
+			return &requestError{code: "SUBMIT_FAILED", message: err.Error()}
+		}
 
-	// 提交「2種人」答案
-	answerRecord, err := c.hub.gameService.SubmitTwoTypesAnswer(room, c.ID, answer, timeUsed)
+		// 存儲答案到房間
+		if room.Answers == nil {
+			room.Answers = make(map[string]*models.Answer)
+		}
+		room.Answers[c.ID] = answerRecord
+		if player, exists := room.GetPlayer(c.ID); exists {
+			player.LastActivity = time.Now()
+		}
+
+		allAnswered = c.checkAllPlayersAnswered(mode, room)
+		return nil
+	})
 	if err != nil {
-		log.Printf("提交答案錯誤: %v", err)
-		c.sendError("SUBMIT_FAILED", err.Error())
+		c.logger().Info("提交答案失敗", logging.Err(err))
+		c.sendRequestError(err, "SUBMIT_FAILED")
 		return
 	}
 
-	// 存儲答案到房間
-	if room.Answers == nil {
-		room.Answers = make(map[string]*models.Answer)
+	if answerRecord, exists := room.Answers[c.ID]; exists {
+		metrics.AnswerSubmitted(room.GameMode, answerRecord.ResponseTime)
 	}
-	room.Answers[c.ID] = answerRecord
 	
 	// 記錄答案提交詳情
 	isHost := c.ID == room.CurrentHost
-	log.Printf("📝 答案記錄: 玩家ID=%s, 玩家名=%s, 答案=%s, 是否主角=%t, 當前主角=%s", c.ID, c.PlayerName, answer, isHost, room.CurrentHost)
-	log.Printf("📊 當前答案總數: %d/%d", len(room.Answers), room.GetPlayerCount())
-	
-	// 記錄所有已答題的玩家
-	for playerID, ans := range room.Answers {
-		player, exists := room.GetPlayer(playerID)
-		playerName := "Unknown"
-		if exists {
-			playerName = player.Name
-		}
-		log.Printf("  - 玩家 %s (%s): %s", playerName, playerID, ans.Answer)
-	}
+	c.logger().Debug("答案已記錄", logging.KeyQuestionNum, room.CurrentQuestion, "answer", answer, "isHost", isHost, "answers", len(room.Answers), "players", room.GetPlayerCount())
 
-	// 更新房間狀態
-	err = c.hub.roomService.UpdateRoom(room)
-	if err != nil {
-		log.Printf("更新房間狀態錯誤: %v", err)
-	}
+	// 答題期間不記錄答案內容，計分後才寫入 scores_update 事件
+	c.hub.roomLogs.Record(c.RoomID, models.RoomEventAnswerSubmitted, c.PlayerName, map[string]interface{}{
+		"playerId":    c.ID,
+		"questionNum": room.CurrentQuestion,
+		"timeUsed":    timeUsed,
+	})
 
 	// 發送答案確認給提交者
 	confirmMsg := Message{
@@ -842,182 +1126,128 @@ func (c *Client) handleSubmitAnswer(data interface{}) {
 
 	// 廣播給其他玩家，告知有人已作答
 	// 給主持人發送包含答案的訊息，給其他玩家發送不含答案的訊息
-	roomClients := c.hub.rooms[c.RoomID]
+	audiences := map[string]map[string]interface{}{
+		// 主持人可以看到所有答案
+		audienceHosts: {
+			"playerId":   c.ID,
+			"playerName": c.PlayerName,
+			"isHost":     c.ID == room.CurrentHost,
+			"answer":     answer, // 主持人能看到答案
+		},
+		// 其他玩家只能看到已答題狀態
+		audiencePlayers: {
+			"playerId":   c.ID,
+			"playerName": c.PlayerName,
+			"isHost":     c.ID == room.CurrentHost,
+		},
+	}
 
-	for client := range roomClients {
-		if client == c {
-			continue // 跳過答題者本人
-		}
-		
-		var msgData map[string]interface{}
-		if client.IsHost {
-			// 主持人可以看到所有答案
-			msgData = map[string]interface{}{
-				"playerId":   c.ID,
-				"playerName": c.PlayerName,
-				"isHost":     c.ID == room.CurrentHost,
-				"answer":     answer, // 主持人能看到答案
-			}
-		} else {
-			// 其他玩家只能看到已答題狀態
-			msgData = map[string]interface{}{
-				"playerId":   c.ID,
-				"playerName": c.PlayerName,
-				"isHost":     c.ID == room.CurrentHost,
-			}
-		}
-		
+	for audience, msgData := range audiences {
 		broadcastMsg := Message{
 			Type: "PLAYER_ANSWERED",
 			Data: msgData,
 		}
 
 		if broadcastBytes, err := json.Marshal(broadcastMsg); err == nil {
-			client.send <- broadcastBytes
+			c.hub.publish(&roomEnvelope{
+				Kind:      envelopeMessage,
+				RoomID:    c.RoomID,
+				ExcludeID: c.ID, // 跳過答題者本人
+				Audience:  audience,
+				Payload:   broadcastBytes,
+			})
 		}
 	}
 
 	// 檢查是否所有玩家都已答題
-	if c.checkAllPlayersAnswered(room) {
-		// 所有人都答完了，計算分數並顯示結果
-		c.calculateAndShowResults(room)
+	if allAnswered {
+		// 所有人都答完了，通知排程器提前結算
+		c.hub.dispatchScheduler(c.RoomID, schedulerCommand{
+			kind:        commandAllAnswered,
+			questionNum: room.CurrentQuestion,
+		})
 	}
 
-	log.Printf("🎯 玩家 %s 提交答案: %s (耗時: %.2f秒), 已廣播給其他玩家", c.PlayerName, answer, timeUsed)
 }
 
 // checkAllPlayersAnswered 檢查是否所有玩家都已答題
-func (c *Client) checkAllPlayersAnswered(room *models.Room) bool {
-	totalPlayers := room.GetPlayerCount()
-	answeredPlayers := len(room.Answers)
-	
-	log.Printf("📊 答題進度: %d/%d 玩家已答題", answeredPlayers, totalPlayers)
-	return answeredPlayers >= totalPlayers
+func (c *Client) checkAllPlayersAnswered(mode services.GameMode, room *models.Room) bool {
+	return mode.AllAnswered(room)
 }
 
-// calculateAndShowResults 計算並顯示結果
-func (c *Client) calculateAndShowResults(room *models.Room) {
-	// 計算分數
-	scores := c.hub.gameService.CalculateTwoTypesScores(room, room.Answers)
-	
-	// 更新房間狀態
-	err := c.hub.roomService.UpdateRoom(room)
-	if err != nil {
-		log.Printf("更新房間狀態錯誤: %v", err)
-	}
-	
-	// 廣播分數結果
-	scoresMsg := Message{
-		Type: "SCORES_UPDATE",
-		Data: map[string]interface{}{
-			"scores":          scores,
-			"currentQuestion": room.CurrentQuestion,
-			"hostAnswer":      c.getHostAnswer(room),
-		},
-	}
-	
-	if msgBytes, err := json.Marshal(scoresMsg); err == nil {
-		c.hub.BroadcastToRoom(c.RoomID, msgBytes)
+// handleLeaveRoom 處理離開房間
+func (c *Client) handleLeaveRoom(data interface{}) {
+	if c.RoomID == "" {
+		return
 	}
-	
-	// 記錄題目歷史
-	c.recordQuestionHistory(room)
-	
-	// 延遲5秒後自動進入下一題，讓玩家有時間查看分數
-	go func() {
-		time.Sleep(5 * time.Second)
-		
-		// 重新獲取房間狀態（避免併發問題）
-		currentRoom, err := c.hub.roomService.GetRoom(c.RoomID)
-		if err != nil {
-			log.Printf("獲取房間錯誤: %v", err)
-			return
-		}
-		
-		// 清除答案記錄，準備下一題
-		currentRoom.Answers = make(map[string]*models.Answer)
-		
-		// 先更新房間狀態
-		err = c.hub.roomService.UpdateRoom(currentRoom)
-		if err != nil {
-			log.Printf("更新房間狀態錯誤: %v", err)
-		}
-		
-		log.Printf("🔄 開始處理下一題邏輯...")
-		c.handleNextQuestion()
-	}()
-	
-	log.Printf("📊 房間 %s 第 %d 題計分完成，5秒後自動下一題", c.RoomID, room.CurrentQuestion)
+
+	// 不可送往 hub.unregister：readPump 仍在處理訊息，send 通道關閉後的回應會 panic
+	c.hub.leaveRoom(c)
 }
 
-// recordQuestionHistory 記錄題目歷史
-func (c *Client) recordQuestionHistory(room *models.Room) {
-	if room.Answers == nil || len(room.Answers) == 0 {
-		log.Printf("⚠️ 沒有答案記錄，跳過歷史記錄")
+// handleResumeSession 處理斷線重連，沿用原本的玩家身分
+func (c *Client) handleResumeSession(data interface{}) {
+	dataMap, ok := data.(map[string]interface{})
+	if !ok {
+		c.sendError("INVALID_DATA", "重連資料格式錯誤")
 		return
 	}
-	
-	// 找到主角答案
-	hostAnswer := ""
-	for playerID, answer := range room.Answers {
-		if playerID == room.CurrentHost {
-			hostAnswer = answer.Answer
-			break
-		}
+
+	resumeToken, _ := dataMap["resumeToken"].(string)
+	if resumeToken == "" {
+		c.sendError("INVALID_DATA", "resume token 不能為空")
+		return
 	}
-	
-	// 創建題目歷史記錄
-	history := models.QuestionHistory{
-		QuestionID:    room.Questions[room.CurrentQuestion-1].ID,
-		QuestionNum:   room.CurrentQuestion,
-		HostPlayerID:  room.CurrentHost,
-		HostAnswer:    hostAnswer,
-		PlayerAnswers: make(map[string]*models.Answer),
+
+	if c.RoomID != "" {
+		c.sendError("ALREADY_IN_ROOM", "已在房間中")
+		return
 	}
-	
-	// 複製所有玩家答案
-	for playerID, answer := range room.Answers {
-		history.PlayerAnswers[playerID] = &models.Answer{
-			PlayerID:     answer.PlayerID,
-			QuestionID:   answer.QuestionID,
-			Answer:       answer.Answer,
-			IsCorrect:    answer.IsCorrect,
-			ResponseTime: answer.ResponseTime,
-			ScoreGained:  answer.ScoreGained,
-			WasHost:      answer.WasHost,
-			HostAnswer:   hostAnswer,
-			SubmittedAt:  answer.SubmittedAt,
-		}
+
+	if err := c.hub.resumeSession(c, resumeToken); err != nil {
+		c.logger().Info("重連失敗", logging.Err(err))
+		c.sendError("RESUME_FAILED", err.Error())
+		return
 	}
-	
-	// 添加到房間歷史
-	if room.GameHistory == nil {
-		room.GameHistory = make([]models.QuestionHistory, 0)
+
+	// 恢復玩家連線狀態（主持人不在玩家列表中）
+	room, err := c.hub.roomService.SetPlayerConnected(c.RoomID, c.ID, true)
+	if err != nil {
+		c.logger().Warn("恢復玩家連線狀態失敗", logging.Err(err))
+		c.sendError("ROOM_NOT_FOUND", "房間不存在")
+		return
 	}
-	room.GameHistory = append(room.GameHistory, history)
-	
-	log.Printf("📝 記錄第 %d 題歷史: 主角=%s, 答案=%s, 玩家答案數=%d", 
-		room.CurrentQuestion, room.CurrentHost, hostAnswer, len(history.PlayerAnswers))
-}
 
-// getHostAnswer 獲取主角答案
-func (c *Client) getHostAnswer(room *models.Room) string {
-	for playerID, answer := range room.Answers {
-		if playerID == room.CurrentHost {
-			return answer.Answer
-		}
+	// 發送房間現況給重連的玩家
+	snapshot := c.hub.roomSnapshot(room, c.ID)
+	snapshot["playerId"] = c.ID
+	snapshot["playerName"] = c.PlayerName
+	snapshot["roomId"] = c.RoomID
+	snapshot["isHost"] = c.IsHost
+	snapshot["resumeToken"] = resumeToken
+
+	resumeResponse := Message{
+		Type: "SESSION_RESUMED",
+		Data: snapshot,
 	}
-	return ""
-}
+	c.sendMessage(&resumeResponse)
 
-// handleLeaveRoom 處理離開房間
-func (c *Client) handleLeaveRoom(data interface{}) {
-	if c.RoomID == "" {
-		return
+	// 通知房間內其他人
+	reconnectMsg := Message{
+		Type: "PLAYER_RECONNECTED",
+		Data: map[string]interface{}{
+			"playerId":   c.ID,
+			"playerName": c.PlayerName,
+			"isHost":     c.IsHost,
+			"players":    room.GetPlayerList(),
+		},
 	}
 
-	// hub.unregister 會處理離開邏輯
-	c.hub.unregister <- c
+	if msgBytes, err := json.Marshal(reconnectMsg); err == nil {
+		c.hub.BroadcastToRoom(c.RoomID, msgBytes)
+	}
+
+	c.logger().Info("玩家重新連線", "playerName", c.PlayerName)
 }
 
 // handlePing 處理 ping 訊息
@@ -1037,11 +1267,48 @@ func (c *Client) sendMessage(msg *Message) {
 		select {
 		case c.send <- msgBytes:
 		default:
-			log.Printf("客戶端 %s 發送通道已滿", c.ID)
+			c.logger().Warn("發送通道已滿，捨棄訊息")
+			metrics.SendDropped()
 		}
 	}
 }
 
+// sendPasscodeError 房間密碼錯誤或暫停加入時回報對應的錯誤代碼
+func (c *Client) sendPasscodeError(err error) bool {
+	switch {
+	case errors.Is(err, services.ErrWrongPasscode):
+		c.sendError("WRONG_PASSCODE", err.Error())
+	case errors.Is(err, services.ErrPasscodeLocked):
+		c.sendError("PASSCODE_LOCKED", err.Error())
+	default:
+		return false
+	}
+	return true
+}
+
+// requestError 帶有錯誤代碼的請求錯誤，讓房間原子更新中的檢查失敗能回報正確代碼
+type requestError struct {
+	code    string
+	message string
+}
+
+func (e *requestError) Error() string {
+	return e.message
+}
+
+// sendRequestError 依錯誤類型發送錯誤訊息
+func (c *Client) sendRequestError(err error, fallbackCode string) {
+	var reqErr *requestError
+	switch {
+	case errors.As(err, &reqErr):
+		c.sendError(reqErr.code, reqErr.message)
+	case errors.Is(err, services.ErrRoomNotFound):
+		c.sendError("ROOM_NOT_FOUND", "房間不存在")
+	default:
+		c.sendError(fallbackCode, err.Error())
+	}
+}
+
 // sendError 發送錯誤訊息
 func (c *Client) sendError(code, message string) {
 	errorMsg := Message{
@@ -1054,15 +1321,38 @@ func (c *Client) sendError(code, message string) {
 	c.sendMessage(&errorMsg)
 }
 
+// decodeData 將訊息資料轉換為指定的結構
+func decodeData(data interface{}, target interface{}) error {
+	if data == nil {
+		return nil
+	}
+
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(raw, target)
+}
+
+// RemoteIP 連線來源 IP；不採用可由客戶端偽造的 X-Forwarded-For
+func RemoteIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
 // ServeWS 處理 WebSocket 連線升級
 func ServeWS(hub *Hub, w http.ResponseWriter, r *http.Request) *Client {
-	conn, err := upgrader.Upgrade(w, r, nil)
+	conn, err := hub.upgrader.Upgrade(w, r, nil)
 	if err != nil {
-		log.Printf("WebSocket 升級失敗: %v", err)
+		hub.logger.Warn("WebSocket 升級失敗", logging.Err(err))
 		return nil
 	}
 
-	client := NewClient(conn, hub)
+	client := NewClient(conn, hub, hub.maxMessageSize, hub.logger)
+	client.remoteIP = RemoteIP(r)
 	client.hub.register <- client
 
 	// 在新的 goroutine 中處理讀寫
@@ -0,0 +1,351 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"kahoot-game/internal/database"
+	"kahoot-game/internal/logging"
+	"kahoot-game/internal/metrics"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// 房間計時器租約的有效時間，負責倒數的節點會定期續約
+const schedulerLeaseTTL = 15 * time.Second
+
+// 跨節點事件種類
+const (
+	envelopeMessage     = "message"      // 傳給房間內的 WebSocket 連線
+	envelopeScheduler   = "scheduler"    // 傳給持有計時器租約的節點
+	envelopeRoomDeleted = "room_deleted" // 房間已刪除，各節點清除本地狀態
+	envelopeKick        = "kick"         // 玩家被踢出，持有該連線的節點關閉連線
+	envelopeRoomClosed  = "room_closed"  // 房間被清理器關閉，各節點通知並關閉房間內的連線
+)
+
+// 訊息接收對象
+const (
+	audienceHosts   = "hosts"   // 只有主持人
+	audiencePlayers = "players" // 只有玩家
+)
+
+// roomEnvelope 透過 Redis pub/sub 在節點間傳遞的房間事件
+// 每個房間使用自己的頻道 room_events:{roomId}，每個節點只投遞給本地的連線
+type roomEnvelope struct {
+	Kind      string           `json:"kind"`
+	Origin    string           `json:"origin"`
+	RoomID    string           `json:"roomId"`
+	ClientID  string           `json:"clientId,omitempty"`  // 只送給此連線
+	ExcludeID string           `json:"excludeId,omitempty"` // 排除此連線
+	Audience  string           `json:"audience,omitempty"`  // 空字串代表房間內所有連線
+	Banned    bool             `json:"banned,omitempty"`    // 踢出時是否同時禁止再加入
+	Payload   json.RawMessage  `json:"payload,omitempty"`
+	Command   *envelopeCommand `json:"command,omitempty"`
+}
+
+// envelopeCommand 可序列化的排程器指令
+type envelopeCommand struct {
+	Kind        schedulerCommandType `json:"kind"`
+	QuestionNum int                  `json:"questionNum"`
+	DelayMs     int64                `json:"delayMs"`
+	Seconds     int                  `json:"seconds,omitempty"`
+}
+
+// 只有租約仍屬於自己時才續約 / 釋放，避免誤刪其他節點取得的租約
+var (
+	renewLeaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0`)
+
+	releaseLeaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0`)
+)
+
+// distributed 是否透過 Redis 在節點間協調房間訊息與計時器
+// 房間資料改存放在記憶體時（未使用 Redis 或 Redis 無法存取）只在本節點內處理
+func (h *Hub) distributed() bool {
+	return h.redisClient != nil && h.roomService.SharedStore()
+}
+
+// publish 發佈房間事件（未持有 Hub 鎖時使用）
+// 沒有 Redis 時直接投遞給本地連線
+func (h *Hub) publish(env *roomEnvelope) error {
+	if h.distributed() {
+		return h.publishRemote(env)
+	}
+
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+	h.deliverLocked(env)
+	return nil
+}
+
+// publishLocked 發佈房間事件（已持有 Hub 鎖時使用）
+func (h *Hub) publishLocked(env *roomEnvelope) error {
+	if h.distributed() {
+		return h.publishRemote(env)
+	}
+
+	h.deliverLocked(env)
+	return nil
+}
+
+// publishRemote 發佈到房間的 Redis 頻道，所有節點（包含自己）都會收到
+func (h *Hub) publishRemote(env *roomEnvelope) error {
+	env.Origin = h.nodeID
+
+	data, err := json.Marshal(env)
+	if err != nil {
+		return fmt.Errorf("序列化房間事件失敗: %w", err)
+	}
+
+	ctx := context.Background()
+	if err := h.redisClient.Publish(ctx, database.Keys.RoomEventsChannel(env.RoomID), data).Err(); err != nil {
+		h.logger.Error("發佈房間事件失敗", logging.KeyRoomID, env.RoomID, logging.Err(err))
+		return fmt.Errorf("發佈房間事件失敗: %w", err)
+	}
+
+	return nil
+}
+
+// runSubscriber 訂閱所有房間事件頻道並交給本節點處理
+func (h *Hub) runSubscriber() {
+	ctx := context.Background()
+
+	pubsub := h.redisClient.PSubscribe(ctx, database.RoomEventsPrefix+"*")
+	defer pubsub.Close()
+
+	h.logger.Info("已訂閱房間事件", "nodeId", h.nodeID)
+
+	for msg := range pubsub.Channel() {
+		var env roomEnvelope
+		if err := json.Unmarshal([]byte(msg.Payload), &env); err != nil {
+			h.logger.Warn("房間事件解析錯誤", logging.Err(err))
+			continue
+		}
+
+		h.handleEnvelope(&env)
+	}
+}
+
+// handleEnvelope 處理從其他節點（或自己）收到的房間事件
+func (h *Hub) handleEnvelope(env *roomEnvelope) {
+	switch env.Kind {
+	case envelopeMessage:
+		h.mutex.RLock()
+		h.deliverLocked(env)
+		h.mutex.RUnlock()
+
+	case envelopeScheduler:
+		if env.Command == nil {
+			return
+		}
+		h.deliverSchedulerCommand(env.RoomID, schedulerCommand{
+			kind:        env.Command.Kind,
+			questionNum: env.Command.QuestionNum,
+			delay:       time.Duration(env.Command.DelayMs) * time.Millisecond,
+			seconds:     env.Command.Seconds,
+		})
+
+	case envelopeRoomDeleted:
+		h.clearRoomState(env.RoomID)
+
+	case envelopeKick:
+		h.kickLocalClient(env.RoomID, env.ClientID, env.Banned)
+
+	case envelopeRoomClosed:
+		h.closeLocalRoom(env.RoomID, []byte(env.Payload))
+	}
+}
+
+// deliverLocked 投遞給本節點房間內符合條件的連線（需已持有 Hub 鎖）
+func (h *Hub) deliverLocked(env *roomEnvelope) {
+	roomClients, exists := h.rooms[env.RoomID]
+	if !exists {
+		return
+	}
+
+	for client := range roomClients {
+		if env.ClientID != "" && client.ID != env.ClientID {
+			continue
+		}
+		if env.ExcludeID != "" && client.ID == env.ExcludeID {
+			continue
+		}
+		if env.Audience == audienceHosts && !client.IsHost {
+			continue
+		}
+		if env.Audience == audiencePlayers && client.IsHost {
+			continue
+		}
+
+		select {
+		case client.send <- []byte(env.Payload):
+		default:
+			client.logger().Warn("發送通道已滿，捨棄訊息")
+			metrics.SendDropped()
+		}
+	}
+}
+
+// dispatchScheduler 將指令交給負責此房間倒數的節點
+func (h *Hub) dispatchScheduler(roomID string, cmd schedulerCommand) {
+	if h.deliverSchedulerCommand(roomID, cmd) || !h.distributed() {
+		return
+	}
+
+	// 排程器在其他節點
+	h.publish(&roomEnvelope{
+		Kind:   envelopeScheduler,
+		RoomID: roomID,
+		Command: &envelopeCommand{
+			Kind:        cmd.kind,
+			QuestionNum: cmd.questionNum,
+			DelayMs:     cmd.delay.Milliseconds(),
+			Seconds:     cmd.seconds,
+		},
+	})
+}
+
+// deliverSchedulerCommand 本節點負責此房間時直接交給排程器，回傳是否已處理
+// 開始遊戲的指令會嘗試取得計時器租約，取得的節點負責整場遊戲的倒數；
+// 其他指令在沒有節點持有租約時（原本負責的節點已停止）由本節點接手
+func (h *Hub) deliverSchedulerCommand(roomID string, cmd schedulerCommand) bool {
+	h.schedulerMutex.Lock()
+	scheduler, exists := h.schedulers[roomID]
+	if !exists {
+		if cmd.kind == commandStartGame {
+			if h.acquireSchedulerLease(roomID) {
+				scheduler = h.startSchedulerLocked(roomID)
+			}
+		} else if h.distributed() {
+			scheduler = h.takeOverSchedulerLocked(roomID)
+		}
+		exists = scheduler != nil
+	}
+	h.schedulerMutex.Unlock()
+
+	if exists {
+		scheduler.send(cmd)
+	}
+	return exists
+}
+
+// startSchedulerLocked 建立並啟動房間排程器（需已持有 schedulerMutex 與計時器租約）
+func (h *Hub) startSchedulerLocked(roomID string) *RoomScheduler {
+	scheduler := newRoomScheduler(h, roomID)
+	h.schedulers[roomID] = scheduler
+	go scheduler.run()
+	return scheduler
+}
+
+// takeOverSchedulerLocked 遊戲進行中的房間沒有節點持有計時器租約時接手計時（需已持有 schedulerMutex）
+// 回傳 nil 代表房間不需要接手或租約仍屬於其他節點
+func (h *Hub) takeOverSchedulerLocked(roomID string) *RoomScheduler {
+	room, err := h.roomService.GetRoom(roomID)
+	if err != nil || !room.IsInProgress() {
+		return nil
+	}
+	if !h.acquireSchedulerLease(roomID) {
+		return nil
+	}
+
+	h.logger.Warn("房間沒有節點負責計時，由本節點接手", logging.KeyRoomID, roomID, "nodeId", h.nodeID)
+	scheduler := h.startSchedulerLocked(roomID)
+	scheduler.send(schedulerCommand{kind: commandTakeOver})
+	return scheduler
+}
+
+// runSchedulerWatchdog 定期檢查本節點有連線的房間，接手失去計時節點的房間
+// 沒有玩家送出指令時（例如大家都在等倒數），原本負責的節點停止後房間也能繼續
+func (h *Hub) runSchedulerWatchdog() {
+	ticker := time.NewTicker(schedulerLeaseTTL)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		h.adoptOrphanedRooms()
+	}
+}
+
+// adoptOrphanedRooms 接手本節點有連線、遊戲進行中但租約已失效的房間
+func (h *Hub) adoptOrphanedRooms() {
+	if !h.distributed() {
+		return
+	}
+
+	h.mutex.RLock()
+	roomIDs := make([]string, 0, len(h.rooms))
+	for roomID := range h.rooms {
+		roomIDs = append(roomIDs, roomID)
+	}
+	h.mutex.RUnlock()
+
+	for _, roomID := range roomIDs {
+		h.schedulerMutex.Lock()
+		if _, exists := h.schedulers[roomID]; !exists {
+			h.takeOverSchedulerLocked(roomID)
+		}
+		h.schedulerMutex.Unlock()
+	}
+}
+
+// acquireSchedulerLease 嘗試取得房間計時器租約（沒有 Redis 時一律成功）
+func (h *Hub) acquireSchedulerLease(roomID string) bool {
+	if !h.distributed() {
+		return true
+	}
+
+	ctx := context.Background()
+	acquired, err := h.redisClient.SetNX(ctx, database.Keys.SchedulerLeaseKey(roomID), h.nodeID, schedulerLeaseTTL).Result()
+	if err != nil {
+		h.logger.Error("取得房間計時器租約失敗", logging.KeyRoomID, roomID, logging.Err(err))
+		return false
+	}
+
+	if acquired {
+		h.logger.Info("本節點負責房間計時", logging.KeyRoomID, roomID, "nodeId", h.nodeID)
+	}
+	return acquired
+}
+
+// renewSchedulerLease 續約房間計時器租約，回傳租約是否仍屬於本節點
+func (h *Hub) renewSchedulerLease(roomID string) bool {
+	if !h.distributed() {
+		return true
+	}
+
+	ctx := context.Background()
+	renewed, err := renewLeaseScript.Run(ctx, h.redisClient,
+		[]string{database.Keys.SchedulerLeaseKey(roomID)},
+		h.nodeID, schedulerLeaseTTL.Milliseconds(),
+	).Int()
+	if err != nil {
+		h.logger.Error("續約房間計時器租約失敗", logging.KeyRoomID, roomID, logging.Err(err))
+		return false
+	}
+
+	return renewed == 1
+}
+
+// releaseSchedulerLease 釋放房間計時器租約
+func (h *Hub) releaseSchedulerLease(roomID string) {
+	if !h.distributed() {
+		return
+	}
+
+	ctx := context.Background()
+	err := releaseLeaseScript.Run(ctx, h.redisClient,
+		[]string{database.Keys.SchedulerLeaseKey(roomID)},
+		h.nodeID,
+	).Err()
+	if err != nil && err != redis.Nil {
+		h.logger.Warn("釋放房間計時器租約失敗", logging.KeyRoomID, roomID, logging.Err(err))
+	}
+}
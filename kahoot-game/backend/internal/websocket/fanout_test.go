@@ -0,0 +1,119 @@
+package websocket
+
+import (
+	"io"
+	"log/slog"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"kahoot-game/internal/config"
+	"kahoot-game/internal/database"
+	"kahoot-game/internal/services"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+)
+
+// newRedisTestHubs 兩個共用同一個 Redis 的節點
+func newRedisTestHubs(t *testing.T) (*miniredis.Miniredis, [2]*Hub, [2]*httptest.Server) {
+	t.Helper()
+
+	mr := miniredis.RunT(t)
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	var hubs [2]*Hub
+	var servers [2]*httptest.Server
+	for i := range hubs {
+		redisClient := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+		t.Cleanup(func() { redisClient.Close() })
+
+		store := services.NewRedisRoomStore(redisClient, logger)
+		hubs[i] = newTestHub(t, store, redisClient, config.WebSocketConfig{})
+		servers[i] = newTestServer(t, hubs[i])
+	}
+
+	waitFor(t, "節點訂閱房間事件", func() bool { return mr.PubSubNumPat() == len(hubs) })
+	return mr, hubs, servers
+}
+
+// hasScheduler 節點是否有此房間的排程器
+func hasScheduler(h *Hub, roomID string) bool {
+	h.schedulerMutex.Lock()
+	defer h.schedulerMutex.Unlock()
+	_, exists := h.schedulers[roomID]
+	return exists
+}
+
+func TestSchedulerTakeOver(t *testing.T) {
+	tests := []struct {
+		name     string
+		takeOver func(t *testing.T, hub *Hub, roomID string, players []*testConn)
+	}{
+		{
+			name: "下一個指令觸發接手",
+			takeOver: func(t *testing.T, hub *Hub, roomID string, players []*testConn) {
+				for _, player := range players {
+					player.send("SUBMIT_ANSWER", map[string]interface{}{"answer": "A"})
+				}
+			},
+		},
+		{
+			name: "定期檢查觸發接手",
+			takeOver: func(t *testing.T, hub *Hub, roomID string, players []*testConn) {
+				hub.adoptOrphanedRooms()
+				if !hasScheduler(hub, roomID) {
+					t.Fatal("定期檢查後未接手房間")
+				}
+				for _, player := range players {
+					player.send("SUBMIT_ANSWER", map[string]interface{}{"answer": "A"})
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mr, hubs, servers := newRedisTestHubs(t)
+			owner, survivor := hubs[0], hubs[1]
+
+			host := dial(t, servers[0])
+			roomID := host.createRoom(nil)
+			players := []*testConn{dial(t, servers[1]), dial(t, servers[1])}
+			players[0].joinRoom(roomID, "小明")
+			players[1].joinRoom(roomID, "小華")
+
+			host.send("START_GAME", nil)
+			for _, player := range players {
+				player.expect("NEW_QUESTION")
+			}
+			leaseKey := database.Keys.SchedulerLeaseKey(roomID)
+			if lease, _ := mr.Get(leaseKey); lease != owner.nodeID {
+				t.Fatalf("租約持有者 = %q, 預期開始遊戲的節點", lease)
+			}
+
+			// 負責計時的節點停止，留下尚未過期的租約
+			owner.StopRoomScheduler(roomID)
+			mr.Set(leaseKey, "stopped-node")
+			mr.SetTTL(leaseKey, schedulerLeaseTTL)
+
+			survivor.adoptOrphanedRooms()
+			if hasScheduler(survivor, roomID) {
+				t.Fatal("租約仍有效時不應接手")
+			}
+
+			mr.FastForward(schedulerLeaseTTL + time.Second)
+			tt.takeOver(t, survivor, roomID, players)
+
+			for _, player := range players {
+				player.expect("SCORES_UPDATE")
+			}
+			if lease, _ := mr.Get(leaseKey); lease != survivor.nodeID {
+				t.Errorf("租約持有者 = %q, 預期接手的節點", lease)
+			}
+			if !hasScheduler(survivor, roomID) {
+				t.Error("接手的節點沒有排程器")
+			}
+		})
+	}
+}
@@ -3,13 +3,21 @@ package websocket
 import (
 	"encoding/json"
 	"fmt"
-	"log"
+	"log/slog"
+	"net/http"
 	"strings"
 	"sync"
 	"time"
 
+	"kahoot-game/internal/config"
+	"kahoot-game/internal/logging"
+	"kahoot-game/internal/metrics"
 	"kahoot-game/internal/models"
 	"kahoot-game/internal/services"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
 )
 
 // Hub WebSocket 連線管理中心
@@ -29,62 +37,109 @@ type Hub struct {
 	// 廣播訊息通道
 	broadcast chan []byte
 
-	// 房間廣播通道
-	roomBroadcast chan *RoomMessage
-
 	// 服務層依賴
 	roomService *services.RoomService
 	gameService *services.GameService
+	roomLogs    *services.RoomLogService
+	hostTokens  *services.HostTokenService
 	frontendURL string
 
+	// 跨節點房間事件（nil 時只在本節點內投遞）
+	redisClient *redis.Client
+	nodeID      string
+
 	// 互斥鎖
 	mutex sync.RWMutex
-}
 
-// RoomMessage 房間訊息結構
-type RoomMessage struct {
-	RoomID  string `json:"roomId"`
-	Message []byte `json:"message"`
+	// 房間回合排程器（與 mutex 分開加鎖，避免持有 Hub 鎖時等待排程器）
+	schedulers     map[string]*RoomScheduler
+	schedulerMutex sync.Mutex
+
+	// 斷線重連工作階段（key 為 resume token）
+	sessions     map[string]*playerSession
+	sessionMutex sync.Mutex
+	resumeGrace  time.Duration
+
+	// WebSocket 連線升級與單則訊息大小上限
+	upgrader       websocket.Upgrader
+	maxMessageSize int64
+
+	// 房間清理器（未啟動時為 nil）
+	janitor *Janitor
+
+	logger *slog.Logger
 }
 
 // NewHub 創建新的 Hub
-func NewHub(roomService *services.RoomService, gameService *services.GameService, frontendURL string) *Hub {
-	return &Hub{
-		clients:       make(map[*Client]bool),
-		rooms:         make(map[string]map[*Client]bool),
-		register:      make(chan *Client),
-		unregister:    make(chan *Client),
-		broadcast:     make(chan []byte),
-		roomBroadcast: make(chan *RoomMessage),
-		roomService:   roomService,
-		gameService:   gameService,
-		frontendURL:   strings.TrimSuffix(frontendURL, "/"),
+// redisClient 不為 nil 且房間資料存放在 Redis 時，房間訊息會透過 Redis pub/sub 傳給所有節點，讓多個後端實例共用房間；
+// hostTokens 簽發與驗證主持人憑證；wsConfig 為連線緩衝區大小、訊息大小上限與斷線後等待玩家重連的時間
+func NewHub(roomService *services.RoomService, gameService *services.GameService, roomLogs *services.RoomLogService, hostTokens *services.HostTokenService, redisClient *redis.Client, frontendURL string, wsConfig config.WebSocketConfig, logger *slog.Logger) *Hub {
+	resumeGrace := wsConfig.ResumeGracePeriod
+	if resumeGrace <= 0 {
+		resumeGrace = defaultResumeGracePeriod
 	}
+	maxMessageSize := wsConfig.MaxMessageSize
+	if maxMessageSize <= 0 {
+		maxMessageSize = defaultMaxMessageSize
+	}
+
+	upgrader := websocket.Upgrader{
+		ReadBufferSize:  wsConfig.ReadBufferSize,
+		WriteBufferSize: wsConfig.WriteBufferSize,
+		CheckOrigin: func(r *http.Request) bool {
+			// 在生產環境中應該檢查 origin
+			return true
+		},
+	}
+
+	h := &Hub{
+		clients:        make(map[*Client]bool),
+		rooms:          make(map[string]map[*Client]bool),
+		register:       make(chan *Client),
+		unregister:     make(chan *Client),
+		broadcast:      make(chan []byte),
+		roomService:    roomService,
+		gameService:    gameService,
+		roomLogs:       roomLogs,
+		hostTokens:     hostTokens,
+		frontendURL:    strings.TrimSuffix(frontendURL, "/"),
+		redisClient:    redisClient,
+		nodeID:         uuid.New().String(),
+		schedulers:     make(map[string]*RoomScheduler),
+		sessions:       make(map[string]*playerSession),
+		resumeGrace:    resumeGrace,
+		upgrader:       upgrader,
+		maxMessageSize: maxMessageSize,
+		logger:         logger,
+	}
+
+	// 房間刪除時一併停止排程器並清除重連工作階段
+	roomService.OnRoomDeleted(h.handleRoomDeleted)
+
+	return h
 }
 
 // Run 啟動 Hub
 func (h *Hub) Run() {
-	log.Println("🚀 WebSocket Hub 已啟動")
+	h.logger.Info("WebSocket Hub 已啟動", "nodeId", h.nodeID)
+
+	// 接收其他節點發佈的房間事件，並接手失去計時節點的房間
+	if h.redisClient != nil {
+		go h.runSubscriber()
+		go h.runSchedulerWatchdog()
+	}
 
 	for {
 		select {
 		case client := <-h.register:
-			log.Printf("🔄 Hub 收到註冊請求: %s", client.ID)
 			h.registerClient(client)
-			log.Printf("✅ Hub 註冊完成: %s", client.ID)
 
 		case client := <-h.unregister:
-			log.Printf("🔄 Hub 收到註銷請求: %s", client.ID)
 			h.unregisterClient(client)
-			log.Printf("❌ Hub 註銷完成: %s", client.ID)
 
 		case message := <-h.broadcast:
-			log.Printf("📡 Hub 處理全域廣播")
+			h.logger.Debug("Hub 處理全域廣播")
 			h.broadcastToAll(message)
-
-		case roomMsg := <-h.roomBroadcast:
-			log.Printf("📡 Hub 處理房間廣播: 房間=%s", roomMsg.RoomID)
-			h.broadcastToRoom(roomMsg.RoomID, roomMsg.Message)
 		}
 	}
 }
@@ -96,7 +151,7 @@ func (h *Hub) registerClient(client *Client) {
 
 	h.clients[client] = true
 
-	log.Printf("✅ 客戶端已註冊: %s (總計: %d)", client.ID, len(h.clients))
+	h.logger.Debug("客戶端已註冊", logging.KeyClientID, client.ID, "clients", len(h.clients))
 
 	// 發送歡迎訊息
 	welcomeMsg := Message{
@@ -119,8 +174,13 @@ func (h *Hub) unregisterClient(client *Client) {
 
 	if _, ok := h.clients[client]; ok {
 		// 1. 先處理離開邏輯（在關閉通道前）
-		if client.RoomID != "" {
-			h.handlePlayerLeaveInternal(client)
+		// 已被新連線接手的舊連線、被踢出的連線、房間已關閉的連線與觀眾不需處理；意外斷線則保留玩家資料等待重連
+		if client.RoomID != "" && !client.replaced && !client.kicked && !client.roomClosed && !client.IsSpectator {
+			if h.suspendSession(client) {
+				h.markPlayerDisconnected(client)
+			} else {
+				h.handlePlayerLeaveInternal(client)
+			}
 		}
 
 		// 2. 從特定房間移除（而不是遍歷所有房間）
@@ -134,9 +194,9 @@ func (h *Hub) unregisterClient(client *Client) {
 		// 4. 最後關閉通道
 		close(client.send)
 
-		log.Printf("❌ 客戶端已註銷: %s (剩餘: %d)", client.ID, len(h.clients))
+		h.logger.Debug("客戶端已註銷", logging.KeyClientID, client.ID, "clients", len(h.clients))
 	} else {
-		log.Printf("⚠️ 嘗試註銷不存在的客戶端: %s", client.ID)
+		h.logger.Warn("嘗試註銷不存在的客戶端", logging.KeyClientID, client.ID)
 	}
 }
 
@@ -152,7 +212,7 @@ func (h *Hub) AddClientToRoom(client *Client, roomID string) {
 	h.rooms[roomID][client] = true
 	client.RoomID = roomID
 
-	log.Printf("🏠 客戶端 %s 加入房間 %s", client.ID, roomID)
+	h.logger.Debug("客戶端加入房間", logging.KeyClientID, client.ID, logging.KeyRoomID, roomID)
 }
 
 // removeClientFromRoom 從房間移除客戶端
@@ -163,7 +223,7 @@ func (h *Hub) removeClientFromRoom(client *Client, roomID string) {
 		// 如果房間沒有客戶端了，刪除房間
 		if len(h.rooms[roomID]) == 0 {
 			delete(h.rooms, roomID)
-			log.Printf("🗑️ 房間 %s 已清空並移除", roomID)
+			h.logger.Debug("房間連線已清空並移除", logging.KeyRoomID, roomID)
 		}
 	}
 }
@@ -177,54 +237,72 @@ func (h *Hub) broadcastToAll(message []byte) {
 		select {
 		case client.send <- message:
 		default:
+			metrics.SendDropped()
 			delete(h.clients, client)
 			close(client.send)
 		}
 	}
 }
 
-// BroadcastToRoom 廣播給特定房間
+// BroadcastToRoom 廣播給特定房間（所有節點上的連線）
 func (h *Hub) BroadcastToRoom(roomID string, message []byte) {
-	h.roomBroadcast <- &RoomMessage{
+	h.publish(&roomEnvelope{
+		Kind:    envelopeMessage,
 		RoomID:  roomID,
-		Message: message,
-	}
+		Payload: message,
+	})
 }
 
-// broadcastToRoom 內部廣播給房間
-func (h *Hub) broadcastToRoom(roomID string, message []byte) {
-	h.mutex.RLock()
-	defer h.mutex.RUnlock()
+// broadcastToRoomExclude 內部廣播給房間（排除指定客戶端，已持有鎖）
+func (h *Hub) broadcastToRoomExclude(roomID string, message []byte, excludeClient *Client) {
+	env := &roomEnvelope{
+		Kind:    envelopeMessage,
+		RoomID:  roomID,
+		Payload: message,
+	}
+	if excludeClient != nil {
+		env.ExcludeID = excludeClient.ID
+	}
 
-	if roomClients, exists := h.rooms[roomID]; exists {
-		for client := range roomClients {
-			select {
-			case client.send <- message:
-			default:
-				delete(roomClients, client)
-				close(client.send)
-			}
-		}
+	h.publishLocked(env)
+}
+
+// offlineClient 代表已沒有連線的玩家（被踢出或重連逾時），用於走一般離開流程
+func (h *Hub) offlineClient(playerID, playerName, roomID string, isHost bool) *Client {
+	return &Client{
+		ID:         playerID,
+		PlayerName: playerName,
+		RoomID:     roomID,
+		IsHost:     isHost,
+		hub:        h,
+		baseLogger: h.logger.With(logging.KeyClientID, playerID),
 	}
 }
 
-// broadcastToRoomExclude 內部廣播給房間（排除指定客戶端）
-func (h *Hub) broadcastToRoomExclude(roomID string, message []byte, excludeClient *Client) {
-	if roomClients, exists := h.rooms[roomID]; exists {
-		for client := range roomClients {
-			// 跳過已離開的客戶端
-			if client == excludeClient {
-				continue
-			}
+// leaveRoom 玩家主動離開房間：移出房間並清除重連工作階段
+// 連線保持開啟，之後可以再建立或加入其他房間
+func (h *Hub) leaveRoom(client *Client) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
 
-			select {
-			case client.send <- message:
-			default:
-				// 如果發送失敗，標記為需要清理（但不在這裡直接刪除，避免併發問題）
-				log.Printf("⚠️ 向客戶端 %s 發送消息失敗", client.ID)
-			}
-		}
+	roomID := client.RoomID
+	if roomID == "" {
+		return
+	}
+
+	h.dropPlayerSession(roomID, client.ID)
+	if !client.IsSpectator {
+		h.handlePlayerLeaveInternal(client)
 	}
+	h.removeClientFromRoom(client, roomID)
+
+	client.logger().Info("已離開房間")
+	client.RoomID = ""
+	client.PlayerName = ""
+	client.IsHost = false
+	client.IsSpectator = false
+	client.resumeToken = ""
+	client.hostToken = ""
 }
 
 // handlePlayerLeave 處理玩家離開（外部調用）
@@ -240,22 +318,52 @@ func (h *Hub) handlePlayerLeaveInternal(client *Client) {
 		return
 	}
 
-	log.Printf("👋 處理玩家離開: %s 從房間 %s", client.PlayerName, client.RoomID)
+	client.logger().Info("處理玩家離開", "playerName", client.PlayerName)
 
 	roomClients := h.rooms[client.RoomID]
 
-	// 從房間服務中移除玩家
-	if err := h.roomService.RemovePlayer(client.RoomID, client.ID); err != nil {
-		log.Printf("❌ 移除玩家失敗: %v", err)
+	// 移除玩家並交給遊戲模式調整狀態（例如重新選擇主角），在同一次原子更新中完成
+	hostChanged := false
+	resetAnswers := false
+	shouldSkipCurrentQuestion := false
+
+	room, err := h.roomService.RemovePlayer(client.RoomID, client.ID, func(room *models.Room) {
+		hostChanged = false
+		resetAnswers = false
+		shouldSkipCurrentQuestion = false
+
+		// 清除離開玩家的答案
+		if room.Answers != nil {
+			delete(room.Answers, client.ID)
+		}
+
+		mode, err := h.gameService.ModeForRoom(room)
+		if err != nil {
+			client.logger().Error("獲取遊戲模式失敗", logging.Err(err))
+			return
+		}
+		hostChanged = mode.PlayerLeft(room, client.ID)
+
+		if hostChanged {
+			if len(room.Answers) > 0 {
+				room.Answers = make(map[string]*models.Answer)
+			}
+			resetAnswers = true
+
+			if room.Status == models.RoomStatusQuestionDisplay {
+				shouldSkipCurrentQuestion = true
+			}
+		}
+	})
+	if err != nil {
+		client.logger().Error("移除玩家失敗", logging.Err(err))
 		return
 	}
 
-	// 獲取更新後的房間資訊
-	room, err := h.roomService.GetRoom(client.RoomID)
-	if err != nil {
-		log.Printf("❌ 獲取房間資訊失敗: %v", err)
+	if room == nil {
+		// 房間已被清空，仍需通知其他客戶端
+		client.logger().Info("房間已無玩家")
 
-		// 房間可能已被清空，仍需通知其他客戶端
 		leaveMsg := Message{
 			Type: "PLAYER_LEFT",
 			Data: map[string]interface{}{
@@ -286,65 +394,14 @@ func (h *Hub) handlePlayerLeaveInternal(client *Client) {
 			}
 		}
 
-		return
-	}
+		h.roomLogs.Record(client.RoomID, models.RoomEventGameFinished, "", map[string]interface{}{
+			"reason": "all_players_left",
+		})
 
-	// 清除離開玩家的答案
-	if room.Answers != nil {
-		delete(room.Answers, client.ID)
+		return
 	}
 
 	remainingPlayers := room.GetPlayerCount()
-	hostChanged := false
-	resetAnswers := false
-	shouldSkipCurrentQuestion := false
-	var nextClient *Client
-
-	if remainingPlayers > 0 {
-		// 如果當前主角不存在或就是離開者，選擇新的主角
-		currentHostMissing := room.CurrentHost == "" || room.Players[room.CurrentHost] == nil
-		if currentHostMissing {
-			if newHost := h.gameService.SelectNextHost(room, client.ID); newHost != "" {
-				room.CurrentHost = newHost
-				room.NextHostOverride = newHost
-				hostChanged = true
-			}
-		}
-
-		// 如果下一題預設主角是離開者，重新選擇
-		if room.NextHostOverride == client.ID {
-			if newOverride := h.gameService.SelectNextHost(room, client.ID); newOverride != "" {
-				room.NextHostOverride = newOverride
-				room.CurrentHost = newOverride
-				hostChanged = true
-			} else {
-				room.NextHostOverride = ""
-			}
-		}
-
-		if hostChanged {
-			if len(room.Answers) > 0 {
-				room.Answers = make(map[string]*models.Answer)
-			}
-			resetAnswers = true
-
-			if room.Status == models.RoomStatusQuestionDisplay {
-				shouldSkipCurrentQuestion = true
-				for c := range roomClients {
-					if c != client {
-						nextClient = c
-						break
-					}
-				}
-			}
-		}
-	} else {
-		room.NextHostOverride = ""
-	}
-
-	if err := h.roomService.UpdateRoom(room); err != nil {
-		log.Printf("❌ 更新房間資料失敗: %v", err)
-	}
 
 	leaveData := map[string]interface{}{
 		"playerId":     client.ID,
@@ -365,7 +422,7 @@ func (h *Hub) handlePlayerLeaveInternal(client *Client) {
 		h.broadcastToRoomExclude(client.RoomID, msgBytes, client)
 	}
 
-	if shouldSkipCurrentQuestion && nextClient != nil {
+	if shouldSkipCurrentQuestion {
 		invalidMsg := Message{
 			Type: "QUESTION_INVALID",
 			Data: map[string]interface{}{
@@ -378,13 +435,114 @@ func (h *Hub) handlePlayerLeaveInternal(client *Client) {
 			h.broadcastToRoomExclude(client.RoomID, msgBytes, nil)
 		}
 
-		go func(handler *Client) {
-			time.Sleep(2 * time.Second)
-			handler.handleNextQuestion()
-		}(nextClient)
+		h.roomLogs.Record(client.RoomID, models.RoomEventQuestionInvalid, client.PlayerName, map[string]interface{}{
+			"questionNum": room.CurrentQuestion,
+			"reason":      "host_left",
+		})
+
+		h.dispatchScheduler(client.RoomID, schedulerCommand{
+			kind:        commandSkipQuestion,
+			questionNum: room.CurrentQuestion,
+			delay:       hostLeftSkipDelay,
+		})
 	}
 
-	log.Printf("✅ 玩家 %s 離開房間 %s 處理完成 (剩餘玩家: %d)", client.PlayerName, client.RoomID, remainingPlayers)
+	client.logger().Info("玩家離開處理完成", "remainingPlayers", remainingPlayers)
+}
+
+// kickPlayer 通知持有被踢出玩家連線的節點關閉連線
+func (h *Hub) kickPlayer(roomID, playerID string, banned bool) {
+	if !h.distributed() {
+		h.kickLocalClient(roomID, playerID, banned)
+		return
+	}
+
+	h.publishRemote(&roomEnvelope{
+		Kind:     envelopeKick,
+		RoomID:   roomID,
+		ClientID: playerID,
+		Banned:   banned,
+	})
+}
+
+// kickLocalClient 清除被踢出玩家的重連工作階段，並關閉本節點上的連線
+func (h *Hub) kickLocalClient(roomID, playerID string, banned bool) {
+	h.dropPlayerSession(roomID, playerID)
+
+	kickedMsg := Message{
+		Type: "KICKED",
+		Data: map[string]interface{}{
+			"roomId":  roomID,
+			"banned":  banned,
+			"message": "你已被主持人移出房間",
+		},
+	}
+	msgBytes, err := json.Marshal(kickedMsg)
+	if err != nil {
+		return
+	}
+
+	h.mutex.Lock()
+	var target *Client
+	for client := range h.rooms[roomID] {
+		if client.ID != playerID || client.IsHost || client.IsSpectator {
+			continue
+		}
+		client.kicked = true
+		client.banned = banned
+		target = client
+
+		select {
+		case client.send <- msgBytes:
+		default:
+		}
+	}
+	h.mutex.Unlock()
+
+	if target != nil {
+		target.logger().Info("關閉被踢出玩家的連線", "playerName", target.PlayerName)
+		target.disconnect()
+	}
+}
+
+// StopRoomScheduler 停止並移除本節點的房間排程器
+func (h *Hub) StopRoomScheduler(roomID string) {
+	h.schedulerMutex.Lock()
+	scheduler, exists := h.schedulers[roomID]
+	delete(h.schedulers, roomID)
+	h.schedulerMutex.Unlock()
+
+	if exists {
+		scheduler.Stop()
+	}
+}
+
+// dropScheduler 移除指定的排程器（例如失去計時器租約時）
+func (h *Hub) dropScheduler(roomID string, scheduler *RoomScheduler) {
+	h.schedulerMutex.Lock()
+	if h.schedulers[roomID] == scheduler {
+		delete(h.schedulers, roomID)
+	}
+	h.schedulerMutex.Unlock()
+}
+
+// handleRoomDeleted 房間刪除時清除本節點狀態並通知其他節點
+// 可能在持有 Hub 鎖時被呼叫（玩家離開導致房間清空），不可再取得 Hub 鎖
+func (h *Hub) handleRoomDeleted(roomID string) {
+	h.clearRoomState(roomID)
+
+	if h.distributed() {
+		h.publishRemote(&roomEnvelope{
+			Kind:   envelopeRoomDeleted,
+			RoomID: roomID,
+		})
+	}
+}
+
+// clearRoomState 停止房間排程器並清除重連工作階段
+func (h *Hub) clearRoomState(roomID string) {
+	h.StopRoomScheduler(roomID)
+	h.dropRoomSessions(roomID)
 }
 
 // BuildJoinURL 生成前端 Join URL
@@ -423,6 +581,21 @@ func (h *Hub) GetRoomClientCount(roomID string) int {
 	return 0
 }
 
+// GetRoomSpectatorCount 獲取本節點房間內的觀眾數量
+func (h *Hub) GetRoomSpectatorCount(roomID string) int {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+
+	count := 0
+	for client := range h.rooms[roomID] {
+		if client.IsSpectator {
+			count++
+		}
+	}
+
+	return count
+}
+
 // GetTotalClients 獲取總客戶端數量
 func (h *Hub) GetTotalClients() int {
 	h.mutex.RLock()
@@ -439,23 +612,14 @@ func (h *Hub) GetTotalRooms() int {
 	return len(h.rooms)
 }
 
-// SendToClient 發送訊息給特定客戶端
-func (h *Hub) SendToClient(clientID string, message []byte) error {
-	h.mutex.RLock()
-	defer h.mutex.RUnlock()
-
-	for client := range h.clients {
-		if client.ID == clientID {
-			select {
-			case client.send <- message:
-				return nil
-			default:
-				return fmt.Errorf("客戶端 %s 發送通道已滿", clientID)
-			}
-		}
-	}
-
-	return fmt.Errorf("找不到客戶端 %s", clientID)
+// SendToClient 發送訊息給房間內特定客戶端（客戶端可能連在其他節點）
+func (h *Hub) SendToClient(roomID, clientID string, message []byte) error {
+	return h.publish(&roomEnvelope{
+		Kind:     envelopeMessage,
+		RoomID:   roomID,
+		ClientID: clientID,
+		Payload:  message,
+	})
 }
 
 // GetStats 獲取 Hub 統計資訊
@@ -463,14 +627,28 @@ func (h *Hub) GetStats() map[string]interface{} {
 	h.mutex.RLock()
 	defer h.mutex.RUnlock()
 
+	// 觀眾與玩家分開計算
 	roomStats := make(map[string]int)
+	spectatorStats := make(map[string]int)
+	totalSpectators := 0
 	for roomID, clients := range h.rooms {
-		roomStats[roomID] = len(clients)
+		for client := range clients {
+			if client.IsSpectator {
+				spectatorStats[roomID]++
+				totalSpectators++
+			} else {
+				roomStats[roomID]++
+			}
+		}
 	}
 
 	return map[string]interface{}{
-		"totalClients": len(h.clients),
-		"totalRooms":   len(h.rooms),
-		"roomStats":    roomStats,
+		"nodeId":          h.nodeID,
+		"totalClients":    len(h.clients),
+		"totalRooms":      len(h.rooms),
+		"totalSpectators": totalSpectators,
+		"roomStats":       roomStats,
+		"spectatorStats":  spectatorStats,
+		"janitor":         h.janitorStats(),
 	}
 }
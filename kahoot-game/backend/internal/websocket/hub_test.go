@@ -0,0 +1,281 @@
+package websocket
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"kahoot-game/internal/config"
+	"kahoot-game/internal/services"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/gorilla/websocket"
+)
+
+// 等待單則訊息的時間上限
+const testReadTimeout = 2 * time.Second
+
+// newTestHub 使用記憶體房間存放與內建題庫的 Hub
+func newTestHub(t *testing.T, store services.RoomStore, redisClient *redis.Client, wsConfig config.WebSocketConfig) *Hub {
+	t.Helper()
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	if store == nil {
+		store = services.NewMemoryRoomStore()
+	}
+
+	questionService := services.NewQuestionService(nil, logger)
+	gameService := services.NewGameService(nil, nil, questionService, logger)
+	roomLogs := services.NewRoomLogService(nil, logger)
+	roomService := services.NewRoomService(store, gameService, roomLogs, config.GameConfig{}, logger)
+	hostTokens := services.NewHostTokenService("test-secret", time.Hour)
+
+	hub := NewHub(roomService, gameService, roomLogs, hostTokens, redisClient, "", wsConfig, logger)
+	go hub.Run()
+	return hub
+}
+
+// newTestServer 將 Hub 掛在測試用 HTTP 伺服器上
+func newTestServer(t *testing.T, hub *Hub) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ServeWS(hub, w, r)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// testConn 測試用 WebSocket 客戶端
+type testConn struct {
+	t    *testing.T
+	conn *websocket.Conn
+}
+
+// dial 連線到測試伺服器並讀取歡迎訊息
+func dial(t *testing.T, server *httptest.Server) *testConn {
+	t.Helper()
+
+	url := "ws" + strings.TrimPrefix(server.URL, "http")
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatalf("連線失敗: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	c := &testConn{t: t, conn: conn}
+	c.expect("CONNECTED")
+	return c
+}
+
+// send 送出訊息
+func (c *testConn) send(msgType string, data interface{}) {
+	c.t.Helper()
+
+	if err := c.conn.WriteJSON(Message{Type: msgType, Data: data}); err != nil {
+		c.t.Fatalf("送出 %s 失敗: %v", msgType, err)
+	}
+}
+
+// expect 略過其他訊息，直到收到指定類型的訊息
+func (c *testConn) expect(msgType string) map[string]interface{} {
+	c.t.Helper()
+
+	c.conn.SetReadDeadline(time.Now().Add(testReadTimeout))
+	for {
+		_, raw, err := c.conn.ReadMessage()
+		if err != nil {
+			c.t.Fatalf("等待 %s 時讀取失敗: %v", msgType, err)
+		}
+
+		var msg struct {
+			Type string                 `json:"type"`
+			Data map[string]interface{} `json:"data"`
+		}
+		if err := json.Unmarshal(raw, &msg); err != nil {
+			c.t.Fatalf("訊息解析失敗: %v", err)
+		}
+		if msg.Type == "ERROR" && msgType != "ERROR" {
+			c.t.Fatalf("等待 %s 時收到錯誤: %v", msgType, msg.Data)
+		}
+		if msg.Type == msgType {
+			return msg.Data
+		}
+	}
+}
+
+// expectClose 略過其他訊息，直到連線被關閉，回傳關閉代碼
+func (c *testConn) expectClose() int {
+	c.t.Helper()
+
+	c.conn.SetReadDeadline(time.Now().Add(testReadTimeout))
+	for {
+		_, _, err := c.conn.ReadMessage()
+		if err == nil {
+			continue
+		}
+
+		var closeErr *websocket.CloseError
+		if !errors.As(err, &closeErr) {
+			c.t.Fatalf("連線未正常關閉: %v", err)
+		}
+		return closeErr.Code
+	}
+}
+
+// createRoom 建立房間並回傳房間ID
+func (c *testConn) createRoom(data map[string]interface{}) string {
+	c.t.Helper()
+
+	if data == nil {
+		data = map[string]interface{}{}
+	}
+	data["hostName"] = "主持人"
+	c.send("CREATE_ROOM", data)
+
+	roomID, _ := c.expect("ROOM_CREATED")["roomId"].(string)
+	if roomID == "" {
+		c.t.Fatal("ROOM_CREATED 缺少 roomId")
+	}
+	return roomID
+}
+
+// joinRoom 加入房間並回傳玩家ID
+func (c *testConn) joinRoom(roomID, playerName string) string {
+	c.t.Helper()
+
+	c.send("JOIN_ROOM", map[string]interface{}{"roomId": roomID, "playerName": playerName})
+	for {
+		data := c.expect("PLAYER_JOINED")
+		// 本人收到的 PLAYER_JOINED 才有 resumeToken
+		if _, ok := data["resumeToken"]; ok {
+			return data["playerId"].(string)
+		}
+	}
+}
+
+// waitFor 輪詢直到條件成立
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(testReadTimeout)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("等待逾時: %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestKickPlayer(t *testing.T) {
+	tests := []struct {
+		name      string
+		ban       bool
+		closeCode int
+	}{
+		{name: "踢出", ban: false, closeCode: closeCodeKicked},
+		{name: "踢出並禁止加入", ban: true, closeCode: closeCodeBanned},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hub := newTestHub(t, nil, nil, config.WebSocketConfig{})
+			server := newTestServer(t, hub)
+
+			host := dial(t, server)
+			roomID := host.createRoom(nil)
+			player := dial(t, server)
+			playerID := player.joinRoom(roomID, "小明")
+			// 留一位玩家，房間才不會因清空而被刪除
+			dial(t, server).joinRoom(roomID, "小華")
+
+			host.send("KICK_PLAYER", map[string]interface{}{"playerId": playerID, "ban": tt.ban})
+
+			kicked := host.expect("PLAYER_KICKED")
+			if kicked["playerId"] != playerID || kicked["banned"] != tt.ban {
+				t.Errorf("PLAYER_KICKED = %v", kicked)
+			}
+			player.expect("KICKED")
+			if code := player.expectClose(); code != tt.closeCode {
+				t.Errorf("關閉代碼 = %d, 預期 %d", code, tt.closeCode)
+			}
+
+			room, err := hub.roomService.GetRoom(roomID)
+			if err != nil {
+				t.Fatalf("讀取房間失敗: %v", err)
+			}
+			if _, exists := room.GetPlayer(playerID); exists {
+				t.Error("被踢出的玩家仍在房間中")
+			}
+			waitFor(t, "被踢出的連線註銷", func() bool { return hub.GetRoomClientCount(roomID) == 2 })
+		})
+	}
+}
+
+func TestSessionExpiry(t *testing.T) {
+	hub := newTestHub(t, nil, nil, config.WebSocketConfig{ResumeGracePeriod: 50 * time.Millisecond})
+	server := newTestServer(t, hub)
+
+	host := dial(t, server)
+	roomID := host.createRoom(nil)
+	player := dial(t, server)
+	playerID := player.joinRoom(roomID, "小明")
+	dial(t, server).joinRoom(roomID, "小華")
+
+	// 意外斷線：先保留玩家等待重連，逾時後才移出房間
+	player.conn.Close()
+	host.expect("PLAYER_DISCONNECTED")
+	left := host.expect("PLAYER_LEFT")
+	if left["playerId"] != playerID {
+		t.Errorf("PLAYER_LEFT = %v", left)
+	}
+
+	room, err := hub.roomService.GetRoom(roomID)
+	if err != nil {
+		t.Fatalf("讀取房間失敗: %v", err)
+	}
+	if _, exists := room.GetPlayer(playerID); exists {
+		t.Error("重連逾時的玩家仍在房間中")
+	}
+}
+
+func TestLeaveRoomKeepsConnection(t *testing.T) {
+	hub := newTestHub(t, nil, nil, config.WebSocketConfig{})
+	server := newTestServer(t, hub)
+
+	host := dial(t, server)
+	roomID := host.createRoom(nil)
+	player := dial(t, server)
+	playerID := player.joinRoom(roomID, "小明")
+	dial(t, server).joinRoom(roomID, "小華")
+
+	// 離開後同一條連線繼續送訊息，不可因 send 通道已關閉而 panic
+	player.send("LEAVE_ROOM", nil)
+	player.send("PING", nil)
+	player.expect("PONG")
+
+	left := host.expect("PLAYER_LEFT")
+	if left["playerId"] != playerID {
+		t.Errorf("PLAYER_LEFT = %v", left)
+	}
+	room, err := hub.roomService.GetRoom(roomID)
+	if err != nil {
+		t.Fatalf("讀取房間失敗: %v", err)
+	}
+	if _, exists := room.GetPlayer(playerID); exists {
+		t.Error("離開的玩家仍在房間中")
+	}
+	if count := hub.GetRoomClientCount(roomID); count != 2 {
+		t.Errorf("房間連線數 = %d, 預期 2", count)
+	}
+
+	// 主動離開不保留重連工作階段，之後可以用同一條連線再加入
+	player.joinRoom(roomID, "小明")
+	host.expect("PLAYER_JOINED")
+}
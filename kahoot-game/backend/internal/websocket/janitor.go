@@ -0,0 +1,281 @@
+package websocket
+
+import (
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"kahoot-game/internal/logging"
+	"kahoot-game/internal/metrics"
+	"kahoot-game/internal/services"
+)
+
+// 未設定時的預設清理間隔
+const defaultJanitorInterval = time.Minute
+
+// 房間已不存在時通知本節點連線的原因
+const roomClosedReasonMissing = "missing"
+
+// Janitor 定期回收閒置房間，並整理活躍房間列表、玩家資料與 Hub 的房間連線表
+type Janitor struct {
+	hub      *Hub
+	policy   services.RoomReapPolicy
+	interval time.Duration
+	stop     chan struct{}
+
+	mutex sync.Mutex
+	stats JanitorStats
+}
+
+// JanitorStats 清理統計（自啟動以來的累計數量）
+type JanitorStats struct {
+	Runs               int64            `json:"runs"`
+	LastRunAt          *time.Time       `json:"lastRunAt,omitempty"`
+	LastRunDuration    time.Duration    `json:"lastRunDurationNs"`
+	RoomsReaped        map[string]int64 `json:"roomsReaped"`        // 依原因分類的回收房間數
+	ClientsNotified    int64            `json:"clientsNotified"`    // 收到 ROOM_CLOSED 的本節點連線數
+	ActiveRoomsPruned  int64            `json:"activeRoomsPruned"`  // 從 active_rooms 移除的過期房間ID
+	PlayerKeysDeleted  int64            `json:"playerKeysDeleted"`  // 刪除的孤兒玩家資料
+	HubRoomsReconciled int64            `json:"hubRoomsReconciled"` // 從 Hub 房間連線表移除的房間
+	Errors             int64            `json:"errors"`
+}
+
+// StartJanitor 啟動房間清理器，interval 為清理間隔
+func (h *Hub) StartJanitor(policy services.RoomReapPolicy, interval time.Duration) *Janitor {
+	if interval <= 0 {
+		interval = defaultJanitorInterval
+	}
+
+	j := &Janitor{
+		hub:      h,
+		policy:   policy,
+		interval: interval,
+		stop:     make(chan struct{}),
+		stats: JanitorStats{
+			RoomsReaped: make(map[string]int64),
+		},
+	}
+
+	h.mutex.Lock()
+	h.janitor = j
+	h.mutex.Unlock()
+
+	go j.run()
+	h.logger.Info("房間清理器已啟動", "interval", interval)
+	return j
+}
+
+// Stop 停止清理器
+func (j *Janitor) Stop() {
+	close(j.stop)
+}
+
+// Stats 目前的清理統計
+func (j *Janitor) Stats() JanitorStats {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	stats := j.stats
+	stats.RoomsReaped = make(map[string]int64, len(j.stats.RoomsReaped))
+	for reason, count := range j.stats.RoomsReaped {
+		stats.RoomsReaped[reason] = count
+	}
+	return stats
+}
+
+func (j *Janitor) run() {
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			j.RunOnce()
+		case <-j.stop:
+			return
+		}
+	}
+}
+
+// RunOnce 執行一次清理
+func (j *Janitor) RunOnce() {
+	started := time.Now()
+	reaped := make(map[string]int64)
+	var pruned, playerKeys, reconciled, errCount int64
+
+	stale, prunedRooms, err := j.hub.roomService.FindStaleRooms(j.policy, started)
+	if err != nil {
+		j.hub.logger.Error("房間清理器讀取房間失敗", logging.Err(err))
+		errCount++
+	}
+	pruned = int64(prunedRooms)
+
+	for _, room := range stale {
+		ok, err := j.hub.roomService.ReapRoom(room.RoomID, j.policy, started)
+		if err != nil {
+			j.hub.logger.Error("回收房間失敗", logging.KeyRoomID, room.RoomID, logging.Err(err))
+			errCount++
+			continue
+		}
+		if !ok {
+			continue
+		}
+
+		j.hub.closeRoom(room.RoomID, room.Reason)
+		reaped[room.Reason]++
+		j.hub.logger.Info("回收房間", logging.KeyRoomID, room.RoomID, "reason", room.Reason)
+	}
+
+	deleted, err := j.hub.roomService.CleanupPlayerKeys()
+	if err != nil {
+		j.hub.logger.Error("清理玩家資料失敗", logging.Err(err))
+		errCount++
+	}
+	playerKeys = int64(deleted)
+
+	reconciled = int64(j.hub.reconcileRooms())
+
+	finished := time.Now()
+
+	j.mutex.Lock()
+	j.stats.Runs++
+	j.stats.LastRunAt = &finished
+	j.stats.LastRunDuration = finished.Sub(started)
+	for reason, count := range reaped {
+		j.stats.RoomsReaped[reason] += count
+	}
+	j.stats.ActiveRoomsPruned += pruned
+	j.stats.PlayerKeysDeleted += playerKeys
+	j.stats.HubRoomsReconciled += reconciled
+	j.stats.Errors += errCount
+	j.mutex.Unlock()
+
+	for reason, count := range reaped {
+		metrics.RoomsReaped(reason, count)
+	}
+	metrics.JanitorCleaned(metrics.CleanedActiveRoomsPruned, pruned)
+	metrics.JanitorCleaned(metrics.CleanedPlayerKeysDeleted, playerKeys)
+	metrics.JanitorCleaned(metrics.CleanedHubRoomsReconciled, reconciled)
+	metrics.JanitorErrors(errCount)
+
+	if len(reaped) > 0 || pruned > 0 || playerKeys > 0 || reconciled > 0 {
+		j.hub.logger.Info("房間清理完成",
+			"roomsReaped", reaped, "activeRoomsPruned", pruned, "playerKeysDeleted", playerKeys,
+			"hubRoomsReconciled", reconciled, "duration", finished.Sub(started))
+	}
+}
+
+// closeRoom 通知所有節點上房間內的連線房間已關閉，並關閉這些連線
+func (h *Hub) closeRoom(roomID, reason string) {
+	payload, err := roomClosedMessage(roomID, reason)
+	if err != nil {
+		return
+	}
+
+	if !h.distributed() {
+		h.closeLocalRoom(roomID, payload)
+		return
+	}
+
+	h.publishRemote(&roomEnvelope{
+		Kind:    envelopeRoomClosed,
+		RoomID:  roomID,
+		Payload: payload,
+	})
+}
+
+// roomClosedMessage 房間關閉通知
+func roomClosedMessage(roomID, reason string) ([]byte, error) {
+	return json.Marshal(Message{
+		Type: "ROOM_CLOSED",
+		Data: map[string]interface{}{
+			"roomId":  roomID,
+			"reason":  reason,
+			"message": "房間閒置過久，已被系統關閉",
+		},
+	})
+}
+
+// closeLocalRoom 通知本節點房間內的連線並關閉連線
+func (h *Hub) closeLocalRoom(roomID string, payload []byte) {
+	h.dropRoomSessions(roomID)
+
+	h.mutex.Lock()
+	targets := make([]*Client, 0, len(h.rooms[roomID]))
+	for client := range h.rooms[roomID] {
+		client.roomClosed = true
+		targets = append(targets, client)
+
+		select {
+		case client.send <- payload:
+		default:
+		}
+	}
+	delete(h.rooms, roomID)
+	if h.janitor != nil {
+		h.janitor.addClientsNotified(len(targets))
+	}
+	h.mutex.Unlock()
+
+	for _, client := range targets {
+		client.disconnect()
+	}
+}
+
+// addClientsNotified 累計收到 ROOM_CLOSED 的連線數
+func (j *Janitor) addClientsNotified(count int) {
+	j.mutex.Lock()
+	j.stats.ClientsNotified += int64(count)
+	j.mutex.Unlock()
+	metrics.JanitorCleaned(metrics.CleanedClientsNotified, int64(count))
+}
+
+// reconcileRooms 整理本節點的房間連線表，回傳整理的房間數：
+// 移除已註銷的連線與空房間，房間資料已不存在時通知並關閉仍在房間中的連線
+func (h *Hub) reconcileRooms() int {
+	reconciled := 0
+
+	h.mutex.Lock()
+	roomIDs := make([]string, 0, len(h.rooms))
+	for roomID, clients := range h.rooms {
+		for client := range clients {
+			if !h.clients[client] {
+				delete(clients, client)
+			}
+		}
+		if len(clients) == 0 {
+			delete(h.rooms, roomID)
+			reconciled++
+			continue
+		}
+		roomIDs = append(roomIDs, roomID)
+	}
+	h.mutex.Unlock()
+
+	for _, roomID := range roomIDs {
+		_, err := h.roomService.GetRoom(roomID)
+		if !errors.Is(err, services.ErrRoomNotFound) {
+			continue
+		}
+
+		payload, err := roomClosedMessage(roomID, roomClosedReasonMissing)
+		if err != nil {
+			continue
+		}
+		h.closeLocalRoom(roomID, payload)
+		h.StopRoomScheduler(roomID)
+		reconciled++
+	}
+
+	return reconciled
+}
+
+// janitorStats 清理統計，未啟動清理器時回傳 nil
+func (h *Hub) janitorStats() *JanitorStats {
+	if h.janitor == nil {
+		return nil
+	}
+	stats := h.janitor.Stats()
+	return &stats
+}
@@ -0,0 +1,41 @@
+package websocket
+
+import (
+	"testing"
+	"time"
+
+	"kahoot-game/internal/config"
+	"kahoot-game/internal/services"
+)
+
+func TestJanitorClosesIdleRoom(t *testing.T) {
+	hub := newTestHub(t, nil, nil, config.WebSocketConfig{})
+	server := newTestServer(t, hub)
+
+	host := dial(t, server)
+	roomID := host.createRoom(nil)
+	player := dial(t, server)
+	player.joinRoom(roomID, "小明")
+
+	janitor := hub.StartJanitor(services.RoomReapPolicy{IdleAfter: time.Nanosecond}, time.Hour)
+	t.Cleanup(janitor.Stop)
+	janitor.RunOnce()
+
+	for _, conn := range []*testConn{host, player} {
+		if closed := conn.expect("ROOM_CLOSED"); closed["reason"] != services.ReapReasonIdle {
+			t.Errorf("ROOM_CLOSED = %v", closed)
+		}
+		if code := conn.expectClose(); code != closeCodeRoomClosed {
+			t.Errorf("關閉代碼 = %d, 預期 %d", code, closeCodeRoomClosed)
+		}
+	}
+
+	if _, err := hub.roomService.GetRoom(roomID); err == nil {
+		t.Error("閒置房間未被刪除")
+	}
+	stats := janitor.Stats()
+	if stats.RoomsReaped[services.ReapReasonIdle] != 1 || stats.ClientsNotified != 2 {
+		t.Errorf("清理統計 = %+v", stats)
+	}
+	waitFor(t, "房間連線註銷", func() bool { return hub.GetTotalClients() == 0 })
+}
@@ -0,0 +1,810 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sort"
+	"time"
+
+	"kahoot-game/internal/logging"
+	"kahoot-game/internal/metrics"
+	"kahoot-game/internal/models"
+	"kahoot-game/internal/services"
+)
+
+const (
+	// 顯示分數結果後自動進入下一題的等待時間
+	resultDisplayDelay = 5 * time.Second
+
+	// 本題無效或無人作答後進入下一題的等待時間
+	skipQuestionDelay = 3 * time.Second
+
+	// 主角離開導致本題無效後進入下一題的等待時間
+	hostLeftSkipDelay = 2 * time.Second
+
+	// 排程器指令佇列大小
+	schedulerQueueSize = 16
+
+	// 主持人延長答題時間的預設與上限秒數
+	defaultExtendSeconds = 10
+	maxExtendSeconds     = 120
+)
+
+// schedulerCommandType 排程器指令類型
+type schedulerCommandType int
+
+const (
+	commandStartGame    schedulerCommandType = iota // 發送當前題目並開始倒數
+	commandAllAnswered                              // 所有玩家已作答，提前結算
+	commandSkipQuestion                             // 略過當前題目
+	commandPause                                    // 主持人暫停倒數與結果計時
+	commandResume                                   // 主持人繼續遊戲
+	commandHostSkip                                 // 主持人略過當前題目或結果畫面
+	commandExtendTime                               // 主持人延長本題答題時間
+	commandEndGame                                  // 主持人提前結束遊戲
+	commandTakeOver                                 // 接手其他節點遺留的房間，依房間狀態接續計時
+)
+
+// schedulerCommand 排程器指令
+type schedulerCommand struct {
+	kind        schedulerCommandType
+	questionNum int           // 指令針對的題號，與當前題號不符的過期指令會被忽略
+	delay       time.Duration // 略過題目後進入下一題前的等待時間
+	seconds     int           // 延長答題時間的秒數
+}
+
+// schedulerPhase 排程器所處階段
+type schedulerPhase int
+
+const (
+	phaseIdle      schedulerPhase = iota // 尚未開始或遊戲已結束
+	phaseAnswering                       // 答題倒數中
+	phaseWaiting                         // 顯示結果或略過後，等待進入下一題
+)
+
+// RoomScheduler 房間回合排程器
+// 每個房間只有一個排程器 goroutine，獨佔答題倒數、結果顯示延遲與換題，
+// 客戶端只送出指令，不再自行啟動計時器
+type RoomScheduler struct {
+	hub      *Hub
+	roomID   string
+	commands chan schedulerCommand
+	ctx      context.Context
+	cancel   context.CancelFunc
+	logger   *slog.Logger
+
+	// 以下狀態只在 run goroutine 中存取
+	phase       schedulerPhase
+	questionNum int
+	timeLeft    int
+	ticker      *time.Ticker
+	waitTimer   *time.Timer
+
+	// 暫停時倒數與等待計時器都會停止，繼續時從剩餘時間接續
+	paused        bool
+	waitDeadline  time.Time
+	waitRemaining time.Duration
+}
+
+// newRoomScheduler 創建房間排程器
+func newRoomScheduler(hub *Hub, roomID string) *RoomScheduler {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &RoomScheduler{
+		hub:      hub,
+		roomID:   roomID,
+		commands: make(chan schedulerCommand, schedulerQueueSize),
+		ctx:      ctx,
+		cancel:   cancel,
+		logger:   hub.logger.With(logging.KeyRoomID, roomID),
+	}
+}
+
+// questionLogger 帶有當前題號欄位的日誌記錄器（只在 run goroutine 中使用）
+func (s *RoomScheduler) questionLogger() *slog.Logger {
+	return s.logger.With(logging.KeyQuestionNum, s.questionNum)
+}
+
+// send 發送指令給排程器（不阻塞）
+func (s *RoomScheduler) send(cmd schedulerCommand) {
+	select {
+	case s.commands <- cmd:
+	case <-s.ctx.Done():
+	default:
+		s.logger.Warn("排程器指令佇列已滿，忽略指令", "command", cmd.kind)
+	}
+}
+
+// Stop 停止排程器
+func (s *RoomScheduler) Stop() {
+	s.cancel()
+}
+
+// run 排程器主迴圈
+func (s *RoomScheduler) run() {
+	s.logger.Debug("排程器已啟動")
+	defer func() {
+		s.stopTimers()
+		s.hub.releaseSchedulerLease(s.roomID)
+		s.logger.Debug("排程器已停止")
+	}()
+
+	// 定期續約計時器租約，確保同一房間只有一個節點在倒數
+	leaseTicker := time.NewTicker(schedulerLeaseTTL / 3)
+	defer leaseTicker.Stop()
+
+	for {
+		var tickC, waitC <-chan time.Time
+		if s.ticker != nil {
+			tickC = s.ticker.C
+		}
+		if s.waitTimer != nil {
+			waitC = s.waitTimer.C
+		}
+
+		select {
+		case <-s.ctx.Done():
+			return
+
+		case cmd := <-s.commands:
+			s.handleCommand(cmd)
+
+		case <-tickC:
+			s.tick()
+
+		case <-waitC:
+			s.waitTimer = nil
+			s.advance()
+
+		case <-leaseTicker.C:
+			if !s.hub.renewSchedulerLease(s.roomID) {
+				s.logger.Warn("計時器租約已失效，停止本節點排程器")
+				s.hub.dropScheduler(s.roomID, s)
+				return
+			}
+		}
+	}
+}
+
+// handleCommand 處理指令
+func (s *RoomScheduler) handleCommand(cmd schedulerCommand) {
+	switch cmd.kind {
+	case commandStartGame:
+		s.stopTimers()
+		s.paused = false
+		s.startQuestion()
+
+	case commandAllAnswered:
+		// 暫停中不結算，繼續遊戲時再檢查
+		if s.phase != phaseAnswering || cmd.questionNum != s.questionNum || s.paused {
+			return
+		}
+		s.stopTimers()
+		s.finishRound()
+
+	case commandSkipQuestion:
+		if s.phase != phaseAnswering || cmd.questionNum != s.questionNum {
+			return
+		}
+		s.stopTimers()
+		s.waitThenAdvance(cmd.delay)
+
+	case commandPause:
+		s.pause()
+
+	case commandResume:
+		s.resume()
+
+	case commandHostSkip:
+		if cmd.questionNum != s.questionNum || s.paused {
+			return
+		}
+		s.hostSkip()
+
+	case commandExtendTime:
+		if s.phase != phaseAnswering || cmd.questionNum != s.questionNum || cmd.seconds <= 0 {
+			return
+		}
+		s.extendTime(cmd.seconds)
+
+	case commandEndGame:
+		s.endGame()
+
+	case commandTakeOver:
+		s.takeOver()
+	}
+}
+
+// takeOver 原本負責計時的節點失效後，依房間狀態接續倒數或換題
+// 延長的答題時間只記錄在原節點，接手後以題目時間限制重新計算剩餘秒數
+func (s *RoomScheduler) takeOver() {
+	room, _, ok := s.loadRoom()
+	if !ok || !room.IsInProgress() {
+		return
+	}
+
+	s.stopTimers()
+	s.questionNum = room.CurrentQuestion
+	s.paused = room.IsPaused
+	s.logger.Info("接手房間計時", logging.KeyQuestionNum, s.questionNum, "status", room.Status, "paused", s.paused)
+
+	switch room.Status {
+	case models.RoomStatusStarting:
+		s.paused = false
+		s.startQuestion()
+
+	case models.RoomStatusQuestionDisplay:
+		// 暫停中的房間以暫停當下計算已經過的時間
+		now := time.Now()
+		if room.PausedAt != nil {
+			now = *room.PausedAt
+		}
+		s.phase = phaseAnswering
+		s.timeLeft = room.QuestionTimeLimit - int(room.QuestionElapsed(now))
+		if s.paused {
+			if s.timeLeft < 1 {
+				s.timeLeft = 1
+			}
+			return
+		}
+		if s.timeLeft <= 0 {
+			s.handleTimeout()
+			return
+		}
+		s.broadcastTimer()
+		s.ticker = time.NewTicker(time.Second)
+
+	case models.RoomStatusShowResult:
+		s.waitThenAdvance(resultDisplayDelay)
+	}
+}
+
+// pause 暫停答題倒數或結果顯示計時
+func (s *RoomScheduler) pause() {
+	if s.paused || s.phase == phaseIdle {
+		return
+	}
+
+	// 記錄等待下一題的剩餘時間
+	if s.waitTimer != nil {
+		s.waitRemaining = time.Until(s.waitDeadline)
+		if s.waitRemaining < 0 {
+			s.waitRemaining = 0
+		}
+	}
+
+	s.stopTimers()
+	s.paused = true
+	s.setRoomPaused(true)
+
+	s.broadcast("GAME_PAUSED", map[string]interface{}{
+		"message":       "主持人暫停了遊戲",
+		"timeLeft":      s.timeLeft,
+		"questionIndex": s.questionNum,
+		"answering":     s.phase == phaseAnswering,
+	})
+
+	s.hub.roomLogs.Record(s.roomID, models.RoomEventGamePaused, "", map[string]interface{}{
+		"questionNum": s.questionNum,
+		"timeLeft":    s.timeLeft,
+	})
+
+	s.questionLogger().Info("暫停答題倒數", "timeLeft", s.timeLeft)
+}
+
+// resume 從暫停時的剩餘時間繼續
+func (s *RoomScheduler) resume() {
+	if !s.paused {
+		return
+	}
+
+	s.paused = false
+	s.setRoomPaused(false)
+
+	s.broadcast("GAME_RESUMED", map[string]interface{}{
+		"message":       "遊戲繼續",
+		"timeLeft":      s.timeLeft,
+		"questionIndex": s.questionNum,
+	})
+
+	s.hub.roomLogs.Record(s.roomID, models.RoomEventGameResumed, "", map[string]interface{}{
+		"questionNum": s.questionNum,
+		"timeLeft":    s.timeLeft,
+	})
+
+	s.questionLogger().Info("繼續答題倒數")
+
+	switch s.phase {
+	case phaseAnswering:
+		// 暫停前所有玩家都已作答時直接結算
+		if room, mode, ok := s.loadRoom(); ok && mode.AllAnswered(room) {
+			s.finishRound()
+			return
+		}
+		s.broadcastTimer()
+		s.ticker = time.NewTicker(time.Second)
+
+	case phaseWaiting:
+		s.waitThenAdvance(s.waitRemaining)
+	}
+}
+
+// hostSkip 主持人略過當前題目；在結果畫面時直接進入下一題
+func (s *RoomScheduler) hostSkip() {
+	switch s.phase {
+	case phaseAnswering:
+		s.stopTimers()
+
+		s.broadcast("QUESTION_SKIPPED", map[string]interface{}{
+			"message": "主持人略過本題",
+			"reason":  "host_skipped",
+		})
+
+		s.hub.roomLogs.Record(s.roomID, models.RoomEventQuestionSkipped, "", map[string]interface{}{
+			"questionNum": s.questionNum,
+			"reason":      "host_skipped",
+		})
+
+		metrics.QuestionInvalidated("host_skipped")
+		s.questionLogger().Info("主持人略過題目", "delay", skipQuestionDelay)
+		s.waitThenAdvance(skipQuestionDelay)
+
+	case phaseWaiting:
+		s.stopTimers()
+		s.questionLogger().Info("主持人略過結果畫面")
+		s.advance()
+	}
+}
+
+// extendTime 延長本題答題時間
+func (s *RoomScheduler) extendTime(seconds int) {
+	s.timeLeft += seconds
+
+	s.broadcast("TIME_EXTENDED", map[string]interface{}{
+		"seconds":       seconds,
+		"timeLeft":      s.timeLeft,
+		"questionIndex": s.questionNum,
+	})
+	s.broadcastTimer()
+
+	s.hub.roomLogs.Record(s.roomID, models.RoomEventTimeExtended, "", map[string]interface{}{
+		"questionNum": s.questionNum,
+		"seconds":     seconds,
+		"timeLeft":    s.timeLeft,
+	})
+
+	s.questionLogger().Info("延長答題時間", "seconds", seconds, "timeLeft", s.timeLeft)
+}
+
+// endGame 主持人提前結束遊戲，以目前的分數結算
+func (s *RoomScheduler) endGame() {
+	var mode services.GameMode
+	room, err := s.hub.roomService.MutateRoom(s.roomID, func(room *models.Room) error {
+		if !room.IsInProgress() {
+			return fmt.Errorf("遊戲未在進行中")
+		}
+
+		var err error
+		mode, err = s.hub.gameService.ModeForRoom(room)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		room.Status = models.RoomStatusFinished
+		room.FinishedAt = &now
+		room.Answers = make(map[string]*models.Answer)
+		room.SetPaused(false, now)
+		return nil
+	})
+	if err != nil {
+		s.questionLogger().Error("結束遊戲失敗", logging.Err(err))
+		return
+	}
+
+	s.stopTimers()
+	s.paused = false
+	s.phase = phaseIdle
+
+	s.questionLogger().Info("主持人提前結束遊戲")
+	s.finishGame(room, mode, true)
+}
+
+// setRoomPaused 更新房間的暫停狀態，讓重連的玩家與其他節點也能得知
+func (s *RoomScheduler) setRoomPaused(paused bool) {
+	_, err := s.hub.roomService.MutateRoom(s.roomID, func(room *models.Room) error {
+		room.SetPaused(paused, time.Now())
+		return nil
+	})
+	if err != nil {
+		s.logger.Warn("更新房間暫停狀態失敗", "paused", paused, logging.Err(err))
+	}
+}
+
+// startQuestion 廣播當前題目並開始倒數
+func (s *RoomScheduler) startQuestion() {
+	s.phase = phaseIdle
+
+	// 確保房間狀態正確
+	room, err := s.hub.roomService.MutateRoom(s.roomID, func(room *models.Room) error {
+		if room.CurrentQuestion < 1 || room.CurrentQuestion > len(room.Questions) {
+			return fmt.Errorf("題目編號超出範圍: %d (總共 %d 題)", room.CurrentQuestion, len(room.Questions))
+		}
+		room.Status = models.RoomStatusQuestionDisplay
+		room.StartQuestionTimer(time.Now())
+		return nil
+	})
+	if err != nil {
+		s.questionLogger().Error("更新房間狀態失敗", logging.Err(err))
+		return
+	}
+
+	mode, err := s.hub.gameService.ModeForRoom(room)
+	if err != nil {
+		s.logger.Error("獲取遊戲模式失敗", logging.Err(err))
+		return
+	}
+
+	// 發送新題目訊息
+	s.broadcast("NEW_QUESTION", buildQuestionPayload(mode, room))
+
+	// 私下發送給個別玩家的內容（例如臥底詞）
+	s.sendPrivatePayloads(mode, room)
+
+	s.logger.Info("發送題目", logging.KeyQuestionNum, room.CurrentQuestion, "currentHost", room.CurrentHost)
+
+	question := room.Questions[room.CurrentQuestion-1]
+	s.hub.roomLogs.Record(s.roomID, models.RoomEventQuestionSent, "", map[string]interface{}{
+		"questionNum":  room.CurrentQuestion,
+		"questionId":   question.ID,
+		"questionText": question.QuestionText,
+		"currentHost":  room.CurrentHost,
+		"timeLimit":    room.QuestionTimeLimit,
+	})
+
+	s.phase = phaseAnswering
+	s.questionNum = room.CurrentQuestion
+	s.timeLeft = room.QuestionTimeLimit
+	s.broadcastTimer()
+	s.ticker = time.NewTicker(time.Second)
+}
+
+// tick 每秒倒數一次
+func (s *RoomScheduler) tick() {
+	s.timeLeft--
+	s.broadcastTimer()
+
+	if s.timeLeft <= 0 {
+		s.stopTimers()
+		s.handleTimeout()
+	}
+}
+
+// handleTimeout 處理答題時間結束
+func (s *RoomScheduler) handleTimeout() {
+	s.broadcast("QUESTION_TIMEOUT", map[string]interface{}{
+		"message": "答題時間結束",
+	})
+
+	room, mode, ok := s.loadRoom()
+	if !ok {
+		s.phase = phaseIdle
+		return
+	}
+
+	s.logger.Debug("答題時間結束", logging.KeyQuestionNum, room.CurrentQuestion)
+
+	// 檢查倒數結束時的答題情況
+	answeredPlayers := len(room.Answers)
+	roundErr := mode.ValidateRound(room)
+
+	s.logger.Info("答題時間結束", logging.KeyQuestionNum, room.CurrentQuestion, "players", room.GetPlayerCount(), "answered", answeredPlayers, "scorable", roundErr == nil)
+
+	switch {
+	case answeredPlayers > 0 && roundErr == nil:
+		// 本題有效，可以進行正常計分
+		s.finishRound()
+
+	case answeredPlayers > 0:
+		// 有人答題但本題無法計分（例如主角沒答題），這題無效
+		s.questionLogger().Info("本題無效", "reason", roundErr.Reason, "delay", skipQuestionDelay)
+		s.broadcast("QUESTION_INVALID", map[string]interface{}{
+			"message": roundErr.Message,
+			"reason":  roundErr.Reason,
+		})
+		s.hub.roomLogs.Record(s.roomID, models.RoomEventQuestionInvalid, "", map[string]interface{}{
+			"questionNum":     room.CurrentQuestion,
+			"reason":          roundErr.Reason,
+			"answeredPlayers": answeredPlayers,
+		})
+		metrics.QuestionInvalidated(roundErr.Reason)
+		s.waitThenAdvance(skipQuestionDelay)
+
+	default:
+		// 沒人答題，直接進入下一題
+		s.questionLogger().Info("沒有玩家答題，略過本題", "delay", skipQuestionDelay)
+		s.broadcast("QUESTION_SKIPPED", map[string]interface{}{
+			"message": "時間到，沒有玩家答題",
+			"reason":  "no_answers",
+		})
+		metrics.QuestionInvalidated("no_answers")
+		s.waitThenAdvance(skipQuestionDelay)
+	}
+}
+
+// finishRound 計算分數、廣播結果，並在顯示一段時間後進入下一題
+func (s *RoomScheduler) finishRound() {
+	// 計算分數（同時記錄題目歷史），只對仍在答題中的當前題目計分一次
+	var result *models.QuestionResult
+	room, err := s.hub.roomService.MutateRoom(s.roomID, func(room *models.Room) error {
+		if room.Status != models.RoomStatusQuestionDisplay || room.CurrentQuestion != s.questionNum {
+			return fmt.Errorf("第 %d 題已不在答題階段", s.questionNum)
+		}
+
+		mode, err := s.hub.gameService.ModeForRoom(room)
+		if err != nil {
+			return err
+		}
+
+		result = mode.ScoreRound(room)
+		room.Status = models.RoomStatusShowResult
+		return nil
+	})
+	if err != nil {
+		s.questionLogger().Error("計分失敗", logging.Err(err))
+		s.phase = phaseIdle
+		return
+	}
+
+	hostAnswer := ""
+	if answer, exists := room.Answers[room.CurrentHost]; exists {
+		hostAnswer = answer.Answer
+	}
+
+	scoresUpdate := map[string]interface{}{
+		"scores":          result.Scores,
+		"currentQuestion": room.CurrentQuestion,
+		"hostAnswer":      hostAnswer,
+		"correctAnswer":   result.CorrectAnswer,
+		"explanation":     result.Explanation,
+		"result":          result,
+	}
+	logData := map[string]interface{}{
+		"questionNum":   room.CurrentQuestion,
+		"hostAnswer":    hostAnswer,
+		"correctAnswer": result.CorrectAnswer,
+		"scores":        result.Scores,
+		"answers":       answerLog(room),
+	}
+
+	// 隊伍模式同時附上隊伍排名
+	if len(result.TeamScores) > 0 {
+		scoresUpdate["teamScores"] = result.TeamScores
+		logData["teamScores"] = result.TeamScores
+	}
+
+	s.broadcast("SCORES_UPDATE", scoresUpdate)
+	s.hub.roomLogs.Record(s.roomID, models.RoomEventScoresUpdate, "", logData)
+
+	s.logger.Info("本題結果已發送", logging.KeyQuestionNum, room.CurrentQuestion, "delay", resultDisplayDelay)
+	s.waitThenAdvance(resultDisplayDelay)
+}
+
+// answerLog 本題各玩家的答案，依玩家ID排序，計分後才寫入房間事件
+func answerLog(room *models.Room) []map[string]interface{} {
+	playerIDs := make([]string, 0, len(room.Answers))
+	for playerID := range room.Answers {
+		playerIDs = append(playerIDs, playerID)
+	}
+	sort.Strings(playerIDs)
+
+	answers := make([]map[string]interface{}, 0, len(playerIDs))
+	for _, playerID := range playerIDs {
+		answers = append(answers, map[string]interface{}{
+			"playerId": playerID,
+			"answer":   room.Answers[playerID].Answer,
+			"isHost":   playerID == room.CurrentHost,
+		})
+	}
+	return answers
+}
+
+// advance 清除答案並進入下一題，遊戲結束時發送最終結果
+func (s *RoomScheduler) advance() {
+	s.phase = phaseIdle
+
+	var mode services.GameMode
+	room, err := s.hub.roomService.MutateRoom(s.roomID, func(room *models.Room) error {
+		// 只從排程器正在處理的題目往下走，避免重複換題
+		if room.CurrentQuestion != s.questionNum {
+			return fmt.Errorf("房間題號 %d 與排程器題號 %d 不符", room.CurrentQuestion, s.questionNum)
+		}
+
+		var err error
+		mode, err = s.hub.gameService.ModeForRoom(room)
+		if err != nil {
+			return err
+		}
+
+		// 清除答案記錄，準備下一題
+		room.Answers = make(map[string]*models.Answer)
+
+		s.logger.Debug("準備進入下一題", logging.KeyQuestionNum, room.CurrentQuestion, "totalQuestions", room.TotalQuestions, "questionPool", len(room.Questions))
+		mode.NextQuestion(room)
+
+		// 題目不足時強制結束遊戲
+		if room.Status != models.RoomStatusFinished && room.CurrentQuestion > len(room.Questions) {
+			s.logger.Warn("沒有更多題目了，強制結束遊戲", logging.KeyQuestionNum, room.CurrentQuestion)
+			room.Status = models.RoomStatusFinished
+		}
+
+		if room.Status == models.RoomStatusFinished {
+			now := time.Now()
+			room.FinishedAt = &now
+		}
+		return nil
+	})
+	if err != nil {
+		s.questionLogger().Error("換題失敗", logging.Err(err))
+		return
+	}
+
+	if room.Status == models.RoomStatusFinished {
+		s.finishGame(room, mode, false)
+		return
+	}
+
+	s.startQuestion()
+}
+
+// finishGame 發送最終結果（包含詳細統計），endedEarly 代表主持人提前結束
+func (s *RoomScheduler) finishGame(room *models.Room, mode services.GameMode, endedEarly bool) {
+	finalStats := mode.FinalRanking(room)
+
+	gameFinished := map[string]interface{}{
+		"finalStats":     finalStats,
+		"message":        "遊戲結束！",
+		"totalQuestions": room.TotalQuestions,
+	}
+	logData := map[string]interface{}{
+		"totalQuestions": room.TotalQuestions,
+		"finalStats":     finalStats,
+	}
+
+	if endedEarly {
+		gameFinished["endedEarly"] = true
+		gameFinished["message"] = "主持人結束了遊戲"
+		gameFinished["questionsPlayed"] = len(room.GameHistory)
+		logData["endedEarly"] = true
+	}
+
+	// 隊伍模式同時附上隊伍最終排名
+	if ranker, ok := mode.(services.TeamRanker); ok && room.IsTeamMode() {
+		teamStats := ranker.FinalTeamRanking(room, finalStats)
+		gameFinished["teamStats"] = teamStats
+		logData["teamStats"] = teamStats
+	}
+
+	s.broadcast("GAME_FINISHED", gameFinished)
+	metrics.GameFinished(room.GameMode)
+
+	s.logger.Info("遊戲結束", logging.KeyGameMode, room.GameMode, "endedEarly", endedEarly)
+
+	s.hub.roomLogs.Record(s.roomID, models.RoomEventGameFinished, "", logData)
+
+	// 保存遊戲記錄，失敗不影響遊戲結束流程
+	go s.saveFinishedGame(room, finalStats)
+
+	// 遊戲結束後不再需要計時，釋放租約；重新開始遊戲時會再建立排程器
+	s.hub.StopRoomScheduler(s.roomID)
+}
+
+// saveFinishedGame 將遊戲記錄寫入資料庫，成功後通知房間遊戲記錄ID
+func (s *RoomScheduler) saveFinishedGame(room *models.Room, finalStats []models.PlayerGameStats) {
+	gameID, err := s.hub.gameService.SaveFinishedGame(room, finalStats)
+	if err != nil {
+		s.logger.Error("保存遊戲記錄失敗", logging.Err(err))
+		return
+	}
+
+	s.broadcast("GAME_SAVED", map[string]interface{}{
+		"gameId": gameID,
+	})
+}
+
+// waitThenAdvance 等待指定時間後進入下一題，暫停中只記錄等待時間
+func (s *RoomScheduler) waitThenAdvance(delay time.Duration) {
+	s.phase = phaseWaiting
+	if s.paused {
+		s.waitRemaining = delay
+		return
+	}
+	s.waitDeadline = time.Now().Add(delay)
+	s.waitTimer = time.NewTimer(delay)
+}
+
+// stopTimers 停止倒數與等待計時器
+func (s *RoomScheduler) stopTimers() {
+	if s.ticker != nil {
+		s.ticker.Stop()
+		s.ticker = nil
+	}
+	if s.waitTimer != nil {
+		s.waitTimer.Stop()
+		s.waitTimer = nil
+	}
+}
+
+// loadRoom 讀取房間與遊戲模式
+func (s *RoomScheduler) loadRoom() (*models.Room, services.GameMode, bool) {
+	room, err := s.hub.roomService.GetRoom(s.roomID)
+	if err != nil {
+		s.logger.Error("獲取房間失敗", logging.Err(err))
+		return nil, nil, false
+	}
+
+	mode, err := s.hub.gameService.ModeForRoom(room)
+	if err != nil {
+		s.logger.Error("獲取遊戲模式失敗", logging.Err(err))
+		return nil, nil, false
+	}
+
+	return room, mode, true
+}
+
+// buildQuestionPayload 當前題目的 NEW_QUESTION 內容（模式內容加上共用欄位）
+func buildQuestionPayload(mode services.GameMode, room *models.Room) map[string]interface{} {
+	payload := mode.QuestionPayload(room)
+	payload["gameMode"] = mode.ID()
+	payload["questionIndex"] = room.CurrentQuestion - 1 // 前端使用 0-based index
+	payload["currentQuestion"] = room.CurrentQuestion
+	payload["totalQuestions"] = room.TotalQuestions
+	payload["timeLimit"] = room.QuestionTimeLimit
+	return payload
+}
+
+// broadcastTimer 廣播倒數時間
+func (s *RoomScheduler) broadcastTimer() {
+	s.broadcast("TIMER_UPDATE", map[string]interface{}{
+		"timeLeft":      s.timeLeft,
+		"questionIndex": s.questionNum,
+	})
+}
+
+// broadcast 廣播訊息給房間
+func (s *RoomScheduler) broadcast(msgType string, data map[string]interface{}) {
+	msg := Message{
+		Type: msgType,
+		Data: data,
+	}
+
+	if msgBytes, err := json.Marshal(msg); err == nil {
+		s.hub.BroadcastToRoom(s.roomID, msgBytes)
+	}
+}
+
+// sendPrivatePayloads 透過各玩家自己的連線發送模式的私密內容
+func (s *RoomScheduler) sendPrivatePayloads(mode services.GameMode, room *models.Room) {
+	dealer, ok := mode.(services.PrivatePayloader)
+	if !ok {
+		return
+	}
+
+	for playerID, payload := range dealer.PrivatePayloads(room) {
+		privateMsg := Message{
+			Type: "PRIVATE_INFO",
+			Data: payload,
+		}
+
+		msgBytes, err := json.Marshal(privateMsg)
+		if err != nil {
+			continue
+		}
+
+		if err := s.hub.SendToClient(s.roomID, playerID, msgBytes); err != nil {
+			s.logger.Warn("發送私密內容失敗", logging.KeyPlayerID, playerID, logging.Err(err))
+		}
+	}
+}
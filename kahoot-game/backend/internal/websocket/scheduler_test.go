@@ -0,0 +1,251 @@
+package websocket
+
+import (
+	"encoding/json"
+	"testing"
+
+	"kahoot-game/internal/config"
+	"kahoot-game/internal/models"
+)
+
+// newTestScheduler 建立已開始遊戲的房間與未啟動 run 的排程器，由測試直接呼叫 handleCommand
+func newTestScheduler(t *testing.T, totalQuestions int) (*Hub, *RoomScheduler) {
+	t.Helper()
+
+	hub := newTestHub(t, nil, nil, config.WebSocketConfig{})
+	room, err := hub.roomService.CreateRoom("主持人", "", totalQuestions, 0, nil, nil, "", false)
+	if err != nil {
+		t.Fatalf("建立房間失敗: %v", err)
+	}
+	for _, name := range []string{"小明", "小華"} {
+		if _, err := hub.roomService.AddPlayer(room.ID, name, name, "", ""); err != nil {
+			t.Fatalf("加入房間失敗: %v", err)
+		}
+	}
+	_, err = hub.roomService.MutateRoom(room.ID, func(room *models.Room) error {
+		mode, err := hub.gameService.ModeForRoom(room)
+		if err != nil {
+			return err
+		}
+		return mode.StartGame(room)
+	})
+	if err != nil {
+		t.Fatalf("開始遊戲失敗: %v", err)
+	}
+
+	scheduler := newRoomScheduler(hub, room.ID)
+	hub.schedulers[room.ID] = scheduler
+	t.Cleanup(func() {
+		scheduler.stopTimers()
+		scheduler.Stop()
+	})
+	return hub, scheduler
+}
+
+// schedulerState 處理完指令後預期的排程器與房間狀態
+type schedulerState struct {
+	phase    schedulerPhase
+	question int
+	timeLeft int
+	paused   bool
+	ticking  bool // 答題倒數中
+	waiting  bool // 等待進入下一題
+	status   models.RoomStatus
+	stopped  bool // 排程器已停止並從 Hub 移除
+}
+
+func TestRoomSchedulerStateMachine(t *testing.T) {
+	const timeLimit = 30 // 設定的預設答題秒數
+
+	start := schedulerCommand{kind: commandStartGame}
+	pause := schedulerCommand{kind: commandPause}
+	resume := schedulerCommand{kind: commandResume}
+	q := func(kind schedulerCommandType, questionNum int) schedulerCommand {
+		return schedulerCommand{kind: kind, questionNum: questionNum}
+	}
+	extend := func(questionNum, seconds int) schedulerCommand {
+		return schedulerCommand{kind: commandExtendTime, questionNum: questionNum, seconds: seconds}
+	}
+
+	tests := []struct {
+		name           string
+		totalQuestions int
+		commands       []schedulerCommand
+		want           schedulerState
+	}{
+		{
+			name:     "開始後進入答題倒數",
+			commands: []schedulerCommand{start},
+			want:     schedulerState{phase: phaseAnswering, question: 1, timeLeft: timeLimit, ticking: true, status: models.RoomStatusQuestionDisplay},
+		},
+		{
+			name:     "暫停時停止倒數",
+			commands: []schedulerCommand{start, pause},
+			want:     schedulerState{phase: phaseAnswering, question: 1, timeLeft: timeLimit, paused: true, status: models.RoomStatusQuestionDisplay},
+		},
+		{
+			name:     "繼續後恢復倒數",
+			commands: []schedulerCommand{start, pause, resume},
+			want:     schedulerState{phase: phaseAnswering, question: 1, timeLeft: timeLimit, ticking: true, status: models.RoomStatusQuestionDisplay},
+		},
+		{
+			name:     "延長答題時間",
+			commands: []schedulerCommand{start, extend(1, 15)},
+			want:     schedulerState{phase: phaseAnswering, question: 1, timeLeft: timeLimit + 15, ticking: true, status: models.RoomStatusQuestionDisplay},
+		},
+		{
+			name:     "忽略其他題目的延長指令",
+			commands: []schedulerCommand{start, extend(2, 15)},
+			want:     schedulerState{phase: phaseAnswering, question: 1, timeLeft: timeLimit, ticking: true, status: models.RoomStatusQuestionDisplay},
+		},
+		{
+			name:     "暫停中不因全員作答而結算",
+			commands: []schedulerCommand{start, pause, q(commandAllAnswered, 1)},
+			want:     schedulerState{phase: phaseAnswering, question: 1, timeLeft: timeLimit, paused: true, status: models.RoomStatusQuestionDisplay},
+		},
+		{
+			name:     "全員作答後顯示結果",
+			commands: []schedulerCommand{start, q(commandAllAnswered, 1)},
+			want:     schedulerState{phase: phaseWaiting, question: 1, timeLeft: timeLimit, waiting: true, status: models.RoomStatusShowResult},
+		},
+		{
+			name:     "略過題目後等待下一題",
+			commands: []schedulerCommand{start, q(commandSkipQuestion, 1)},
+			want:     schedulerState{phase: phaseWaiting, question: 1, timeLeft: timeLimit, waiting: true, status: models.RoomStatusQuestionDisplay},
+		},
+		{
+			name:     "結果畫面暫停時保留等待時間",
+			commands: []schedulerCommand{start, q(commandAllAnswered, 1), pause},
+			want:     schedulerState{phase: phaseWaiting, question: 1, timeLeft: timeLimit, paused: true, status: models.RoomStatusShowResult},
+		},
+		{
+			name:     "主持人在結果畫面略過時直接換題",
+			commands: []schedulerCommand{start, q(commandHostSkip, 1), q(commandHostSkip, 1)},
+			want:     schedulerState{phase: phaseAnswering, question: 2, timeLeft: timeLimit, ticking: true, status: models.RoomStatusQuestionDisplay},
+		},
+		{
+			name:           "最後一題結束後停止排程器",
+			totalQuestions: 1,
+			commands:       []schedulerCommand{start, q(commandAllAnswered, 1), q(commandHostSkip, 1)},
+			want:           schedulerState{phase: phaseIdle, question: 1, timeLeft: timeLimit, status: models.RoomStatusFinished, stopped: true},
+		},
+		{
+			name:     "主持人提前結束遊戲後停止排程器",
+			commands: []schedulerCommand{start, pause, q(commandEndGame, 1)},
+			want:     schedulerState{phase: phaseIdle, question: 1, timeLeft: timeLimit, status: models.RoomStatusFinished, stopped: true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			totalQuestions := tt.totalQuestions
+			if totalQuestions == 0 {
+				totalQuestions = 5
+			}
+			hub, s := newTestScheduler(t, totalQuestions)
+
+			for _, cmd := range tt.commands {
+				s.handleCommand(cmd)
+			}
+
+			if s.phase != tt.want.phase {
+				t.Errorf("phase = %d, 預期 %d", s.phase, tt.want.phase)
+			}
+			if s.questionNum != tt.want.question {
+				t.Errorf("questionNum = %d, 預期 %d", s.questionNum, tt.want.question)
+			}
+			if s.timeLeft != tt.want.timeLeft {
+				t.Errorf("timeLeft = %d, 預期 %d", s.timeLeft, tt.want.timeLeft)
+			}
+			if s.paused != tt.want.paused {
+				t.Errorf("paused = %v, 預期 %v", s.paused, tt.want.paused)
+			}
+			if ticking := s.ticker != nil; ticking != tt.want.ticking {
+				t.Errorf("倒數中 = %v, 預期 %v", ticking, tt.want.ticking)
+			}
+			if waiting := s.waitTimer != nil; waiting != tt.want.waiting {
+				t.Errorf("等待下一題 = %v, 預期 %v", waiting, tt.want.waiting)
+			}
+
+			room, err := hub.roomService.GetRoom(s.roomID)
+			if err != nil {
+				t.Fatalf("讀取房間失敗: %v", err)
+			}
+			if room.Status != tt.want.status {
+				t.Errorf("房間狀態 = %s, 預期 %s", room.Status, tt.want.status)
+			}
+			if room.IsPaused != tt.want.paused {
+				t.Errorf("房間暫停 = %v, 預期 %v", room.IsPaused, tt.want.paused)
+			}
+
+			stopped := s.ctx.Err() != nil && !hasScheduler(hub, s.roomID)
+			if stopped != tt.want.stopped {
+				t.Errorf("排程器已停止 = %v, 預期 %v", stopped, tt.want.stopped)
+			}
+		})
+	}
+}
+
+func TestAnswerLogHiddenUntilScored(t *testing.T) {
+	hub := newTestHub(t, nil, nil, config.WebSocketConfig{})
+	server := newTestServer(t, hub)
+
+	host := dial(t, server)
+	roomID := host.createRoom(map[string]interface{}{"totalQuestions": 1})
+	players := []*testConn{dial(t, server), dial(t, server)}
+	players[0].joinRoom(roomID, "小明")
+	players[1].joinRoom(roomID, "小華")
+
+	host.send("START_GAME", nil)
+	for _, player := range players {
+		player.expect("NEW_QUESTION")
+	}
+
+	players[0].send("SUBMIT_ANSWER", map[string]interface{}{"answer": "A"})
+	players[0].expect("ANSWER_SUBMITTED")
+
+	// 答題期間的事件不可包含答案內容
+	submitted, err := hub.roomLogs.ListEvents(roomID, 0, models.RoomEventAnswerSubmitted, 10)
+	if err != nil || len(submitted) != 1 {
+		t.Fatalf("answer_submitted 事件 = %v, %v", submitted, err)
+	}
+	var data map[string]interface{}
+	json.Unmarshal(submitted[0].EventData, &data)
+	for _, key := range []string{"answer", "isHost"} {
+		if _, exists := data[key]; exists {
+			t.Errorf("answer_submitted 事件包含 %s: %v", key, data)
+		}
+	}
+
+	players[1].send("SUBMIT_ANSWER", map[string]interface{}{"answer": "B"})
+	host.expect("SCORES_UPDATE")
+
+	// 計分後 scores_update 事件才附上所有答案
+	scored, err := hub.roomLogs.ListEvents(roomID, 0, models.RoomEventScoresUpdate, 10)
+	if err != nil || len(scored) != 1 {
+		t.Fatalf("scores_update 事件 = %v, %v", scored, err)
+	}
+	var scores struct {
+		Answers []struct {
+			PlayerID string `json:"playerId"`
+			Answer   string `json:"answer"`
+			IsHost   bool   `json:"isHost"`
+		} `json:"answers"`
+	}
+	json.Unmarshal(scored[0].EventData, &scores)
+	if len(scores.Answers) != 2 {
+		t.Fatalf("scores_update 答案 = %+v, 預期 2 筆", scores.Answers)
+	}
+	hosts := 0
+	for _, answer := range scores.Answers {
+		if answer.Answer != "A" && answer.Answer != "B" {
+			t.Errorf("玩家 %s 的答案 = %q", answer.PlayerID, answer.Answer)
+		}
+		if answer.IsHost {
+			hosts++
+		}
+	}
+	if hosts != 1 {
+		t.Errorf("主角答案數 = %d, 預期 1", hosts)
+	}
+}
@@ -0,0 +1,245 @@
+package websocket
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"kahoot-game/internal/logging"
+	"kahoot-game/internal/models"
+	"kahoot-game/internal/services"
+)
+
+// 未設定時的預設重連等待時間
+const defaultResumeGracePeriod = 30 * time.Second
+
+// playerSession 玩家連線工作階段
+// 連線中斷時不會立即移除玩家，而是保留資料等待玩家以 resume token 重新連線
+type playerSession struct {
+	token      string
+	playerID   string
+	playerName string
+	roomID     string
+	isHost     bool
+	hostToken  string
+
+	// 目前使用此工作階段的連線，斷線等待重連時為 nil
+	client *Client
+
+	// 重連等待計時器與世代編號（避免過期計時器誤刪已重連的玩家）
+	graceTimer *time.Timer
+	generation int
+}
+
+// newResumeToken 產生隨機的 resume token
+func newResumeToken() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("產生 resume token 失敗: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// createSession 為剛加入房間的客戶端建立工作階段，回傳 resume token
+func (h *Hub) createSession(client *Client) string {
+	token, err := newResumeToken()
+	if err != nil {
+		client.logger().Error("建立重連工作階段失敗", logging.Err(err))
+		return ""
+	}
+
+	h.sessionMutex.Lock()
+	h.sessions[token] = &playerSession{
+		token:      token,
+		playerID:   client.ID,
+		playerName: client.PlayerName,
+		roomID:     client.RoomID,
+		isHost:     client.IsHost,
+		hostToken:  client.hostToken,
+		client:     client,
+	}
+	h.sessionMutex.Unlock()
+
+	client.resumeToken = token
+	return token
+}
+
+// suspendSession 連線中斷時保留工作階段並開始重連倒數（需已持有 Hub 鎖）
+// 回傳 false 表示此連線沒有可保留的工作階段，應直接當作離開處理
+func (h *Hub) suspendSession(client *Client) bool {
+	h.sessionMutex.Lock()
+	defer h.sessionMutex.Unlock()
+
+	session, exists := h.sessions[client.resumeToken]
+	if !exists || session.client != client {
+		return false
+	}
+
+	session.client = nil
+	session.generation++
+	generation := session.generation
+	session.graceTimer = time.AfterFunc(h.resumeGrace, func() {
+		h.expireSession(session.token, generation)
+	})
+
+	h.logger.Info("玩家斷線，等待重連", logging.KeyRoomID, session.roomID, logging.KeyPlayerID, session.playerID, "grace", h.resumeGrace)
+	return true
+}
+
+// expireSession 重連等待時間結束，正式將玩家移出房間
+func (h *Hub) expireSession(token string, generation int) {
+	h.sessionMutex.Lock()
+	session, exists := h.sessions[token]
+	if !exists || session.client != nil || session.generation != generation {
+		h.sessionMutex.Unlock()
+		return
+	}
+	delete(h.sessions, token)
+	h.sessionMutex.Unlock()
+
+	h.logger.Info("玩家重連逾時，移出房間", logging.KeyRoomID, session.roomID, logging.KeyPlayerID, session.playerID)
+
+	h.handlePlayerLeave(h.offlineClient(session.playerID, session.playerName, session.roomID, session.isHost))
+}
+
+// resumeSession 將新連線接回既有的工作階段，沿用原本的玩家 ID
+func (h *Hub) resumeSession(client *Client, token string) error {
+	h.mutex.Lock()
+	h.sessionMutex.Lock()
+
+	session, exists := h.sessions[token]
+	if !exists {
+		h.sessionMutex.Unlock()
+		h.mutex.Unlock()
+		return fmt.Errorf("工作階段不存在或已過期")
+	}
+
+	if session.graceTimer != nil {
+		session.graceTimer.Stop()
+		session.graceTimer = nil
+	}
+	session.generation++
+
+	// 舊連線尚未被偵測到中斷時，由新連線接手
+	previous := session.client
+	if previous != nil && previous != client {
+		previous.replaced = true
+		h.removeClientFromRoom(previous, session.roomID)
+	}
+	session.client = client
+
+	client.ID = session.playerID
+	client.PlayerName = session.playerName
+	client.RoomID = session.roomID
+	client.IsHost = session.isHost
+	client.hostToken = session.hostToken
+	client.resumeToken = token
+
+	if h.rooms[session.roomID] == nil {
+		h.rooms[session.roomID] = make(map[*Client]bool)
+	}
+	h.rooms[session.roomID][client] = true
+
+	h.sessionMutex.Unlock()
+	h.mutex.Unlock()
+
+	if previous != nil && previous != client && previous.conn != nil {
+		previous.conn.Close()
+	}
+
+	return nil
+}
+
+// dropRoomSessions 房間刪除時清除該房間的所有工作階段
+func (h *Hub) dropRoomSessions(roomID string) {
+	h.sessionMutex.Lock()
+	defer h.sessionMutex.Unlock()
+
+	for token, session := range h.sessions {
+		if session.roomID != roomID {
+			continue
+		}
+		if session.graceTimer != nil {
+			session.graceTimer.Stop()
+		}
+		delete(h.sessions, token)
+	}
+}
+
+// dropPlayerSession 清除玩家在房間中的工作階段（例如被踢出時）
+func (h *Hub) dropPlayerSession(roomID, playerID string) {
+	h.sessionMutex.Lock()
+	defer h.sessionMutex.Unlock()
+
+	for token, session := range h.sessions {
+		if session.roomID != roomID || session.playerID != playerID {
+			continue
+		}
+		if session.graceTimer != nil {
+			session.graceTimer.Stop()
+		}
+		delete(h.sessions, token)
+	}
+}
+
+// markPlayerDisconnected 將斷線玩家標記為離線並通知房間（需已持有 Hub 鎖）
+func (h *Hub) markPlayerDisconnected(client *Client) {
+	if client.IsHost {
+		return
+	}
+
+	room, err := h.roomService.SetPlayerConnected(client.RoomID, client.ID, false)
+	if err != nil {
+		client.logger().Error("更新玩家連線狀態失敗", logging.Err(err))
+		return
+	}
+
+	disconnectMsg := Message{
+		Type: "PLAYER_DISCONNECTED",
+		Data: map[string]interface{}{
+			"playerId":    client.ID,
+			"playerName":  client.PlayerName,
+			"players":     room.GetPlayerList(),
+			"gracePeriod": h.resumeGrace.Seconds(),
+		},
+	}
+
+	if msgBytes, err := json.Marshal(disconnectMsg); err == nil {
+		h.broadcastToRoomExclude(client.RoomID, msgBytes, client)
+	}
+}
+
+// roomSnapshot 重連玩家需要的房間現況
+func (h *Hub) roomSnapshot(room *models.Room, playerID string) map[string]interface{} {
+	snapshot := map[string]interface{}{
+		"room":         room.PublicView(),
+		"totalPlayers": room.GetPlayerCount(),
+		"players":      room.GetPlayerList(),
+		"currentHost":  room.CurrentHost,
+	}
+
+	_, hasAnswered := room.Answers[playerID]
+	snapshot["hasAnswered"] = hasAnswered
+
+	if room.Status != models.RoomStatusQuestionDisplay || room.CurrentQuestion < 1 || room.CurrentQuestion > len(room.Questions) {
+		return snapshot
+	}
+
+	mode, err := h.gameService.ModeForRoom(room)
+	if err != nil {
+		return snapshot
+	}
+
+	snapshot["question"] = buildQuestionPayload(mode, room)
+
+	// 私密內容（例如臥底詞）只回給本人
+	if dealer, ok := mode.(services.PrivatePayloader); ok {
+		if payload, exists := dealer.PrivatePayloads(room)[playerID]; exists {
+			snapshot["privateInfo"] = payload
+		}
+	}
+
+	return snapshot
+}
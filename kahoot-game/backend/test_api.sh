@@ -27,6 +27,7 @@ ROOM_RESPONSE=$(curl -s -X POST "$BASE_URL/api/rooms" \
 
 echo "$ROOM_RESPONSE" | jq '.'
 ROOM_ID=$(echo "$ROOM_RESPONSE" | jq -r '.data.roomId')
+HOST_TOKEN=$(echo "$ROOM_RESPONSE" | jq -r '.data.hostToken')
 echo ""
 
 # 測試獲取房間資訊
@@ -36,7 +37,7 @@ if [ "$ROOM_ID" != "null" ] && [ "$ROOM_ID" != "" ]; then
     echo ""
     
     echo "5. 測試刪除房間..."
-    curl -s -X DELETE "$BASE_URL/api/rooms/$ROOM_ID" | jq '.' || echo "❌ 刪除房間失敗"
+    curl -s -X DELETE "$BASE_URL/api/rooms/$ROOM_ID" -H "Authorization: Bearer $HOST_TOKEN" | jq '.' || echo "❌ 刪除房間失敗"
     echo ""
 else
     echo "❌ 無法獲取房間ID，跳過房間相關測試"
@@ -122,8 +122,10 @@ export const apiService = {
     throw new Error(response.data.error || '獲取房間失敗')
   },
 
-  async deleteRoom(roomId: string): Promise<void> {
-    const response = await api.delete<APIResponse<void>>(`/rooms/${roomId}`)
+  async deleteRoom(roomId: string, hostToken: string): Promise<void> {
+    const response = await api.delete<APIResponse<void>>(`/rooms/${roomId}`, {
+      headers: { Authorization: `Bearer ${hostToken}` }
+    })
     if (!response.data.success) {
       throw new Error(response.data.error || '刪除房間失敗')
     }
@@ -138,6 +138,7 @@ export interface RoomCreatedResponse {
   questionTimeLimit: number
   qrCode: string
   joinUrl: string
+  hostToken: string
   createdAt: Date
 }
 